@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/ai8future/serper_mod/serper"
+)
+
+// cluster runs `serper cluster <keywords.txt>`: it searches every keyword
+// in the configured market and groups those sharing top results. The file
+// holds one keyword per line, as for `serper volatility`.
+func cluster(ctx context.Context, client *serper.Client, cfg Config, args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s", usage)
+	}
+	if cfg.Format != "json" && cfg.Format != "csv" {
+		return fmt.Errorf("unknown format %q (want json or csv)", cfg.Format)
+	}
+	keywords, err := readKeywords(args[0])
+	if err != nil {
+		return err
+	}
+	sink, err := webhookSink(cfg)
+	if err != nil {
+		return err
+	}
+	res, err := client.ClusterKeywords(ctx, serper.KeywordClusterConfig{
+		Keywords:  keywords,
+		Market:    serper.Market{GL: cfg.GL, HL: cfg.HL, Location: cfg.Location},
+		TopN:      cfg.Num,
+		MinShared: cfg.ClusterMinShared,
+		Strict:    cfg.ClusterStrict,
+		Budget:    serper.NewCreditBudget(cfg.MaxCredits),
+		Sink:      sink,
+	})
+	return writeThenNotify(res, err, func(res *serper.KeywordClusterResult) error {
+		if cfg.Format == "csv" {
+			return res.WriteCSV(w)
+		}
+		return writeJSON(w, res)
+	})
+}
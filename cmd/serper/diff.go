@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ai8future/serper_mod/serper"
+)
+
+// diff runs `serper diff <old.json> <new.json>`. Each file holds a search
+// response as printed by `serper <query>`, or a snapshot as printed by
+// `serper history`. SERPER_FORMAT selects json, text or markdown output.
+func diff(cfg Config, args []string, w io.Writer) error {
+	if len(args) != 2 {
+		return fmt.Errorf("%s", usage)
+	}
+	var render func(*serper.SearchDiff) error
+	switch cfg.Format {
+	case "json":
+		render = func(d *serper.SearchDiff) error { return writeJSON(w, d) }
+	case "text":
+		render = func(d *serper.SearchDiff) error { _, err := io.WriteString(w, d.Text()); return err }
+	case "markdown", "md":
+		render = func(d *serper.SearchDiff) error { _, err := io.WriteString(w, d.Markdown()); return err }
+	default:
+		return fmt.Errorf("unknown format %q (want json, text or markdown)", cfg.Format)
+	}
+
+	a, err := readSearchResponse(args[0])
+	if err != nil {
+		return err
+	}
+	b, err := readSearchResponse(args[1])
+	if err != nil {
+		return err
+	}
+	return render(serper.DiffSearch(a, b))
+}
+
+// readSearchResponse loads a search response, unwrapping a snapshot.
+func readSearchResponse(path string) (*serper.SearchResponse, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snap serper.Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(snap.Response) > 0 {
+		data = snap.Response
+	}
+	var resp serper.SearchResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ai8future/serper_mod/serper"
+)
+
+// expand runs `serper expand <query>`: it explores the People Also Ask and
+// related-search graph around the query and prints it as JSON, DOT or CSV.
+func expand(ctx context.Context, client *serper.Client, cfg Config, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", usage)
+	}
+	if cfg.Format != "json" && cfg.Format != "dot" && cfg.Format != "csv" {
+		return fmt.Errorf("unknown format %q (want json, dot or csv)", cfg.Format)
+	}
+	sink, err := webhookSink(cfg)
+	if err != nil {
+		return err
+	}
+	g, err := client.ExpandQueries(ctx, serper.ExpandConfig{
+		Seeds:    []string{strings.Join(args, " ")},
+		Market:   serper.Market{GL: cfg.GL, HL: cfg.HL, Location: cfg.Location},
+		MaxDepth: cfg.ExpandDepth,
+		MaxNodes: cfg.ExpandNodes,
+		Budget:   serper.NewCreditBudget(cfg.MaxCredits),
+		Sink:     sink,
+	})
+	return writeThenNotify(g, err, func(g *serper.QueryGraph) error {
+		switch cfg.Format {
+		case "dot":
+			_, err := io.WriteString(w, g.DOT())
+			return err
+		case "csv":
+			return g.WriteCSV(w)
+		}
+		return writeJSON(w, g)
+	})
+}
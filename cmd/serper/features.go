@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ai8future/serper_mod/serper"
+)
+
+// features runs `serper features <keywords.txt>`: it searches every keyword
+// and reports which SERP features each one triggered. Searches that fail
+// are skipped; the report is still written and the failures are returned
+// together.
+func features(ctx context.Context, client *serper.Client, cfg Config, args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s", usage)
+	}
+	if cfg.Format != "json" && cfg.Format != "text" && cfg.Format != "csv" {
+		return fmt.Errorf("unknown format %q (want json, text or csv)", cfg.Format)
+	}
+	sink, err := webhookSink(cfg)
+	if err != nil {
+		return err
+	}
+	keywords, err := readKeywords(args[0])
+	if err != nil {
+		return err
+	}
+
+	budget := serper.NewCreditBudget(cfg.MaxCredits)
+	var resps []*serper.SearchResponse
+	var errs []error
+	for _, kw := range keywords {
+		req := searchRequest(cfg, []string{kw})
+		resp, err := func() (*serper.SearchResponse, error) {
+			if err := client.ReserveCredits(ctx, budget, "search", req); err != nil {
+				return nil, err
+			}
+			return client.Search(ctx, req)
+		}()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("search %q: %w", kw, err))
+		} else {
+			// Report under the keyword as written, whatever Serper echoes.
+			resp.SearchParameters.Q = kw
+			resps = append(resps, resp)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	report := serper.SummarizeFeatures(resps)
+	switch cfg.Format {
+	case "text":
+		_, err = io.WriteString(w, report.Text())
+	case "csv":
+		err = report.WriteCSV(w)
+	default:
+		err = writeJSON(w, report)
+	}
+	if err := serper.Notify(ctx, sink, serper.EventFeaturesComplete, report); err != nil {
+		errs = append(errs, fmt.Errorf("notify: %w", err))
+	}
+	return errors.Join(append([]error{err}, errs...)...)
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	serpermod "github.com/ai8future/serper_mod"
+	"github.com/ai8future/serper_mod/serper"
+)
+
+const (
+	// gatewayMaxBody bounds a POSTed search request.
+	gatewayMaxBody = 64 << 10
+	// gatewayMaxCallers bounds the rate limiter's table; callers whose
+	// allowance has refilled, or else the least recently seen, are
+	// forgotten when it fills up.
+	gatewayMaxCallers = 4096
+)
+
+// gatewayVerticals maps /v1/{vertical} to the Client method it calls.
+var gatewayVerticals = map[string]func(*serper.Client, context.Context, *serper.SearchRequest) (any, error){
+	"search":       vertical((*serper.Client).Search),
+	"news":         vertical((*serper.Client).News),
+	"images":       vertical((*serper.Client).Images),
+	"videos":       vertical((*serper.Client).Videos),
+	"places":       vertical((*serper.Client).Places),
+	"scholar":      vertical((*serper.Client).Scholar),
+	"shopping":     vertical((*serper.Client).Shopping),
+	"autocomplete": vertical((*serper.Client).Autocomplete),
+}
+
+// vertical adapts a Client search method to gatewayVerticals.
+func vertical[T any](method func(*serper.Client, context.Context, *serper.SearchRequest) (*T, error)) func(*serper.Client, context.Context, *serper.SearchRequest) (any, error) {
+	return func(c *serper.Client, ctx context.Context, req *serper.SearchRequest) (any, error) {
+		return method(c, ctx, req)
+	}
+}
+
+// gatewayToken is a caller token accepted by the gateway and the Serper
+// key its requests are sent with. An empty apiKey uses SERPER_API_KEY.
+type gatewayToken struct {
+	token  string
+	apiKey string
+}
+
+// loadGatewayTokens reads a SERPER_GATEWAY_TOKENS file: one token per
+// line, optionally followed by whitespace and the Serper key to use for
+// it. Blank lines and lines starting with # are skipped.
+func loadGatewayTokens(path string) ([]gatewayToken, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []gatewayToken
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("%s:%d: want a token and an optional Serper key", path, n)
+		}
+		t := gatewayToken{token: fields[0]}
+		if len(fields) == 2 {
+			t.apiKey = fields[1]
+		}
+		out = append(out, t)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%s: no tokens", path)
+	}
+	return out, nil
+}
+
+// gateway guards the search routes of `serper serve`. With tokens set,
+// every request must present one and runs with the Serper key it maps to;
+// without, the routes are open and use SERPER_API_KEY. limiter, if set,
+// caps each caller's request rate.
+type gateway struct {
+	client  *serper.Client
+	cfg     Config
+	tokens  []gatewayToken
+	limiter *rateLimiter
+}
+
+// guard authenticates and rate-limits a request before passing it to h.
+// The token is read from an "Authorization: Bearer" header. Only feed
+// routes, whose readers often cannot set headers, set queryToken to also
+// accept a token query parameter; elsewhere it would only leak tokens into
+// access logs.
+func (g *gateway) guard(h http.HandlerFunc, queryToken bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		caller := clientIP(r)
+		if g.tokens != nil {
+			t, ok := authenticate(g.tokens, r, queryToken)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="serper"`)
+				writeError(w, http.StatusUnauthorized, "missing or unknown API token")
+				return
+			}
+			caller = t.token
+			r = r.WithContext(serper.WithAPIKey(r.Context(), t.apiKey))
+		}
+		if g.limiter != nil {
+			if wait, ok := g.limiter.allow(caller); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+		}
+		h(w, r)
+	}
+}
+
+// authenticate returns which of tokens was presented with r. Every token
+// is compared in constant time so response timing does not reveal them.
+func authenticate(tokens []gatewayToken, r *http.Request, queryToken bool) (gatewayToken, bool) {
+	var presented string
+	if queryToken {
+		presented = r.URL.Query().Get("token")
+	}
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		scheme, value, _ := strings.Cut(auth, " ")
+		if !strings.EqualFold(scheme, "Bearer") {
+			return gatewayToken{}, false
+		}
+		presented = strings.TrimSpace(value)
+	}
+	if presented == "" {
+		return gatewayToken{}, false
+	}
+	var found gatewayToken
+	ok := false
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(t.token)) == 1 {
+			found, ok = t, true
+		}
+	}
+	return found, ok
+}
+
+// search serves /v1/{vertical}. GET takes the request fields as query
+// parameters and POST a SearchRequest as JSON. Fields left empty default
+// to the CLI configuration. The response is the vertical's JSON as
+// returned by the Client; errors are {"error": "..."} with the status of
+// the typed error.
+func (g *gateway) search(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("vertical")
+	call, ok := gatewayVerticals[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown vertical %q", name))
+		return
+	}
+	req, err := gatewayRequest(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Num == 0 {
+		req.Num = g.cfg.Num
+	}
+	req.GL = firstNonEmpty(req.GL, g.cfg.GL)
+	req.HL = firstNonEmpty(req.HL, g.cfg.HL)
+	req.Location = firstNonEmpty(req.Location, g.cfg.Location)
+
+	resp, err := call(g.client, r.Context(), req)
+	if err != nil {
+		writeError(w, httpStatus(err), err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// gatewayRequest decodes the search request from the query string of a
+// GET or the JSON body of a POST.
+func gatewayRequest(w http.ResponseWriter, r *http.Request) (*serper.SearchRequest, error) {
+	var req serper.SearchRequest
+	if r.Method == http.MethodPost {
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, gatewayMaxBody))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			return nil, fmt.Errorf("invalid request body: %w", err)
+		}
+		return &req, nil
+	}
+	q := r.URL.Query()
+	req.Q = q.Get("q")
+	req.GL = q.Get("gl")
+	req.HL = q.Get("hl")
+	req.Location = q.Get("location")
+	req.LL = q.Get("ll")
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"num", &req.Num}, {"page", &req.Page}} {
+		if v := q.Get(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be an integer", p.name)
+			}
+			*p.dst = n
+		}
+	}
+	return &req, nil
+}
+
+// healthz reports that the process is up.
+func healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = fmt.Fprintln(w, `{"status":"ok"}`)
+}
+
+// readyz reports whether the server accepts traffic: it answers 503 once
+// ctx is cancelled and the server is draining. It does not call Serper,
+// since every search costs credits.
+func readyz(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if ctx.Err() != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"status": "shutting down"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "ready", "version": serpermod.AppVersion})
+	}
+}
+
+// writeError writes msg as a JSON error body with status.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// clientIP returns the address a request came from, without its port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimiter is a token bucket per caller: each holds up to burst
+// requests and refills at perMinute requests a minute.
+type rateLimiter struct {
+	perMinute int
+	burst     int
+	now       func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns a limiter allowing perMinute requests a minute
+// per caller, in bursts of up to a minute's allowance, or nil if perMinute
+// is not positive.
+func newRateLimiter(perMinute int) *rateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &rateLimiter{perMinute: perMinute, burst: perMinute, now: time.Now, buckets: make(map[string]*bucket)}
+}
+
+// allow takes one request from caller's bucket. When it is empty, allow
+// returns false and how long until the next request is allowed.
+func (l *rateLimiter) allow(caller string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	perSecond := float64(l.perMinute) / 60
+	b, ok := l.buckets[caller]
+	if !ok {
+		if len(l.buckets) >= gatewayMaxCallers {
+			l.prune(now, perSecond)
+		}
+		b = &bucket{tokens: float64(l.burst), last: now}
+		l.buckets[caller] = b
+	}
+	b.tokens = math.Min(float64(l.burst), b.tokens+now.Sub(b.last).Seconds()*perSecond)
+	b.last = now
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / perSecond * float64(time.Second)), false
+	}
+	b.tokens--
+	return 0, true
+}
+
+// prune forgets callers whose buckets have refilled, which behave the same
+// as new callers. If none has, it forgets the least recently seen caller so
+// the table stays bounded; that caller starts again with a full bucket.
+func (l *rateLimiter) prune(now time.Time, perSecond float64) {
+	var oldest string
+	for caller, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*perSecond >= float64(l.burst) {
+			delete(l.buckets, caller)
+		} else if oldest == "" || b.last.Before(l.buckets[oldest].last) {
+			oldest = caller
+		}
+	}
+	if len(l.buckets) >= gatewayMaxCallers {
+		delete(l.buckets, oldest)
+	}
+}
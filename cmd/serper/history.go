@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ai8future/serper_mod/serper"
+)
+
+// historyEntry is one line of `serper history` output.
+type historyEntry struct {
+	Time     time.Time `json:"time"`
+	Credits  int       `json:"credits"`
+	Vertical string    `json:"vertical"`
+	Query    string    `json:"query"`
+	GL       string    `json:"gl"`
+	HL       string    `json:"hl"`
+	Location string    `json:"location,omitempty"`
+}
+
+// history runs `serper history <query>` against SERPER_SNAPSHOT_DIR. It
+// lists the query's snapshots as JSON lines, or, when SERPER_AT is set,
+// prints the stored response nearest to that time.
+func history(ctx context.Context, cfg Config, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", usage)
+	}
+	if cfg.SnapshotDir == "" {
+		return fmt.Errorf("history: SERPER_SNAPSHOT_DIR is not set")
+	}
+	store, err := serper.NewJSONLSnapshotStore(cfg.SnapshotDir)
+	if err != nil {
+		return err
+	}
+	key := serper.SnapshotKey{
+		Query:    strings.Join(args, " "),
+		Vertical: cfg.Vertical,
+		GL:       cfg.GL,
+		HL:       cfg.HL,
+		Location: cfg.Location,
+	}
+
+	if cfg.At != "" {
+		at, err := parseTime(cfg.At)
+		if err != nil {
+			return err
+		}
+		snap, err := store.Nearest(ctx, key, at)
+		if err != nil {
+			return err
+		}
+		return writeJSON(w, snap)
+	}
+
+	snaps, err := store.History(ctx, key)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	for _, s := range snaps {
+		if err := enc.Encode(historyEntry{
+			Time:     s.Time,
+			Credits:  s.Credits,
+			Vertical: s.Vertical,
+			Query:    s.Query,
+			GL:       s.GL,
+			HL:       s.HL,
+			Location: s.Location,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseTime accepts an RFC 3339 timestamp or a YYYY-MM-DD date (UTC).
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (want RFC 3339 or YYYY-MM-DD)", s)
+}
@@ -5,9 +5,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	chassis "github.com/ai8future/chassis-go/v11"
@@ -20,16 +23,61 @@ import (
 	"github.com/ai8future/serper_mod/serper"
 )
 
+const usage = `usage: serper <query>
+       serper search <query>     (or serper -- <query>, for queries starting with a command name)
+       serper scholar <query>
+       serper serve
+       serper watch news <query>...
+       serper history <query>
+       serper diff <old.json> <new.json>
+       serper track <config.json>
+       serper volatility <keywords.txt>
+       serper cluster <keywords.txt>
+       serper expand <query>
+       serper suggest <seed>
+       serper sov <keywords.txt>
+       serper features <keywords.txt>
+       serper mcp [stdio|http]`
+
+// maxCacheEntries bounds the in-memory response cache.
+const maxCacheEntries = 1024
+
 // Config holds CLI configuration loaded from environment.
 type Config struct {
-	APIKey   string        `env:"SERPER_API_KEY" required:"true"`
-	BaseURL  string        `env:"SERPER_BASE_URL" default:"https://google.serper.dev"`
-	Num      int           `env:"SERPER_NUM" default:"10"`
-	GL       string        `env:"SERPER_GL" default:"us"`
-	HL       string        `env:"SERPER_HL" default:"en"`
-	Location string        `env:"SERPER_LOCATION" required:"false"`
-	Timeout  time.Duration `env:"SERPER_TIMEOUT" default:"30s"`
-	LogLevel string        `env:"LOG_LEVEL" default:"error"`
+	APIKey           string        `env:"SERPER_API_KEY" required:"true"`
+	BaseURL          string        `env:"SERPER_BASE_URL" default:"https://google.serper.dev"`
+	Num              int           `env:"SERPER_NUM" default:"10"`
+	GL               string        `env:"SERPER_GL" default:"us"`
+	HL               string        `env:"SERPER_HL" default:"en"`
+	Location         string        `env:"SERPER_LOCATION" required:"false"`
+	Timeout          time.Duration `env:"SERPER_TIMEOUT" default:"30s"`
+	Format           string        `env:"SERPER_FORMAT" default:"json"`
+	Addr             string        `env:"SERPER_ADDR" default:"127.0.0.1:8080"`
+	MCPAddr          string        `env:"SERPER_MCP_ADDR" default:"127.0.0.1:8080"`
+	MCPSharedKey     bool          `env:"SERPER_MCP_SHARED_KEY" default:"false"`
+	CacheTTL         time.Duration `env:"SERPER_CACHE_TTL" default:"15m"`
+	CacheDir         string        `env:"SERPER_CACHE_DIR" required:"false"`
+	WatchInterval    time.Duration `env:"SERPER_WATCH_INTERVAL" default:"15m"`
+	WatchState       string        `env:"SERPER_WATCH_STATE" default:"serper-watch.json"`
+	MaxCredits       int           `env:"SERPER_MAX_CREDITS" default:"0"`
+	WebhookURL       string        `env:"SERPER_WEBHOOK_URL" required:"false"`
+	WebhookSecret    string        `env:"SERPER_WEBHOOK_SECRET" required:"false"`
+	DeadLetter       string        `env:"SERPER_WEBHOOK_DEAD_LETTER" default:"serper-webhook-dead.jsonl"`
+	SnapshotDir      string        `env:"SERPER_SNAPSHOT_DIR" required:"false"`
+	Vertical         string        `env:"SERPER_VERTICAL" default:"search"`
+	At               string        `env:"SERPER_AT" required:"false"`
+	ClusterMinShared int           `env:"SERPER_CLUSTER_MIN_SHARED" default:"3"`
+	ClusterStrict    bool          `env:"SERPER_CLUSTER_STRICT" default:"false"`
+	ExpandDepth      int           `env:"SERPER_EXPAND_DEPTH" default:"2"`
+	ExpandNodes      int           `env:"SERPER_EXPAND_NODES" default:"100"`
+	SovVerticals     string        `env:"SERPER_SOV_VERTICALS" default:"search,news"`
+	AllowFile        string        `env:"SERPER_ALLOW_FILE" required:"false"`
+	BlockFile        string        `env:"SERPER_BLOCK_FILE" required:"false"`
+	Renumber         bool          `env:"SERPER_RENUMBER" default:"false"`
+	GatewayTokens    string        `env:"SERPER_GATEWAY_TOKENS" required:"false"`
+	GatewayRate      int           `env:"SERPER_GATEWAY_RATE" default:"0"`
+	GatewayOpen      bool          `env:"SERPER_GATEWAY_OPEN" default:"false"`
+	LogLevel         string        `env:"LOG_LEVEL" default:"error"`
 }
 
 func main() {
@@ -45,47 +93,203 @@ func main() {
 	logger.Info("starting", "chassis_version", chassis.Version)
 
 	if len(os.Args) < 2 {
-		fmt.Fprintln(os.Stderr, "usage: serper <query>")
+		fmt.Fprintln(os.Stderr, usage)
 		os.Exit(1)
 	}
-	query := strings.Join(os.Args[1:], " ")
 
 	caller := call.New(
 		call.WithTimeout(cfg.Timeout),
 		call.WithRetry(3, 500*time.Millisecond),
 	)
 
-	client, err := serper.New(cfg.APIKey,
+	opts := []serper.Option{
 		serper.WithBaseURL(cfg.BaseURL),
 		serper.WithDoer(caller),
-	)
+	}
+	// SERPER_CACHE_DIR persists responses between runs, so batch commands
+	// such as cluster can be re-run without spending credits again. Without
+	// it only the long-running servers, serve and mcp, benefit from a cache.
+	// watch must see fresh results on every poll.
+	switch {
+	case cfg.CacheTTL <= 0 || os.Args[1] == "watch":
+	case cfg.CacheDir != "":
+		cache, err := serper.NewFileCache(cfg.CacheDir, cfg.CacheTTL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, serper.WithCache(cache))
+	case os.Args[1] == "serve" || os.Args[1] == "mcp":
+		opts = append(opts, serper.WithCache(serper.NewMemoryCache(cfg.CacheTTL, maxCacheEntries)))
+	}
+	if cfg.SnapshotDir != "" {
+		store, err := serper.NewJSONLSnapshotStore(cfg.SnapshotDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, serper.WithSnapshotStore(store))
+	}
+	filter, err := domainFilter(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if filter != nil {
+		opts = append(opts, serper.WithDomainFilter(filter))
+	}
+	client, err := serper.New(cfg.APIKey, opts...)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 
-	logger.Debug("searching", "query", query, "num", cfg.Num, "gl", cfg.GL)
+	logger.Debug("running", "args", os.Args[1:], "num", cfg.Num, "gl", cfg.GL, "format", cfg.Format)
 
 	// call.Client already enforces per-attempt timeouts and handles retries,
-	// so no additional context timeout is needed here.
-	resp, err := client.Search(context.Background(), &serper.SearchRequest{
-		Q:        query,
+	// so the context only carries cancellation on SIGINT/SIGTERM.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, client, cfg, os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	registry.ShutdownCLI(0)
+}
+
+// run dispatches the subcommand named by args[0], falling back to a web
+// search over all args, and writes the result to w. "search" and "--"
+// force a web search over the remaining args, so queries that start with a
+// command name, such as "history of rome", can still be sent.
+func run(ctx context.Context, client *serper.Client, cfg Config, args []string, w io.Writer) error {
+	switch args[0] {
+	case "search", "--":
+		if len(args) < 2 {
+			return fmt.Errorf("%s", usage)
+		}
+		return webSearch(ctx, client, cfg, args[1:], w)
+	case "serve":
+		return serve(ctx, client, cfg)
+	case "watch":
+		return watch(ctx, client, cfg, args[1:], w)
+	case "history":
+		return history(ctx, cfg, args[1:], w)
+	case "diff":
+		return diff(cfg, args[1:], w)
+	case "track":
+		return track(ctx, client, cfg, args[1:], w)
+	case "volatility":
+		return volatility(ctx, cfg, args[1:], w)
+	case "cluster":
+		return cluster(ctx, client, cfg, args[1:], w)
+	case "expand":
+		return expand(ctx, client, cfg, args[1:], w)
+	case "suggest":
+		return suggest(ctx, client, cfg, args[1:], w)
+	case "sov":
+		return sov(ctx, client, cfg, args[1:], w)
+	case "features":
+		return features(ctx, client, cfg, args[1:], w)
+	case "mcp":
+		return mcp(ctx, client, cfg, args[1:], w)
+	case "scholar":
+		if len(args) < 2 {
+			return fmt.Errorf("%s", usage)
+		}
+		if !scholarFormats[cfg.Format] {
+			return unknownFormatError(cfg.Format)
+		}
+		resp, err := client.Scholar(ctx, searchRequest(cfg, args[1:]))
+		if err != nil {
+			return err
+		}
+		return writeScholar(w, cfg.Format, resp)
+	default:
+		return webSearch(ctx, client, cfg, args, w)
+	}
+}
+
+// webSearch runs a web search for the query words and writes it as JSON.
+func webSearch(ctx context.Context, client *serper.Client, cfg Config, words []string, w io.Writer) error {
+	if cfg.Format != "json" {
+		return fmt.Errorf("format %q is not supported for web search", cfg.Format)
+	}
+	resp, err := client.Search(ctx, searchRequest(cfg, words))
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, resp)
+}
+
+// searchRequest builds a request for the query words using the configured defaults.
+func searchRequest(cfg Config, words []string) *serper.SearchRequest {
+	return &serper.SearchRequest{
+		Q:        strings.Join(words, " "),
 		Num:      cfg.Num,
 		GL:       cfg.GL,
 		HL:       cfg.HL,
 		Location: cfg.Location,
-	})
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
 	}
+}
 
-	out, err := json.MarshalIndent(resp, "", "  ")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error formatting response: %v\n", err)
-		os.Exit(1)
+// domainFilter loads the allow and block lists named by SERPER_ALLOW_FILE
+// and SERPER_BLOCK_FILE. It returns nil when neither is set.
+func domainFilter(cfg Config) (*serper.DomainFilter, error) {
+	if cfg.AllowFile == "" && cfg.BlockFile == "" {
+		return nil, nil
+	}
+	f := &serper.DomainFilter{Renumber: cfg.Renumber}
+	var err error
+	if cfg.AllowFile != "" {
+		if f.Allow, err = serper.LoadDomainRules(cfg.AllowFile); err != nil {
+			return nil, err
+		}
 	}
-	fmt.Println(string(out))
+	if cfg.BlockFile != "" {
+		if f.Block, err = serper.LoadDomainRules(cfg.BlockFile); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
 
-	registry.ShutdownCLI(0)
+// writeScholar writes Scholar results as JSON, BibTeX, RIS or CSL-JSON.
+func writeScholar(w io.Writer, format string, resp *serper.ScholarResponse) error {
+	switch format {
+	case "json":
+		return writeJSON(w, resp)
+	case "bibtex":
+		_, err := io.WriteString(w, resp.BibTeX())
+		return err
+	case "ris":
+		_, err := io.WriteString(w, resp.RIS())
+		return err
+	case "csl-json", "csl":
+		out, err := resp.CSLJSON()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	default:
+		return unknownFormatError(format)
+	}
+}
+
+// scholarFormats lists the SERPER_FORMAT values accepted by the scholar command.
+var scholarFormats = map[string]bool{"json": true, "bibtex": true, "ris": true, "csl-json": true, "csl": true}
+
+func unknownFormatError(format string) error {
+	return fmt.Errorf("unknown format %q (want json, bibtex, ris or csl-json)", format)
+}
+
+// writeJSON pretty-prints v to w.
+func writeJSON(w io.Writer, v any) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("formatting response: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
 }
@@ -1,12 +1,24 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	chassis "github.com/ai8future/chassis-go/v11"
 	chassisconfig "github.com/ai8future/chassis-go/v11/config"
 	"github.com/ai8future/chassis-go/v11/testkit"
+
+	"github.com/ai8future/serper_mod/serper"
 )
 
 func TestMain(m *testing.M) {
@@ -46,3 +58,435 @@ func TestConfig_PanicsWithoutAPIKey(t *testing.T) {
 	}()
 	_ = chassisconfig.MustLoad[Config]()
 }
+
+func TestDomainFilter(t *testing.T) {
+	if f, err := domainFilter(Config{}); f != nil || err != nil {
+		t.Errorf("no lists: got %v, %v", f, err)
+	}
+
+	dir := t.TempDir()
+	block := filepath.Join(dir, "block.txt")
+	if err := os.WriteFile(block, []byte("# farms\nfarm.com\n*.spam.net\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := domainFilter(Config{BlockFile: block, Renumber: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Allow) != 0 || len(f.Block) != 2 || !f.Renumber {
+		t.Errorf("filter: got %+v", f)
+	}
+
+	if _, err := domainFilter(Config{AllowFile: filepath.Join(dir, "missing.txt")}); err == nil {
+		t.Error("expected an error for a missing allow list")
+	}
+}
+
+// newTestClient returns a client pointed at a test server that answers
+// every request with body.
+func newTestClient(t *testing.T, body string) *serper.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	client, err := serper.New("test-key", serper.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("serper.New: %v", err)
+	}
+	return client
+}
+
+func TestRun_ScholarFormats(t *testing.T) {
+	client := newTestClient(t, `{"organic":[{"title":"Deep Nets","publicationInfo":"J Smith - Nature, 2021 - nature.com","link":"https://example.com/p"}]}`)
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"json", `"title": "Deep Nets"`},
+		{"bibtex", "@article{smith2021deep,"},
+		{"ris", "TY  - JOUR"},
+		{"csl-json", `"container-title": "Nature"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out bytes.Buffer
+			cfg := Config{Num: 10, Format: tt.format}
+			if err := run(context.Background(), client, cfg, []string{"scholar", "deep", "nets"}, &out); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("output should contain %q:\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestRun_RejectsUnknownFormat(t *testing.T) {
+	client := newTestClient(t, `{"organic":[]}`)
+	var out bytes.Buffer
+	if err := run(context.Background(), client, Config{Format: "yaml"}, []string{"scholar", "x"}, &out); err == nil {
+		t.Error("expected error for unknown scholar format")
+	}
+	if err := run(context.Background(), client, Config{Format: "bibtex"}, []string{"golang"}, &out); err == nil {
+		t.Error("expected error for citation format on web search")
+	}
+}
+
+func TestRun_WebSearch(t *testing.T) {
+	client := newTestClient(t, `{"organic":[{"title":"Go","link":"https://go.dev","position":1}]}`)
+	var out bytes.Buffer
+	if err := run(context.Background(), client, Config{Format: "json"}, []string{"golang", "docs"}, &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !strings.Contains(out.String(), `"link": "https://go.dev"`) {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestRun_SearchEscapesCommands(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req serper.SearchRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		queries = append(queries, r.URL.Path+" "+req.Q)
+		_, _ = io.WriteString(w, `{"organic":[]}`)
+	}))
+	defer srv.Close()
+	client, err := serper.New("test-key", serper.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"search", "history", "of", "rome"},
+		{"--", "history", "of", "rome"},
+	} {
+		if err := run(context.Background(), client, Config{Format: "json"}, args, io.Discard); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+	want := []string{"/search history of rome", "/search history of rome"}
+	if strings.Join(queries, ",") != strings.Join(want, ",") {
+		t.Errorf("searches: got %q, want %q", queries, want)
+	}
+	if err := run(context.Background(), client, Config{Format: "json"}, []string{"search"}, io.Discard); err == nil {
+		t.Error("expected usage for search without a query")
+	}
+}
+
+func TestRun_WatchNews(t *testing.T) {
+	var events []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		events = append(events, r.Header.Get(serper.WebhookEventHeader))
+	}))
+	defer receiver.Close()
+
+	client := newTestClient(t, `{"news":[{"title":"Acme launches","link":"https://news.example/acme"}]}`)
+	cfg := Config{
+		Num:           10,
+		WatchInterval: time.Millisecond,
+		WatchState:    filepath.Join(t.TempDir(), "watch.json"),
+		MaxCredits:    3,
+		WebhookURL:    receiver.URL,
+		WebhookSecret: "s3cret",
+	}
+	var out bytes.Buffer
+	err := run(context.Background(), client, cfg, []string{"watch", "news", "acme corp"}, &out)
+	if !errors.Is(err, serper.ErrCreditLimit) {
+		t.Fatalf("run: got %v, want credit limit after three polls", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], `"query":"acme corp"`) {
+		t.Errorf("the article should be emitted once, got:\n%s", out.String())
+	}
+	if len(events) != 1 || events[0] != serper.EventWatchNewItems {
+		t.Errorf("webhook events: got %v", events)
+	}
+}
+
+func TestRun_History(t *testing.T) {
+	dir := t.TempDir()
+	store, err := serper.NewJSONLSnapshotStore(dir)
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"organic":[{"title":"Go","link":"https://go.dev","position":1}],"credits":1}`)
+	}))
+	defer srv.Close()
+	client, err := serper.New("test-key", serper.WithBaseURL(srv.URL), serper.WithSnapshotStore(store))
+	if err != nil {
+		t.Fatalf("serper.New: %v", err)
+	}
+
+	cfg := Config{Format: "json", Num: 10, GL: "us", HL: "en", Vertical: "search", SnapshotDir: dir}
+	ctx := context.Background()
+	if err := run(ctx, client, cfg, []string{"golang"}, io.Discard); err != nil {
+		t.Fatalf("search: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := run(ctx, client, cfg, []string{"history", "golang"}, &out); err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 1 || !strings.Contains(lines[0], `"credits":1`) {
+		t.Errorf("history output:\n%s", out.String())
+	}
+
+	out.Reset()
+	cfg.At = "2000-01-01"
+	if err := run(ctx, client, cfg, []string{"history", "golang"}, &out); err != nil {
+		t.Fatalf("history at: %v", err)
+	}
+	if !strings.Contains(out.String(), `"link": "https://go.dev"`) {
+		t.Errorf("nearest snapshot should include the stored response:\n%s", out.String())
+	}
+}
+
+func TestRun_Diff(t *testing.T) {
+	dir := t.TempDir()
+	oldPath, newPath := filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json")
+	old := `{"searchParameters":{"q":"golang"},"organic":[{"title":"Go","link":"https://go.dev","position":1},{"title":"Tour","link":"https://go.dev/tour","position":2}]}`
+	// The newer file is a snapshot, as printed by `serper history`.
+	snap := `{"query":"golang","vertical":"search","time":"2026-03-01T00:00:00Z","response":{"searchParameters":{"q":"golang"},"organic":[{"title":"Tour","link":"https://go.dev/tour","position":1}]}}`
+	for path, body := range map[string]string{oldPath: old, newPath: snap} {
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := run(context.Background(), nil, Config{Format: "text"}, []string{"diff", oldPath, newPath}, &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	want := "Query: golang\nLeft:\n  - #1 Go (https://go.dev)\nMoved:\n  #2 -> #1 (+1) Tour (https://go.dev/tour)\n"
+	if out.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	if err := run(context.Background(), nil, Config{Format: "bibtex"}, []string{"diff", oldPath, newPath}, &out); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestRun_Track(t *testing.T) {
+	client := newTestClient(t, `{"organic":[{"title":"Rival","link":"https://rival.example/","position":1},{"title":"Us","link":"https://www.example.com/","position":2}]}`)
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "track.json")
+	config := `{"keywords":["coffee"],"targets":["example.com"],"markets":[{"gl":"us","hl":"en"}],"depth":10,"history":"` + filepath.Join(dir, "ranks.jsonl") + `"}`
+	if err := os.WriteFile(configPath, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{Format: "text"}
+	for range 2 {
+		var out bytes.Buffer
+		if err := run(context.Background(), client, cfg, []string{"track", configPath}, &out); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		if !strings.Contains(out.String(), "ranked 1/1, avg 2.0") {
+			t.Errorf("report:\n%s", out.String())
+		}
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "ranks.jsonl"))
+	if n := strings.Count(string(data), "\n"); n != 2 {
+		t.Errorf("history lines: got %d, want 2", n)
+	}
+}
+
+func TestRun_Cluster(t *testing.T) {
+	client := newTestClient(t, `{"organic":[{"link":"https://a.example"},{"link":"https://b.example"},{"link":"https://c.example"}]}`)
+	keywords := filepath.Join(t.TempDir(), "keywords.txt")
+	if err := os.WriteFile(keywords, []byte("espresso machine\nespresso maker\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{Format: "csv", Num: 10, GL: "us", HL: "en", ClusterMinShared: 3}
+	var out bytes.Buffer
+	if err := run(context.Background(), client, cfg, []string{"cluster", keywords}, &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !strings.Contains(out.String(), "1,espresso machine,espresso maker,2,") {
+		t.Errorf("csv:\n%s", out.String())
+	}
+
+	cfg.Format = "text"
+	if err := run(context.Background(), client, cfg, []string{"cluster", keywords}, io.Discard); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
+
+func TestRun_Expand(t *testing.T) {
+	client := newTestClient(t, `{"peopleAlsoAsk":[{"question":"Is coffee healthy?"}],"relatedSearches":[{"query":"coffee beans"}]}`)
+	cfg := Config{Format: "dot", GL: "us", HL: "en", ExpandDepth: 1, ExpandNodes: 10}
+	var out bytes.Buffer
+	if err := run(context.Background(), client, cfg, []string{"expand", "coffee"}, &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, want := range []string{`n1 [label="Is coffee healthy?"];`, "n0 -> n2 [style=dashed];"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("DOT should contain %q:\n%s", want, out.String())
+		}
+	}
+
+	cfg.Format = "text"
+	if err := run(context.Background(), client, cfg, []string{"expand", "coffee"}, io.Discard); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
+
+func TestRun_Suggest(t *testing.T) {
+	client := newTestClient(t, `{"suggestions":[{"value":"espresso machine"}]}`)
+	var out bytes.Buffer
+	if err := run(context.Background(), client, Config{Format: "json"}, []string{"suggest", "espresso"}, &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	want := "suggestion,prefix,query,rank,count,error\nespresso machine,,espresso,1,59,\n"
+	if out.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestRun_ShareOfVoice(t *testing.T) {
+	// The same body answers both verticals.
+	client := newTestClient(t, `{"organic":[{"link":"https://www.example.com/"}],"news":[{"link":"https://news.example.com/a"},{"link":"https://other.org/b"}]}`)
+	keywords := filepath.Join(t.TempDir(), "keywords.txt")
+	if err := os.WriteFile(keywords, []byte("coffee\ntea\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{Format: "csv", Num: 10, SovVerticals: "search,news", MaxCredits: 3}
+	var out bytes.Buffer
+	err := run(context.Background(), client, cfg, []string{"sov", keywords}, &out)
+	if !errors.Is(err, serper.ErrCreditLimit) || !strings.Contains(err.Error(), `news "tea"`) {
+		t.Errorf("run: got %v, want the fourth search refused", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "example.com,") || !strings.HasSuffix(lines[1], ",1,2") {
+		t.Errorf("report should still be written:\n%s", out.String())
+	}
+
+	cfg.SovVerticals = "search,maps"
+	if err := run(context.Background(), client, cfg, []string{"sov", keywords}, io.Discard); err == nil {
+		t.Error("expected an error for an unknown vertical")
+	}
+}
+
+func TestRun_ShareOfVoice_CacheIsFree(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"organic":[{"link":"https://www.example.com/"}],"news":[]}`)
+	}))
+	defer srv.Close()
+	client, err := serper.New("test-key", serper.WithBaseURL(srv.URL), serper.WithCache(serper.NewMemoryCache(time.Minute, 10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keywords := filepath.Join(t.TempDir(), "keywords.txt")
+	if err := os.WriteFile(keywords, []byte("coffee\ntea\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{Format: "json", Num: 10, SovVerticals: "search,news", MaxCredits: 4}
+	if err := run(context.Background(), client, cfg, []string{"sov", keywords}, io.Discard); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	cfg.MaxCredits = 1
+	if err := run(context.Background(), client, cfg, []string{"sov", keywords}, io.Discard); err != nil {
+		t.Errorf("cached run should not be charged: %v", err)
+	}
+}
+
+func TestRun_BatchWebhooks(t *testing.T) {
+	var events []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		events = append(events, r.Header.Get(serper.WebhookEventHeader))
+	}))
+	defer receiver.Close()
+	client := newTestClient(t, `{"organic":[{"link":"https://www.example.com/"}],"suggestions":[{"value":"coffee beans"}]}`)
+	keywords := filepath.Join(t.TempDir(), "keywords.txt")
+	if err := os.WriteFile(keywords, []byte("coffee\ntea\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{Format: "csv", Num: 10, SovVerticals: "search", ExpandDepth: 1, ExpandNodes: 5, WebhookURL: receiver.URL, WebhookSecret: "s3cret"}
+	for _, args := range [][]string{
+		{"cluster", keywords},
+		{"expand", "coffee"},
+		{"suggest", "coffee"},
+		{"sov", keywords},
+		{"features", keywords},
+	} {
+		if err := run(context.Background(), client, cfg, args, io.Discard); err != nil {
+			t.Errorf("%s: %v", args[0], err)
+		}
+	}
+	want := []string{serper.EventClusterComplete, serper.EventExpandComplete, serper.EventSuggestComplete, serper.EventSOVComplete, serper.EventFeaturesComplete}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Errorf("webhook events: got %v, want %v", events, want)
+	}
+
+	cfg.WebhookSecret = ""
+	if err := run(context.Background(), client, cfg, []string{"cluster", keywords}, io.Discard); err == nil || !strings.Contains(err.Error(), "SERPER_WEBHOOK_SECRET") {
+		t.Errorf("webhook without a secret: got %v", err)
+	}
+}
+
+func TestRun_Features(t *testing.T) {
+	client := newTestClient(t, `{"answerBox":{"answer":"yes"},"relatedSearches":[{"query":"a"},{"query":"b"}]}`)
+	keywords := filepath.Join(t.TempDir(), "keywords.txt")
+	if err := os.WriteFile(keywords, []byte("coffee\ntea\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{Format: "csv", Num: 10, MaxCredits: 1}
+	var out bytes.Buffer
+	err := run(context.Background(), client, cfg, []string{"features", keywords}, &out)
+	if !errors.Is(err, serper.ErrCreditLimit) || !strings.Contains(err.Error(), `search "tea"`) {
+		t.Errorf("run: got %v, want the second search refused", err)
+	}
+	want := "keyword,answer_box,knowledge_graph,people_also_ask,top_stories,images,videos,sitelinks,related_searches\n" +
+		"coffee,1,0,0,0,0,0,0,2\n"
+	if out.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestRun_Volatility(t *testing.T) {
+	dir := t.TempDir()
+	store, err := serper.NewJSONLSnapshotStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for i, body := range []string{
+		`{"organic":[{"link":"https://a.example"},{"link":"https://b.example"}]}`,
+		`{"organic":[{"link":"https://c.example"},{"link":"https://d.example"}]}`,
+	} {
+		snap := serper.Snapshot{
+			SnapshotKey: serper.SnapshotKey{Query: "coffee"},
+			Time:        time.Date(2026, 3, 1+i, 0, 0, 0, 0, time.UTC),
+			Response:    []byte(body),
+		}
+		if err := store.Save(ctx, snap); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keywords := filepath.Join(dir, "keywords.txt")
+	if err := os.WriteFile(keywords, []byte("# tracked\ncoffee\n\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{Format: "text", Num: 10, GL: "us", HL: "en", SnapshotDir: dir}
+	var out bytes.Buffer
+	if err := run(ctx, nil, cfg, []string{"volatility", keywords}, &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !strings.Contains(out.String(), "2026-03-02  us-en") || !strings.Contains(out.String(), "1.000") {
+		t.Errorf("report:\n%s", out.String())
+	}
+
+	cfg.SnapshotDir = ""
+	if err := run(ctx, nil, cfg, []string{"volatility", keywords}, io.Discard); err == nil {
+		t.Error("expected an error without SERPER_SNAPSHOT_DIR")
+	}
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	serpermod "github.com/ai8future/serper_mod"
+	"github.com/ai8future/serper_mod/serper"
+)
+
+// mcpProtocolVersions are the MCP revisions the server speaks, newest
+// first. A client asking for another revision is offered the newest.
+var mcpProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+const (
+	// mcpMaxMessage bounds a single JSON-RPC message.
+	mcpMaxMessage = 1 << 20
+	// mcpMaxSessions bounds the HTTP sessions kept; the least recently
+	// used is dropped to make room.
+	mcpMaxSessions = 1024
+	// mcpSessionIdle is how long a session may go unused before it
+	// expires, so abandoned sessions do not keep a caller's key.
+	mcpSessionIdle = 30 * time.Minute
+	// mcpSessionHeader carries the HTTP session ID.
+	mcpSessionHeader = "Mcp-Session-Id"
+	// mcpAPIKeyHeader carries a caller's own Serper API key, the same
+	// header Serper itself reads.
+	mcpAPIKeyHeader = "X-API-KEY"
+)
+
+// mcp runs `serper mcp [stdio|http]`: a Model Context Protocol server
+// exposing each search vertical and autocomplete as a tool. stdio, the
+// default, reads newline-delimited JSON-RPC from stdin and writes replies
+// to w; http serves the streamable HTTP transport at /mcp on
+// SERPER_MCP_ADDR until ctx is cancelled. Over HTTP, sessions need a
+// SERPER_GATEWAY_TOKENS token when that is set, and otherwise their own
+// Serper key unless SERPER_MCP_SHARED_KEY lends them SERPER_API_KEY.
+func mcp(ctx context.Context, client *serper.Client, cfg Config, args []string, w io.Writer) error {
+	s := newMCPServer(client)
+	s.sharedKey = cfg.MCPSharedKey
+	transport := "stdio"
+	if len(args) > 0 {
+		transport = args[0]
+	}
+	switch {
+	case len(args) > 1:
+		return fmt.Errorf("%s", usage)
+	case transport == "stdio":
+		return s.serveStdio(ctx, stdin, w)
+	case transport == "http":
+		if cfg.GatewayTokens != "" {
+			tokens, err := loadGatewayTokens(cfg.GatewayTokens)
+			if err != nil {
+				return fmt.Errorf("mcp: %w", err)
+			}
+			s.tokens = tokens
+		}
+		return listenAndServe(ctx, "mcp", cfg.MCPAddr, s.handler())
+	}
+	return fmt.Errorf("unknown MCP transport %q (want stdio or http)", transport)
+}
+
+// stdin is read by the stdio transport; tests replace it.
+var stdin io.Reader = os.Stdin
+
+// mcpServer answers MCP requests by running tools through a
+// serper.Dispatcher. Over HTTP it keeps a session per initialize, holding
+// the API key the client connected with. With tokens set, every HTTP
+// request must present one; without, initialize must bring an API key
+// unless sharedKey allows sessions to use the client's own.
+type mcpServer struct {
+	tools      *serper.Dispatcher
+	tokens     []gatewayToken
+	sharedKey  bool
+	mu         sync.Mutex
+	sessions   map[string]*mcpSession
+	now        func() time.Time
+	newSession func() string
+}
+
+// mcpSession is an HTTP client that has called initialize. token is the
+// gateway token it opened the session with, if any, and must be presented
+// again on every later request.
+type mcpSession struct {
+	token    string
+	apiKey   string
+	lastUsed time.Time
+}
+
+func newMCPServer(client *serper.Client) *mcpServer {
+	return &mcpServer{
+		// Models see URLs next to each numbered source so they can cite them.
+		tools:      serper.NewDispatcher(client, serper.ContextConfig{Format: serper.ContextText, IncludeURLs: true}),
+		sessions:   make(map[string]*mcpSession),
+		now:        time.Now,
+		newSession: randomSessionID,
+	}
+}
+
+// rpcMessage is a JSON-RPC 2.0 request, notification or response.
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   json.RawMessage `json:"error,omitempty"`
+}
+
+// rpcResponse is the reply to a request.
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// mcpContent is a text content block of a tool result.
+type mcpContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// mcpToolResult is the result of tools/call. Failures of the search itself
+// are reported with IsError so the model can see and react to them.
+type mcpToolResult struct {
+	Content []mcpContent `json:"content"`
+	IsError bool         `json:"isError,omitempty"`
+}
+
+// mcpTool is an entry of tools/list.
+type mcpTool struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	InputSchema *serper.JSONSchema `json:"inputSchema"`
+}
+
+// handle answers one message. It returns nil for notifications and for
+// responses sent by the client, which need no reply. Tool calls run with
+// apiKey, if set, in place of the client's key.
+func (s *mcpServer) handle(ctx context.Context, apiKey string, raw []byte) *rpcResponse {
+	var msg rpcMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return rpcFail(json.RawMessage("null"), rpcParseError, "parse error: "+err.Error())
+	}
+	if msg.Method == "" {
+		if msg.Result != nil || msg.Error != nil {
+			return nil
+		}
+		return rpcFail(idOrNull(msg.ID), rpcInvalidRequest, "invalid request: missing method")
+	}
+	if msg.JSONRPC != "2.0" {
+		return rpcFail(idOrNull(msg.ID), rpcInvalidRequest, `invalid request: jsonrpc must be "2.0"`)
+	}
+	if msg.ID == nil {
+		return nil // notifications/initialized, notifications/cancelled, ...
+	}
+
+	var result any
+	var rerr *rpcError
+	switch msg.Method {
+	case "initialize":
+		result, rerr = s.initialize(msg.Params)
+	case "ping":
+		result = struct{}{}
+	case "tools/list":
+		result = s.listTools()
+	case "tools/call":
+		result, rerr = s.callTool(serper.WithAPIKey(ctx, apiKey), msg.Params)
+	default:
+		rerr = &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)}
+	}
+	if rerr != nil {
+		return &rpcResponse{JSONRPC: "2.0", ID: msg.ID, Error: rerr}
+	}
+	return &rpcResponse{JSONRPC: "2.0", ID: msg.ID, Result: result}
+}
+
+func rpcFail(id json.RawMessage, code int, message string) *rpcResponse {
+	return &rpcResponse{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
+}
+
+func idOrNull(id json.RawMessage) json.RawMessage {
+	if id == nil {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+// initialize agrees on a protocol revision and describes the server.
+func (s *mcpServer) initialize(params json.RawMessage) (any, *rpcError) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "invalid params: " + err.Error()}
+		}
+	}
+	version := mcpProtocolVersions[0]
+	if slices.Contains(mcpProtocolVersions, p.ProtocolVersion) {
+		version = p.ProtocolVersion
+	}
+	return map[string]any{
+		"protocolVersion": version,
+		"capabilities":    map[string]any{"tools": map[string]any{"listChanged": false}},
+		"serverInfo":      map[string]any{"name": "serper", "version": serpermod.AppVersion},
+		"instructions":    "Google search through Serper.dev. Results are numbered sources with their URLs; cite them as [n].",
+	}, nil
+}
+
+func (s *mcpServer) listTools() any {
+	defs := serper.ToolDefinitions()
+	tools := make([]mcpTool, len(defs))
+	for i, d := range defs {
+		tools[i] = mcpTool{Name: d.Name, Description: d.Description, InputSchema: d.Parameters}
+	}
+	return map[string]any{"tools": tools}
+}
+
+// callTool runs a tool through the dispatcher. An unknown tool is a
+// protocol error; invalid arguments and failed searches are tool results
+// with isError set, carrying the typed error's message.
+func (s *mcpServer) callTool(ctx context.Context, params json.RawMessage) (any, *rpcError) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil || p.Name == "" {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "invalid params: tools/call needs a tool name"}
+	}
+	if !slices.ContainsFunc(serper.ToolDefinitions(), func(d serper.ToolDefinition) bool { return d.Name == p.Name }) {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("unknown tool: %s", p.Name)}
+	}
+	out, err := s.tools.Call(ctx, p.Name, p.Arguments)
+	if err != nil {
+		return mcpToolResult{Content: []mcpContent{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+	}
+	return mcpToolResult{Content: []mcpContent{{Type: "text", Text: out.Text}}}, nil
+}
+
+// serveStdio answers newline-delimited messages from r on w until r ends
+// or ctx is cancelled.
+func (s *mcpServer) serveStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), mcpMaxMessage)
+	enc := json.NewEncoder(w)
+	for sc.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if resp := s.handle(ctx, "", line); resp != nil {
+			if err := enc.Encode(resp); err != nil {
+				return fmt.Errorf("mcp: %w", err)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("mcp: %w", err)
+	}
+	return nil
+}
+
+// handler serves the streamable HTTP transport at /mcp. Each POST carries
+// one message. initialize opens a session, returned in Mcp-Session-Id,
+// that later requests must send. With tokens set, every request needs the
+// "Authorization: Bearer" token the session was opened with, and the
+// session uses the Serper key it maps to. Otherwise the X-API-KEY header
+// of the initialize request becomes the session's Serper key and is
+// required unless sharedKey is set. An X-API-KEY on a later request
+// overrides the session's key for that request. Replies are plain JSON;
+// the server sends no messages of its own, so GET is not offered.
+func (s *mcpServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /mcp", s.post)
+	mux.HandleFunc("DELETE /mcp", s.delete)
+	return mux
+}
+
+func (s *mcpServer) post(w http.ResponseWriter, r *http.Request) {
+	if !sameOrigin(r) {
+		http.Error(w, "forbidden origin", http.StatusForbidden)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, mcpMaxMessage+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body) > mcpMaxMessage {
+		http.Error(w, "message too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	var peek struct {
+		Method string `json:"method"`
+	}
+	_ = json.Unmarshal(body, &peek)
+
+	token, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+	apiKey := r.Header.Get(mcpAPIKeyHeader)
+	if peek.Method == "initialize" {
+		sessionKey := apiKey
+		if s.tokens != nil {
+			sessionKey = token.apiKey
+		} else if apiKey == "" && !s.sharedKey {
+			http.Error(w, "initialize needs an "+mcpAPIKeyHeader+" header", http.StatusUnauthorized)
+			return
+		}
+		id := s.openSession(token.token, sessionKey)
+		w.Header().Set(mcpSessionHeader, id)
+	} else {
+		sess, status := s.session(r.Header.Get(mcpSessionHeader), token.token)
+		if sess == nil {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		if apiKey == "" {
+			apiKey = sess.apiKey
+		}
+	}
+
+	resp := s.handle(r.Context(), apiKey, body)
+	if resp == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (s *mcpServer) delete(w http.ResponseWriter, r *http.Request) {
+	token, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+	id := r.Header.Get(mcpSessionHeader)
+	s.mu.Lock()
+	sess, ok := s.sessions[id]
+	ok = ok && sess.token == token.token
+	if ok {
+		delete(s.sessions, id)
+	}
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authenticate returns the gateway token presented with r, or answers 401
+// and returns false if tokens are set and none was. Without tokens it
+// returns the zero token.
+func (s *mcpServer) authenticate(w http.ResponseWriter, r *http.Request) (gatewayToken, bool) {
+	if s.tokens == nil {
+		return gatewayToken{}, true
+	}
+	t, ok := authenticate(s.tokens, r, false)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="serper"`)
+		http.Error(w, "missing or unknown API token", http.StatusUnauthorized)
+	}
+	return t, ok
+}
+
+// openSession records a new session, dropping the least recently used
+// one if the table is full.
+func (s *mcpServer) openSession(token, apiKey string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.sessions) >= mcpMaxSessions {
+		var oldest string
+		for id, sess := range s.sessions {
+			if oldest == "" || sess.lastUsed.Before(s.sessions[oldest].lastUsed) {
+				oldest = id
+			}
+		}
+		delete(s.sessions, oldest)
+	}
+	id := s.newSession()
+	s.sessions[id] = &mcpSession{token: token, apiKey: apiKey, lastUsed: s.now()}
+	return id
+}
+
+// session returns the session id names, or nil and the status to answer
+// with: 400 without an ID and 404 for one that is unknown, opened with a
+// token other than token, or unused for longer than mcpSessionIdle.
+// Expired sessions are dropped.
+func (s *mcpServer) session(id, token string) (*mcpSession, int) {
+	if id == "" {
+		return nil, http.StatusBadRequest
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	if ok && s.now().Sub(sess.lastUsed) > mcpSessionIdle {
+		delete(s.sessions, id)
+		ok = false
+	}
+	if !ok || sess.token != token {
+		return nil, http.StatusNotFound
+	}
+	sess.lastUsed = s.now()
+	cp := *sess
+	return &cp, 0
+}
+
+// sameOrigin reports whether a browser request comes from the server's own
+// origin. Requests without an Origin header, from non-browser clients, are
+// allowed. This guards a local server against DNS rebinding.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+func randomSessionID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
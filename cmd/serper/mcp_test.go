@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ai8future/serper_mod/serper"
+)
+
+// keyRecordingClient returns a client whose upstream answers every request
+// with body and records the API keys it was sent.
+func keyRecordingClient(t *testing.T, status int, body string) (*serper.Client, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("X-API-KEY"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	client, err := serper.New("default-key", serper.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("serper.New: %v", err)
+	}
+	return client, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), keys...)
+	}
+}
+
+// decodeReplies splits newline-delimited JSON-RPC replies.
+func decodeReplies(t *testing.T, out string) []map[string]any {
+	t.Helper()
+	var replies []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("reply %q: %v", line, err)
+		}
+		replies = append(replies, m)
+	}
+	return replies
+}
+
+func TestMCP_Stdio(t *testing.T) {
+	client, keys := keyRecordingClient(t, http.StatusOK, `{"searchParameters":{"q":"go"},"organic":[{"title":"Go","link":"https://go.dev","snippet":"Build fast."}]}`)
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"serper_search","arguments":{"q":"go"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":5,"method":"resources/list"}`,
+		`not json`,
+		``,
+	}, "\n")
+	orig := stdin
+	stdin = strings.NewReader(in)
+	t.Cleanup(func() { stdin = orig })
+
+	var out bytes.Buffer
+	if err := run(context.Background(), client, Config{}, []string{"mcp"}, &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	replies := decodeReplies(t, out.String())
+	if len(replies) != 6 {
+		t.Fatalf("got %d replies, want 6:\n%s", len(replies), out.String())
+	}
+
+	init := replies[0]["result"].(map[string]any)
+	if init["protocolVersion"] != "2025-03-26" || init["serverInfo"].(map[string]any)["name"] != "serper" {
+		t.Errorf("initialize: %v", init)
+	}
+	tools := replies[1]["result"].(map[string]any)["tools"].([]any)
+	first := tools[0].(map[string]any)
+	if len(tools) != len(serper.ToolDefinitions()) || first["name"] != "serper_search" || first["inputSchema"] == nil {
+		t.Errorf("tools/list: %v", tools)
+	}
+	call := replies[2]["result"].(map[string]any)
+	text := call["content"].([]any)[0].(map[string]any)["text"].(string)
+	if call["isError"] != nil || !strings.Contains(text, "[1] Go\nhttps://go.dev\nBuild fast.") {
+		t.Errorf("tools/call: %v", call)
+	}
+	if got := keys(); len(got) != 1 || got[0] != "default-key" {
+		t.Errorf("upstream keys: %v", got)
+	}
+	if r := replies[3]; r["id"] != 4.0 || r["result"] == nil {
+		t.Errorf("ping: %v", r)
+	}
+	if e := replies[4]["error"].(map[string]any); e["code"] != -32601.0 {
+		t.Errorf("unknown method: %v", e)
+	}
+	if e := replies[5]["error"].(map[string]any); e["code"] != -32700.0 || replies[5]["id"] != nil {
+		t.Errorf("parse error: %v", replies[5])
+	}
+}
+
+func TestMCP_ToolErrors(t *testing.T) {
+	client, _ := keyRecordingClient(t, http.StatusTooManyRequests, `{"message":"slow down"}`)
+	s := newMCPServer(client)
+	ctx := context.Background()
+
+	tests := []struct {
+		name, params string
+		wantCode     int    // JSON-RPC error code, or 0 for a tool result
+		wantText     string // in the isError result
+	}{
+		{"unknown tool", `{"name":"serper_maps","arguments":{"q":"x"}}`, -32602, ""},
+		{"missing name", `{"arguments":{}}`, -32602, ""},
+		{"invalid arguments", `{"name":"serper_search","arguments":{"num":3}}`, 0, "query (q) is required"},
+		{"upstream error", `{"name":"serper_news","arguments":{"q":"x"}}`, 0, "slow down"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := s.handle(ctx, "", []byte(`{"jsonrpc":"2.0","id":"a","method":"tools/call","params":`+tt.params+`}`))
+			if tt.wantCode != 0 {
+				if resp.Error == nil || resp.Error.Code != tt.wantCode {
+					t.Fatalf("got %+v, want error %d", resp, tt.wantCode)
+				}
+				return
+			}
+			res, ok := resp.Result.(mcpToolResult)
+			if !ok || !res.IsError || !strings.Contains(res.Content[0].Text, tt.wantText) {
+				t.Errorf("got %+v, want an isError result containing %q", resp.Result, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestMCP_HTTPSessions(t *testing.T) {
+	client, keys := keyRecordingClient(t, http.StatusOK, `{"organic":[]}`)
+	s := newMCPServer(client)
+	ids := []string{"s1", "s2"}
+	s.newSession = func() string { id := ids[0]; ids = ids[1:]; return id }
+	h := s.handler()
+
+	post := func(session, apiKey, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if session != "" {
+			req.Header.Set(mcpSessionHeader, session)
+		}
+		if apiKey != "" {
+			req.Header.Set(mcpAPIKeyHeader, apiKey)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	const initialize = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`
+	const search = `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"serper_search","arguments":{"q":"go"}}}`
+
+	rec := post("", "tenant-a", initialize)
+	if rec.Code != http.StatusOK || rec.Header().Get(mcpSessionHeader) != "s1" || !strings.Contains(rec.Body.String(), `"protocolVersion":"2025-06-18"`) {
+		t.Fatalf("initialize: %d %v %s", rec.Code, rec.Header(), rec.Body)
+	}
+	if rec := post("s1", "", `{"jsonrpc":"2.0","method":"notifications/initialized"}`); rec.Code != http.StatusAccepted {
+		t.Errorf("notification: got %d", rec.Code)
+	}
+	post("s1", "", search)
+	post("s1", "tenant-override", search)
+	if rec := post("", "", initialize); rec.Code != http.StatusUnauthorized || rec.Header().Get(mcpSessionHeader) != "" {
+		t.Errorf("initialize without a key: got %d %v", rec.Code, rec.Header())
+	}
+	s.sharedKey = true
+	post("", "", initialize) // s2, lent the client's key
+	post("s2", "", search)
+	want := []string{"tenant-a", "tenant-override", "default-key"}
+	if got := keys(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("upstream keys: got %v, want %v", got, want)
+	}
+
+	if rec := post("", "", search); rec.Code != http.StatusBadRequest {
+		t.Errorf("no session: got %d", rec.Code)
+	}
+	if rec := post("nope", "", search); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown session: got %d", rec.Code)
+	}
+
+	del := httptest.NewRequest(http.MethodDelete, "/mcp", nil)
+	del.Header.Set(mcpSessionHeader, "s1")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, del)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("delete: got %d", rec.Code)
+	}
+	if rec := post("s1", "", search); rec.Code != http.StatusNotFound {
+		t.Errorf("deleted session: got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(initialize))
+	req.Header.Set("Origin", "https://evil.example")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("cross-origin: got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/mcp", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: got %d", rec.Code)
+	}
+}
+
+func TestMCP_HTTPTokens(t *testing.T) {
+	client, keys := keyRecordingClient(t, http.StatusOK, `{"organic":[]}`)
+	s := newMCPServer(client)
+	s.tokens = []gatewayToken{{token: "tok-a", apiKey: "key-a"}, {token: "tok-b"}}
+	ids := []string{"s1", "s2"}
+	s.newSession = func() string { id := ids[0]; ids = ids[1:]; return id }
+	h := s.handler()
+
+	send := func(method, session, token, body string) int {
+		req := httptest.NewRequest(method, "/mcp", strings.NewReader(body))
+		if session != "" {
+			req.Header.Set(mcpSessionHeader, session)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	const initialize = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`
+	const search = `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"serper_search","arguments":{"q":"go"}}}`
+
+	tests := []struct {
+		name                   string
+		method, session, token string
+		body                   string
+		want                   int
+	}{
+		{"initialize without a token", http.MethodPost, "", "", initialize, http.StatusUnauthorized},
+		{"initialize with an unknown token", http.MethodPost, "", "nope", initialize, http.StatusUnauthorized},
+		{"initialize", http.MethodPost, "", "tok-a", initialize, http.StatusOK},
+		{"call", http.MethodPost, "s1", "tok-a", search, http.StatusOK},
+		{"call without the token", http.MethodPost, "s1", "", search, http.StatusUnauthorized},
+		{"call with another token", http.MethodPost, "s1", "tok-b", search, http.StatusNotFound},
+		{"delete with another token", http.MethodDelete, "s1", "tok-b", "", http.StatusNotFound},
+		{"initialize without a mapped key", http.MethodPost, "", "tok-b", initialize, http.StatusOK},
+		{"call with the client's key", http.MethodPost, "s2", "tok-b", search, http.StatusOK},
+		{"delete", http.MethodDelete, "s1", "tok-a", "", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		if got := send(tt.method, tt.session, tt.token, tt.body); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+	want := []string{"key-a", "default-key"}
+	if got := keys(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("upstream keys: got %v, want %v", got, want)
+	}
+}
+
+func TestMCP_SessionsExpireWhenIdle(t *testing.T) {
+	client, _ := keyRecordingClient(t, http.StatusOK, `{"organic":[]}`)
+	s := newMCPServer(client)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	id := s.openSession("", "tenant-a")
+
+	now = now.Add(mcpSessionIdle)
+	if sess, _ := s.session(id, ""); sess == nil || sess.apiKey != "tenant-a" {
+		t.Fatalf("session used within the idle timeout: got %+v", sess)
+	}
+	now = now.Add(mcpSessionIdle + time.Second)
+	if sess, status := s.session(id, ""); sess != nil || status != http.StatusNotFound {
+		t.Errorf("idle session: got %+v, %d, want 404", sess, status)
+	}
+	if len(s.sessions) != 0 {
+		t.Errorf("expired session kept: %v", s.sessions)
+	}
+}
+
+func TestMCP_UnknownTransport(t *testing.T) {
+	client, _ := keyRecordingClient(t, http.StatusOK, `{}`)
+	if err := run(context.Background(), client, Config{}, []string{"mcp", "sse"}, io.Discard); err == nil {
+		t.Error("expected an error for an unknown transport")
+	}
+}
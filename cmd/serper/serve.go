@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	chassiserrors "github.com/ai8future/chassis-go/v11/errors"
+
+	"github.com/ai8future/serper_mod/serper"
+)
+
+// shutdownTimeout bounds how long in-flight requests may take to finish
+// once the server is asked to stop.
+const shutdownTimeout = 10 * time.Second
+
+// serve runs the HTTP server until ctx is cancelled, then shuts it down
+// gracefully. Without SERPER_GATEWAY_TOKENS it refuses to listen beyond
+// loopback, where anyone could spend the server's credits, unless
+// SERPER_GATEWAY_OPEN says that is intended.
+func serve(ctx context.Context, client *serper.Client, cfg Config) error {
+	if cfg.GatewayTokens == "" && !cfg.GatewayOpen && !loopbackAddr(cfg.Addr) {
+		return fmt.Errorf("serve: %s is reachable beyond this host; set SERPER_GATEWAY_TOKENS, or SERPER_GATEWAY_OPEN=true to serve it without tokens", cfg.Addr)
+	}
+	mux, err := newServeMux(ctx, client, cfg)
+	if err != nil {
+		return fmt.Errorf("serve: %w", err)
+	}
+	return listenAndServe(ctx, "serve", cfg.Addr, mux)
+}
+
+// loopbackAddr reports whether addr listens only on a loopback interface.
+// An empty host listens on every interface.
+func loopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// listenAndServe serves handler on addr until ctx is cancelled, then shuts
+// the server down gracefully. name prefixes returned errors.
+func listenAndServe(ctx context.Context, name, addr string, handler http.Handler) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("%s: %w", name, err)
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("%s: shutdown: %w", name, err)
+	}
+	return nil
+}
+
+// newServeMux returns the routes served by `serper serve`: a REST endpoint
+// per vertical, news feeds, and health and readiness checks. The search
+// routes need a token when SERPER_GATEWAY_TOKENS is set and are limited
+// to SERPER_GATEWAY_RATE requests a minute per caller. readyz fails once
+// ctx is cancelled.
+func newServeMux(ctx context.Context, client *serper.Client, cfg Config) (*http.ServeMux, error) {
+	g := &gateway{client: client, cfg: cfg, limiter: newRateLimiter(cfg.GatewayRate)}
+	if cfg.GatewayTokens != "" {
+		tokens, err := loadGatewayTokens(cfg.GatewayTokens)
+		if err != nil {
+			return nil, err
+		}
+		g.tokens = tokens
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/{vertical}", g.guard(g.search, false))
+	mux.HandleFunc("POST /v1/{vertical}", g.guard(g.search, false))
+	mux.HandleFunc("GET /feeds/news", g.guard(newsFeedHandler(client, cfg), true))
+	mux.HandleFunc("GET /healthz", healthz)
+	mux.HandleFunc("GET /readyz", readyz(ctx))
+	return mux, nil
+}
+
+// newsFeedHandler serves an RSS or Atom feed for ?q=<query>. The format is
+// chosen with ?format=rss|atom (default rss); gl, hl and location may be
+// given as query parameters and default to the CLI configuration. Repeated
+// polls within SERPER_CACHE_TTL are answered from the client's cache.
+func newsFeedHandler(client *serper.Client, cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		format := q.Get("format")
+		if format == "" {
+			format = "rss"
+		}
+		if format != "rss" && format != "atom" {
+			http.Error(w, fmt.Sprintf("unknown format %q (want rss or atom)", format), http.StatusBadRequest)
+			return
+		}
+
+		req := &serper.SearchRequest{
+			Q:        q.Get("q"),
+			Num:      cfg.Num,
+			GL:       firstNonEmpty(q.Get("gl"), cfg.GL),
+			HL:       firstNonEmpty(q.Get("hl"), cfg.HL),
+			Location: firstNonEmpty(q.Get("location"), cfg.Location),
+		}
+		resp, err := client.News(r.Context(), req)
+		if err != nil {
+			http.Error(w, err.Error(), httpStatus(err))
+			return
+		}
+
+		opts := serper.FeedOptions{SelfLink: requestURL(r)}
+		var body []byte
+		contentType := "application/rss+xml; charset=utf-8"
+		if format == "atom" {
+			contentType = "application/atom+xml; charset=utf-8"
+			body, err = resp.Atom(opts)
+		} else {
+			body, err = resp.RSS(opts)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		if cfg.CacheTTL > 0 {
+			w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(cfg.CacheTTL.Seconds())))
+		}
+		_, _ = w.Write(body)
+	}
+}
+
+// httpStatus maps a chassis typed error to its HTTP status code.
+func httpStatus(err error) int {
+	var se *chassiserrors.ServiceError
+	if errors.As(err, &se) && se.HTTPCode != 0 {
+		return se.HTTPCode
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
+
+// requestURL reconstructs the absolute URL a request was made to.
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
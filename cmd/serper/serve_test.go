@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const newsBody = `{"searchParameters":{"q":"golang"},"news":[{"title":"Go 1.30 released","link":"https://go.dev/blog/go1.30","source":"Go Blog","date":"1 day ago","position":1}]}`
+
+func TestNewsFeedHandler(t *testing.T) {
+	mux, err := newServeMux(context.Background(), newTestClient(t, newsBody), Config{Num: 10, GL: "us", HL: "en", CacheTTL: 15 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, query, wantType, wantBody string
+	}{
+		{"rss default", "?q=golang", "application/rss+xml", "<rss version=\"2.0\""},
+		{"atom", "?q=golang&format=atom", "application/atom+xml", "<feed xmlns=\"http://www.w3.org/2005/Atom\">"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feeds/news"+tt.query, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status: got %d, body %s", rec.Code, rec.Body)
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, tt.wantType) {
+				t.Errorf("Content-Type: got %q", ct)
+			}
+			if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=900" {
+				t.Errorf("Cache-Control: got %q", cc)
+			}
+			body := rec.Body.String()
+			if !strings.Contains(body, tt.wantBody) || !strings.Contains(body, "Go 1.30 released") {
+				t.Errorf("body:\n%s", body)
+			}
+		})
+	}
+}
+
+func TestNewsFeedHandler_Errors(t *testing.T) {
+	mux, err := newServeMux(context.Background(), newTestClient(t, newsBody), Config{Num: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, target string
+		want         int
+	}{
+		{"missing query", "/feeds/news", http.StatusBadRequest},
+		{"unknown format", "/feeds/news?q=golang&format=json", http.StatusBadRequest},
+		{"wrong method", "/feeds/news?q=golang", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := http.MethodGet
+			if tt.want == http.StatusMethodNotAllowed {
+				method = http.MethodPost
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(method, tt.target, nil))
+			if rec.Code != tt.want {
+				t.Errorf("status: got %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestGateway_Verticals(t *testing.T) {
+	client, keys := keyRecordingClient(t, http.StatusOK, `{"searchParameters":{"q":"go"},"organic":[{"title":"Go","link":"https://go.dev","position":1}]}`)
+	mux, err := newServeMux(context.Background(), client, Config{Num: 10, GL: "us", HL: "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, method, target, body string
+		want                       int
+	}{
+		{"get", http.MethodGet, "/v1/search?q=go&num=5", "", http.StatusOK},
+		{"post", http.MethodPost, "/v1/news", `{"q":"go","gl":"de"}`, http.StatusOK},
+		{"autocomplete", http.MethodGet, "/v1/autocomplete?q=go", "", http.StatusOK},
+		{"unknown vertical", http.MethodGet, "/v1/maps?q=go", "", http.StatusNotFound},
+		{"missing query", http.MethodGet, "/v1/search", "", http.StatusBadRequest},
+		{"bad num", http.MethodGet, "/v1/search?q=go&num=ten", "", http.StatusBadRequest},
+		{"num out of range", http.MethodGet, "/v1/search?q=go&num=500", "", http.StatusBadRequest},
+		{"unknown field", http.MethodPost, "/v1/search", `{"q":"go","safe":true}`, http.StatusBadRequest},
+		{"wrong method", http.MethodDelete, "/v1/search?q=go", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if rec.Code != tt.want {
+				t.Fatalf("status: got %d, want %d, body %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusMethodNotAllowed {
+				return
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type: got %q", ct)
+			}
+			if tt.want == http.StatusOK && !strings.Contains(rec.Body.String(), `"searchParameters":{"q":"go"`) {
+				t.Errorf("body: %s", rec.Body)
+			} else if tt.want != http.StatusOK && !strings.Contains(rec.Body.String(), `"error":`) {
+				t.Errorf("error body: %s", rec.Body)
+			}
+		})
+	}
+	if got := keys(); len(got) != 3 {
+		t.Errorf("upstream calls: got %d, want 3", len(got))
+	}
+}
+
+func TestGateway_UpstreamError(t *testing.T) {
+	client, _ := keyRecordingClient(t, http.StatusUnauthorized, `{"message":"bad key"}`)
+	mux, err := newServeMux(context.Background(), client, Config{Num: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/search?q=go", nil))
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), `"error":`) {
+		t.Errorf("got %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestGateway_Tokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	tokens := "# caller token, then its Serper key\nteam-a key-a\n\nteam-b\n"
+	if err := os.WriteFile(path, []byte(tokens), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client, keys := keyRecordingClient(t, http.StatusOK, newsBody)
+	mux, err := newServeMux(context.Background(), client, Config{Num: 10, GatewayTokens: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, target, auth string
+		want               int
+	}{
+		{"mapped key", "/v1/search?q=go", "Bearer team-a", http.StatusOK},
+		{"default key", "/v1/news?q=go", "bearer team-b", http.StatusOK},
+		{"query token", "/feeds/news?q=go&token=team-a", "", http.StatusOK},
+		{"query token off feeds", "/v1/search?q=go&token=team-a", "", http.StatusUnauthorized},
+		{"no token", "/v1/search?q=go", "", http.StatusUnauthorized},
+		{"unknown token", "/v1/search?q=go", "Bearer team-c", http.StatusUnauthorized},
+		{"basic auth", "/v1/search?q=go", "Basic dGVhbS1hOg==", http.StatusUnauthorized},
+		{"feed without token", "/feeds/news?q=go", "", http.StatusUnauthorized},
+		{"health is open", "/healthz", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status: got %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("missing WWW-Authenticate")
+			}
+		})
+	}
+	want := []string{"key-a", "default-key", "key-a"}
+	if got := keys(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("upstream keys: got %v, want %v", got, want)
+	}
+}
+
+func TestLoadGatewayTokens_Errors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct{ name, content string }{
+		{"empty", "# nothing here\n"},
+		{"extra field", "team-a key-a extra\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := loadGatewayTokens(path); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+	if _, err := newServeMux(context.Background(), nil, Config{GatewayTokens: filepath.Join(dir, "missing")}); err == nil {
+		t.Error("expected an error for a missing tokens file")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter(2)
+	l.now = func() time.Time { return now }
+	for i := 0; i < 2; i++ {
+		if _, ok := l.allow("a"); !ok {
+			t.Fatalf("request %d refused within the burst", i+1)
+		}
+	}
+	wait, ok := l.allow("a")
+	if ok || wait != 30*time.Second {
+		t.Errorf("third request: ok=%v wait=%v, want refused for 30s", ok, wait)
+	}
+	if _, ok := l.allow("b"); !ok {
+		t.Error("callers should not share a bucket")
+	}
+	now = now.Add(30 * time.Second)
+	if _, ok := l.allow("a"); !ok {
+		t.Error("request refused after refill")
+	}
+	if newRateLimiter(0) != nil {
+		t.Error("a zero rate should disable limiting")
+	}
+}
+
+func TestRateLimiter_Bounded(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter(1)
+	l.now = func() time.Time { return now }
+	// Every caller has spent its allowance, so none can be pruned as
+	// refilled; the least recently seen goes instead.
+	for i := 0; i <= gatewayMaxCallers; i++ {
+		now = now.Add(time.Millisecond)
+		l.allow(strconv.Itoa(i))
+	}
+	if len(l.buckets) > gatewayMaxCallers {
+		t.Errorf("buckets: got %d, want at most %d", len(l.buckets), gatewayMaxCallers)
+	}
+	if _, ok := l.buckets["0"]; ok {
+		t.Error("the least recently seen caller should have been evicted")
+	}
+	if _, ok := l.allow("1"); ok {
+		t.Error("a recent caller should keep its spent bucket")
+	}
+}
+
+func TestGateway_RateLimit(t *testing.T) {
+	mux, err := newServeMux(context.Background(), newTestClient(t, newsBody), Config{Num: 10, GatewayRate: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var codes []int
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/news?q=go", nil))
+		codes = append(codes, rec.Code)
+		if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") != "60" {
+			t.Errorf("Retry-After: got %q", rec.Header().Get("Retry-After"))
+		}
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("statuses: got %v", codes)
+	}
+}
+
+func TestHealthAndReadiness(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	mux, err := newServeMux(ctx, newTestClient(t, newsBody), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+	if rec := get("/healthz"); rec.Code != http.StatusOK {
+		t.Errorf("healthz: got %d", rec.Code)
+	}
+	if rec := get("/readyz"); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"ready"`) {
+		t.Errorf("readyz: got %d %s", rec.Code, rec.Body)
+	}
+	cancel()
+	if rec := get("/readyz"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("readyz while draining: got %d", rec.Code)
+	}
+	if rec := get("/healthz"); rec.Code != http.StatusOK {
+		t.Errorf("healthz while draining: got %d", rec.Code)
+	}
+}
+
+func TestServe_RefusesOpenGateway(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // allowed configurations shut down as soon as they start
+	client := newTestClient(t, newsBody)
+	tokens := filepath.Join(t.TempDir(), "tokens")
+	if err := os.WriteFile(tokens, []byte("tok\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		refused bool
+	}{
+		{"all interfaces", Config{Addr: ":0"}, true},
+		{"public address", Config{Addr: "0.0.0.0:0"}, true},
+		{"host name", Config{Addr: "example.com:0"}, true},
+		{"loopback", Config{Addr: "127.0.0.1:0"}, false},
+		{"localhost", Config{Addr: "localhost:0"}, false},
+		{"ipv6 loopback", Config{Addr: "[::1]:0"}, false},
+		{"tokens", Config{Addr: ":0", GatewayTokens: tokens}, false},
+		{"opted out", Config{Addr: ":0", GatewayOpen: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := serve(ctx, client, tt.cfg)
+			if refused := err != nil && strings.Contains(err.Error(), "SERPER_GATEWAY_OPEN"); refused != tt.refused {
+				t.Errorf("got %v, want refused %v", err, tt.refused)
+			}
+		})
+	}
+}
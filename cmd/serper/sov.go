@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ai8future/serper_mod/serper"
+)
+
+// sovTopDomains caps the text report; JSON and CSV list every domain.
+const sovTopDomains = 25
+
+// sov runs `serper sov <keywords.txt>`: it searches every keyword in the
+// verticals named by SERPER_SOV_VERTICALS and reports each domain's share
+// of voice. Searches that fail are skipped; the report is still written and
+// the failures are returned together.
+func sov(ctx context.Context, client *serper.Client, cfg Config, args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s", usage)
+	}
+	if cfg.Format != "json" && cfg.Format != "text" && cfg.Format != "csv" {
+		return fmt.Errorf("unknown format %q (want json, text or csv)", cfg.Format)
+	}
+	var verticals []string
+	for _, v := range strings.Split(cfg.SovVerticals, ",") {
+		switch v = strings.TrimSpace(v); v {
+		case "search", "news":
+			verticals = append(verticals, v)
+		case "":
+		default:
+			return fmt.Errorf("unknown vertical %q (want search or news)", v)
+		}
+	}
+	sink, err := webhookSink(cfg)
+	if err != nil {
+		return err
+	}
+	keywords, err := readKeywords(args[0])
+	if err != nil {
+		return err
+	}
+
+	budget := serper.NewCreditBudget(cfg.MaxCredits)
+	agg := serper.NewShareOfVoice(nil)
+	fetch := func(vertical string, req *serper.SearchRequest) error {
+		if err := client.ReserveCredits(ctx, budget, vertical, req); err != nil {
+			return err
+		}
+		if vertical == "news" {
+			resp, err := client.News(ctx, req)
+			if err == nil {
+				agg.AddNews(resp)
+			}
+			return err
+		}
+		resp, err := client.Search(ctx, req)
+		if err == nil {
+			agg.AddSearch(resp)
+		}
+		return err
+	}
+	var errs []error
+	for _, kw := range keywords {
+		for _, v := range verticals {
+			if err := fetch(v, searchRequest(cfg, []string{kw})); err != nil {
+				errs = append(errs, fmt.Errorf("%s %q: %w", v, kw, err))
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	report := agg.Report()
+	switch cfg.Format {
+	case "text":
+		_, err = io.WriteString(w, report.Text(sovTopDomains))
+	case "csv":
+		err = report.WriteCSV(w)
+	default:
+		err = writeJSON(w, report)
+	}
+	if err := serper.Notify(ctx, sink, serper.EventSOVComplete, report); err != nil {
+		errs = append(errs, fmt.Errorf("notify: %w", err))
+	}
+	return errors.Join(append([]error{err}, errs...)...)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ai8future/serper_mod/serper"
+)
+
+// suggest runs `serper suggest <seed>`: alphabet-soup autocomplete
+// discovery for the seed, written as CSV.
+func suggest(ctx context.Context, client *serper.Client, cfg Config, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", usage)
+	}
+	sink, err := webhookSink(cfg)
+	if err != nil {
+		return err
+	}
+	res, err := client.DiscoverSuggestions(ctx, serper.SuggestConfig{
+		Seed:   strings.Join(args, " "),
+		Market: serper.Market{GL: cfg.GL, HL: cfg.HL, Location: cfg.Location},
+		Budget: serper.NewCreditBudget(cfg.MaxCredits),
+		Sink:   sink,
+	})
+	return writeThenNotify(res, err, func(res *serper.SuggestResult) error {
+		return res.WriteCSV(w)
+	})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ai8future/serper_mod/serper"
+)
+
+// trackConfig is the file read by `serper track`.
+type trackConfig struct {
+	Keywords []string        `json:"keywords"`
+	Targets  []string        `json:"targets"` // "example.com", "*.example.com", "example.com/blog"
+	Markets  []serper.Market `json:"markets"`
+	Depth    int             `json:"depth"`
+	History  string          `json:"history"` // JSONL rank history; default "ranks.jsonl"
+}
+
+// track runs `serper track <config.json>`: one rank-tracking run whose
+// records are appended to the history file, followed by a report comparing
+// it with the previous run. SERPER_FORMAT selects json or text output.
+func track(ctx context.Context, client *serper.Client, cfg Config, args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s", usage)
+	}
+	if cfg.Format != "json" && cfg.Format != "text" {
+		return fmt.Errorf("unknown format %q (want json or text)", cfg.Format)
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	var tc trackConfig
+	if err := json.Unmarshal(data, &tc); err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	targets := make([]serper.RankTarget, 0, len(tc.Targets))
+	for _, s := range tc.Targets {
+		t, err := serper.ParseRankTarget(s)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, t)
+	}
+	if tc.History == "" {
+		tc.History = "ranks.jsonl"
+	}
+	store := serper.NewJSONLRankStore(tc.History)
+
+	sink, err := webhookSink(cfg)
+	if err != nil {
+		return err
+	}
+	if _, err := client.TrackRanks(ctx, serper.RankTrackConfig{
+		Keywords: tc.Keywords,
+		Targets:  targets,
+		Markets:  tc.Markets,
+		Depth:    tc.Depth,
+		Budget:   serper.NewCreditBudget(cfg.MaxCredits),
+		Store:    store,
+		Sink:     sink,
+	}); err != nil {
+		return err
+	}
+	history, err := store.Records(ctx)
+	if err != nil {
+		return err
+	}
+	report := serper.CompareRanks(serper.LatestRankRuns(history))
+	if cfg.Format == "text" {
+		_, err := io.WriteString(w, report.Text())
+		return err
+	}
+	return writeJSON(w, report)
+}
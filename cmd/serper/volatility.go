@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ai8future/serper_mod/serper"
+)
+
+// volatility runs `serper volatility <keywords.txt>`: it scores the day to
+// day churn of each keyword's stored web results in SERPER_SNAPSHOT_DIR for
+// the configured market. The file holds one keyword per line; blank lines
+// and lines starting with # are skipped.
+func volatility(ctx context.Context, cfg Config, args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s", usage)
+	}
+	if cfg.Format != "json" && cfg.Format != "text" {
+		return fmt.Errorf("unknown format %q (want json or text)", cfg.Format)
+	}
+	if cfg.SnapshotDir == "" {
+		return fmt.Errorf("volatility: SERPER_SNAPSHOT_DIR is not set")
+	}
+	keywords, err := readKeywords(args[0])
+	if err != nil {
+		return err
+	}
+	store, err := serper.NewJSONLSnapshotStore(cfg.SnapshotDir)
+	if err != nil {
+		return err
+	}
+	keys := make([]serper.SnapshotKey, len(keywords))
+	for i, k := range keywords {
+		keys[i] = serper.SnapshotKey{Query: k, Vertical: "search", GL: cfg.GL, HL: cfg.HL, Location: cfg.Location}
+	}
+	report, err := serper.VolatilityFromStore(ctx, store, keys, serper.VolatilityConfig{TopN: cfg.Num})
+	if err != nil {
+		return err
+	}
+	if cfg.Format == "text" {
+		_, err := io.WriteString(w, report.Text())
+		return err
+	}
+	return writeJSON(w, report)
+}
+
+// readKeywords reads one keyword per line, skipping blanks and # comments.
+func readKeywords(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		out = append(out, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return out, nil
+}
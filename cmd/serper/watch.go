@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ai8future/serper_mod/serper"
+)
+
+// watchJitter spreads polls by up to +/-10% of SERPER_WATCH_INTERVAL.
+const watchJitter = 0.1
+
+// watch runs `serper watch news <query>...`: each argument is a separate
+// query, and every article not seen before is written to w as one JSON
+// line. New articles are also posted to SERPER_WEBHOOK_URL when set. It
+// returns nil when interrupted.
+func watch(ctx context.Context, client *serper.Client, cfg Config, args []string, w io.Writer) error {
+	if len(args) < 2 || args[0] != "news" {
+		return fmt.Errorf("%s", usage)
+	}
+	queries := make([]serper.SearchRequest, 0, len(args)-1)
+	for _, q := range args[1:] {
+		queries = append(queries, *searchRequest(cfg, []string{q}))
+	}
+
+	enc := json.NewEncoder(w)
+	var writeErr error
+	wcfg := serper.WatchConfig{
+		Queries:   queries,
+		Interval:  cfg.WatchInterval,
+		Jitter:    watchJitter,
+		StatePath: cfg.WatchState,
+		Budget:    serper.NewCreditBudget(cfg.MaxCredits),
+		OnNew: func(item serper.WatchItem) {
+			if err := enc.Encode(item); err != nil && writeErr == nil {
+				writeErr = err
+			}
+		},
+		OnError: func(err error) { fmt.Fprintf(os.Stderr, "watch: %v\n", err) },
+	}
+	sink, err := webhookSink(cfg)
+	if err != nil {
+		return err
+	}
+	wcfg.Sink = sink
+	watcher, err := client.NewWatcher(wcfg)
+	if err != nil {
+		return err
+	}
+
+	err = watcher.Run(ctx)
+	switch {
+	case writeErr != nil:
+		return writeErr
+	case errors.Is(err, context.Canceled):
+		return nil
+	}
+	return err
+}
+
+// webhookSink returns the configured webhook sink, or nil if
+// SERPER_WEBHOOK_URL is not set. A URL without SERPER_WEBHOOK_SECRET is
+// refused: deliveries signed with an empty key prove nothing.
+func webhookSink(cfg Config) (serper.Sink, error) {
+	if cfg.WebhookURL == "" {
+		return nil, nil
+	}
+	if cfg.WebhookSecret == "" {
+		return nil, fmt.Errorf("SERPER_WEBHOOK_URL is set but SERPER_WEBHOOK_SECRET is empty")
+	}
+	return &serper.WebhookSink{
+		URL:            cfg.WebhookURL,
+		Secret:         []byte(cfg.WebhookSecret),
+		DeadLetterPath: cfg.DeadLetter,
+	}, nil
+}
+
+// writeThenNotify writes the result of a batch job with write and returns
+// the job's error alongside. A failed notification still returns the
+// result, so it is written first; without a result only err is returned.
+func writeThenNotify[T any](res *T, err error, write func(*T) error) error {
+	if res == nil {
+		return err
+	}
+	return errors.Join(write(res), err)
+}
@@ -2,7 +2,10 @@ module github.com/ai8future/serper_mod
 
 go 1.25.5
 
-require github.com/ai8future/chassis-go/v11 v11.0.0
+require (
+	github.com/ai8future/chassis-go/v11 v11.0.0
+	golang.org/x/net v0.49.0
+)
 
 replace github.com/ai8future/chassis-go/v11 => ../../chassis_suite/chassis-go
 
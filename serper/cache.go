@@ -0,0 +1,221 @@
+package serper
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Cache stores raw API response bodies keyed by endpoint and request.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+}
+
+// WithCache serves repeated identical requests from c instead of the API.
+// Only successful, validated responses are cached. Requests made with the
+// client's own key share entries; each key set with WithAPIKey has entries
+// of its own, so a caller cannot read results fetched, and paid for, with
+// another tenant's key.
+func WithCache(c Cache) Option {
+	return func(cl *Client) { cl.cache = c }
+}
+
+// cacheKey derives a cache key from the endpoint and the marshalled
+// request, scoped to the API key overriding the client's own, if any. An
+// empty scope hashes as before scoping existed, so file caches stay valid.
+func cacheKey(endpoint, scope string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(endpoint))
+	h.Write([]byte{0})
+	h.Write(body)
+	if scope != "" {
+		h.Write([]byte{0})
+		h.Write([]byte(scope))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheScope returns the API key a request made with ctx uses, or "" if it
+// is the client's own.
+func (c *Client) cacheScope(ctx context.Context) string {
+	if key := c.getAPIKey(ctx); key != c.apiKey {
+		return key
+	}
+	return ""
+}
+
+// ReserveCredits reserves the credits for req to vertical ("search",
+// "news", ...) on budget, unless the response would be served from the
+// client's cache for a request made with ctx. Batch jobs built outside
+// this package use it so cached responses are not charged, as they are not
+// in ClusterKeywords.
+func (c *Client) ReserveCredits(ctx context.Context, budget *CreditBudget, vertical string, req *SearchRequest) error {
+	return c.reserveUncached(ctx, budget, "/"+vertical, req)
+}
+
+// reserveUncached reserves the credits for req on budget unless the
+// response would be served from the client's cache, so batch jobs are not
+// charged for responses they already have.
+func (c *Client) reserveUncached(ctx context.Context, budget *CreditBudget, endpoint string, req *SearchRequest) error {
+	if c.cached(ctx, endpoint, req) {
+		return nil
+	}
+	return budget.Reserve(RequestCredits(req))
+}
+
+// cached reports whether the response to req at endpoint, made with ctx,
+// would be served from the client's cache.
+func (c *Client) cached(ctx context.Context, endpoint string, req *SearchRequest) bool {
+	if c.cache == nil {
+		return false
+	}
+	prepared, err := prepareRequest(req)
+	if err != nil {
+		return false
+	}
+	body, err := json.Marshal(prepared)
+	if err != nil {
+		return false
+	}
+	_, ok := c.cache.Get(cacheKey(endpoint, c.cacheScope(ctx), body))
+	return ok
+}
+
+// MemoryCache is an in-process LRU cache with a fixed time-to-live.
+type MemoryCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	order      *list.List // front is most recently used
+	entries    map[string]*list.Element
+	now        func() time.Time
+}
+
+type memoryCacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache returns a cache whose entries expire after ttl. When it
+// holds maxEntries entries the least recently used one is evicted; a
+// maxEntries of zero or less means no limit.
+func NewMemoryCache(ttl time.Duration, maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Get returns the cached value for key if present and not expired.
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*memoryCacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return entry.value, true
+}
+
+// Set stores value under key, replacing any previous entry.
+func (c *MemoryCache) Set(key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := c.now().Add(c.ttl)
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*memoryCacheEntry)
+		entry.value, entry.expires = value, expires
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&memoryCacheEntry{key: key, value: value, expires: expires})
+	if c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Len returns the number of entries, including expired ones not yet evicted.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// FileCache is a Cache keeping one file per entry in a directory, so
+// responses survive between runs of a command. Entries expire ttl after
+// they were written; a ttl of zero or less never expires them. Failed
+// writes are ignored and simply miss on the next Get.
+type FileCache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewFileCache returns a cache in dir, creating it if needed.
+func NewFileCache(dir string, ttl time.Duration) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("serper: file cache: %w", err)
+	}
+	return &FileCache{dir: dir, ttl: ttl, now: time.Now}, nil
+}
+
+// Get returns the cached value for key if present and not expired.
+func (c *FileCache) Get(key string) ([]byte, bool) {
+	path := c.path(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if c.ttl > 0 && !c.now().Before(info.ModTime().Add(c.ttl)) {
+		_ = os.Remove(path)
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Set writes value under key, replacing any previous entry atomically.
+func (c *FileCache) Set(key string, value []byte) {
+	tmp, err := os.CreateTemp(c.dir, key+".*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(value); err != nil {
+		tmp.Close()
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		return
+	}
+	_ = os.Rename(tmp.Name(), c.path(key))
+}
+
+func (c *FileCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
@@ -0,0 +1,127 @@
+package serper
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache_TTLAndEviction(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewMemoryCache(time.Minute, 2)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", []byte("1"))
+	c.Set("b", []byte("2"))
+	if _, ok := c.Get("a"); !ok { // "a" becomes most recently used
+		t.Fatal("expected hit for a")
+	}
+	c.Set("c", []byte("3")) // evicts "b"
+	if _, ok := c.Get("b"); ok {
+		t.Error("b should have been evicted as least recently used")
+	}
+	if v, ok := c.Get("a"); !ok || string(v) != "1" {
+		t.Errorf("a: got %q, %v", v, ok)
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := c.Get("c"); ok {
+		t.Error("c should have expired")
+	}
+	if c.Len() != 1 {
+		t.Errorf("Len: got %d, want 1", c.Len())
+	}
+}
+
+func TestFileCache_TTL(t *testing.T) {
+	now := time.Now()
+	c, err := NewFileCache(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatalf("NewFileCache: %v", err)
+	}
+	c.now = func() time.Time { return now }
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("empty cache should miss")
+	}
+	c.Set("a", []byte("1"))
+	c.Set("a", []byte("2"))
+	if v, ok := c.Get("a"); !ok || string(v) != "2" {
+		t.Errorf("a: got %q, %v", v, ok)
+	}
+	now = now.Add(2 * time.Hour)
+	if _, ok := c.Get("a"); ok {
+		t.Error("a should have expired")
+	}
+}
+
+func TestWithCache_ServesRepeatedRequests(t *testing.T) {
+	mock := &mockDoer{statusCode: 200, respBody: `{"organic":[{"title":"Go","position":1}]}`}
+	c := mustNew(t, "key", WithDoer(mock), WithCache(NewMemoryCache(time.Minute, 10)))
+
+	if _, err := c.Search(context.Background(), &SearchRequest{Q: "golang"}); err != nil {
+		t.Fatalf("first search: %v", err)
+	}
+	mock.req = nil
+	resp, err := c.Search(context.Background(), &SearchRequest{Q: "golang"})
+	if err != nil {
+		t.Fatalf("second search: %v", err)
+	}
+	if mock.req != nil {
+		t.Error("second identical search should be served from cache")
+	}
+	if len(resp.Organic) != 1 || resp.Organic[0].Title != "Go" {
+		t.Errorf("cached response: got %+v", resp)
+	}
+
+	// A different endpoint or query is a different cache entry.
+	if _, err := c.News(context.Background(), &SearchRequest{Q: "golang"}); err != nil {
+		t.Fatalf("news: %v", err)
+	}
+	if mock.req == nil {
+		t.Error("news search should not share the web search cache entry")
+	}
+}
+
+func TestWithCache_DoesNotCacheErrors(t *testing.T) {
+	mock := &mockDoer{statusCode: 500, respBody: `{"error":"boom"}`}
+	cache := NewMemoryCache(time.Minute, 10)
+	c := mustNew(t, "key", WithDoer(mock), WithCache(cache))
+
+	if _, err := c.Search(context.Background(), &SearchRequest{Q: "x"}); err == nil {
+		t.Fatal("expected error")
+	}
+	if cache.Len() != 0 {
+		t.Errorf("error responses should not be cached, got %d entries", cache.Len())
+	}
+}
+
+func TestWithCache_ScopedByAPIKey(t *testing.T) {
+	mock := &mockDoer{statusCode: 200, respBody: `{"organic":[]}`}
+	c := mustNew(t, "key", WithDoer(mock), WithCache(NewMemoryCache(time.Minute, 10)))
+	req := &SearchRequest{Q: "golang"}
+
+	tenantA := WithAPIKey(context.Background(), "tenant-a")
+	if _, err := c.Search(tenantA, req); err != nil {
+		t.Fatalf("tenant a: %v", err)
+	}
+	for _, tt := range []struct {
+		name       string
+		ctx        context.Context
+		wantCached bool
+	}{
+		{"same key", tenantA, true},
+		{"other key", WithAPIKey(context.Background(), "tenant-b"), false},
+		{"client key", context.Background(), false},
+		{"client key again", context.Background(), true},
+		{"client key set explicitly", WithAPIKey(context.Background(), "key"), true},
+	} {
+		mock.req = nil
+		if _, err := c.Search(tt.ctx, req); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if cached := mock.req == nil; cached != tt.wantCached {
+			t.Errorf("%s: served from cache %v, want %v", tt.name, cached, tt.wantCached)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package serper
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// citationStopWords are skipped when picking the title word of a citation key.
+var citationStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "on": true, "of": true, "in": true,
+	"for": true, "and": true, "to": true, "with": true, "from": true, "by": true,
+}
+
+// asciiFold maps common accented Latin letters to ASCII for citation keys.
+var asciiFold = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a", "å", "a", "æ", "ae",
+	"ç", "c", "č", "c", "ć", "c", "é", "e", "è", "e", "ê", "e", "ë", "e", "ě", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i", "ñ", "n", "ń", "n", "ň", "n",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o", "ø", "o", "œ", "oe",
+	"ř", "r", "š", "s", "ś", "s", "ß", "ss", "ú", "u", "ù", "u", "û", "u",
+	"ü", "u", "ů", "u", "ý", "y", "ÿ", "y", "ž", "z", "ź", "z", "ż", "z", "ł", "l",
+)
+
+// bibtexEscaper escapes characters that are special in BibTeX field values.
+var bibtexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`, "{", `\{`, "}", `\}`, "&", `\&`, "%", `\%`,
+	"$", `\$`, "#", `\#`, "_", `\_`, "~", `\textasciitilde{}`, "^", `\textasciicircum{}`,
+)
+
+// CitationKey returns a BibTeX-style key built from the first author's
+// family name, the year and the first significant title word, e.g.
+// "smith2021deep". The key is ASCII-only and stable for a given result.
+func (r ScholarResult) CitationKey() string {
+	var b strings.Builder
+	if len(r.Authors) > 0 {
+		_, family := splitAuthorName(r.Authors[0])
+		b.WriteString(keyPart(family))
+	}
+	if b.Len() == 0 {
+		b.WriteString("anon")
+	}
+	if r.Year > 0 {
+		b.WriteString(strconv.Itoa(r.Year))
+	}
+	for _, w := range strings.Fields(r.Title) {
+		if k := keyPart(w); k != "" && !citationStopWords[k] {
+			b.WriteString(k)
+			break
+		}
+	}
+	return b.String()
+}
+
+// CitationKeys returns a key per result, in order. Duplicate keys get "a",
+// "b", ... suffixes so every key in the response is unique.
+func (r *ScholarResponse) CitationKeys() []string {
+	keys := make([]string, len(r.Organic))
+	counts := map[string]int{}
+	for i, res := range r.Organic {
+		keys[i] = res.CitationKey()
+		counts[keys[i]]++
+	}
+	seen := map[string]int{}
+	for i, k := range keys {
+		if counts[k] > 1 {
+			keys[i] = k + citationSuffix(seen[k])
+			seen[k]++
+		}
+	}
+	return keys
+}
+
+// citationSuffix returns "a".."z", then "aa", "ab", ... for n = 0, 1, ...
+func citationSuffix(n int) string {
+	suffix := ""
+	for n++; n > 0; n = (n - 1) / 26 {
+		suffix = string(rune('a'+(n-1)%26)) + suffix
+	}
+	return suffix
+}
+
+// BibTeX formats the result as a BibTeX entry using its CitationKey.
+func (r ScholarResult) BibTeX() string {
+	return r.bibtex(r.CitationKey())
+}
+
+// BibTeX formats every result as a BibTeX entry with unique citation keys.
+func (r *ScholarResponse) BibTeX() string {
+	var b strings.Builder
+	for i, key := range r.CitationKeys() {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(r.Organic[i].bibtex(key))
+	}
+	return b.String()
+}
+
+func (r ScholarResult) bibtex(key string) string {
+	entryType := "misc"
+	if r.Venue != "" {
+		entryType = "article"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "@%s{%s,\n", entryType, key)
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "  %s = {%s},\n", name, bibtexEscaper.Replace(value))
+		}
+	}
+	// URLs are read verbatim by the url package; only braces would break the entry.
+	urlField := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "  %s = {%s},\n", name, strings.NewReplacer("{", "%7B", "}", "%7D").Replace(value))
+		}
+	}
+	field("title", r.Title)
+	field("author", strings.Join(r.Authors, " and "))
+	field("journal", r.Venue)
+	if r.Year > 0 {
+		field("year", strconv.Itoa(r.Year))
+	}
+	field("publisher", r.Publisher)
+	urlField("url", r.Link)
+	if r.CitedBy > 0 {
+		field("note", fmt.Sprintf("Cited by %d", r.CitedBy))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// RIS formats the result as an RIS record using its CitationKey as ID.
+func (r ScholarResult) RIS() string {
+	return r.ris(r.CitationKey())
+}
+
+func (r ScholarResult) ris(id string) string {
+	var b strings.Builder
+	tag := func(name, value string) {
+		if value = risValue(value); value != "" {
+			fmt.Fprintf(&b, "%s  - %s\n", name, value)
+		}
+	}
+	if r.Venue != "" {
+		tag("TY", "JOUR")
+	} else {
+		tag("TY", "GEN")
+	}
+	tag("ID", id)
+	tag("TI", r.Title)
+	for _, a := range r.Authors {
+		tag("AU", a)
+	}
+	if r.Year > 0 {
+		tag("PY", strconv.Itoa(r.Year))
+	}
+	tag("T2", r.Venue)
+	tag("PB", r.Publisher)
+	tag("UR", r.Link)
+	tag("AB", r.Snippet)
+	b.WriteString("ER  - \n")
+	return b.String()
+}
+
+// RIS formats every result as an RIS record with unique IDs, the same
+// keys as BibTeX.
+func (r *ScholarResponse) RIS() string {
+	var b strings.Builder
+	for i, key := range r.CitationKeys() {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(r.Organic[i].ris(key))
+	}
+	return b.String()
+}
+
+// CSLItem is a CSL-JSON bibliographic item, as read by Zotero, Pandoc and
+// citeproc processors.
+type CSLItem struct {
+	ID             string    `json:"id"`
+	Type           string    `json:"type"`
+	Title          string    `json:"title,omitempty"`
+	Author         []CSLName `json:"author,omitempty"`
+	Issued         *CSLDate  `json:"issued,omitempty"`
+	ContainerTitle string    `json:"container-title,omitempty"`
+	Publisher      string    `json:"publisher,omitempty"`
+	URL            string    `json:"URL,omitempty"`
+	Abstract       string    `json:"abstract,omitempty"`
+	Note           string    `json:"note,omitempty"`
+}
+
+// CSLName is a CSL-JSON personal name.
+type CSLName struct {
+	Family string `json:"family,omitempty"`
+	Given  string `json:"given,omitempty"`
+}
+
+// CSLDate is a CSL-JSON date.
+type CSLDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+// CSLItems converts the results to CSL-JSON items with unique ids.
+func (r *ScholarResponse) CSLItems() []CSLItem {
+	items := make([]CSLItem, 0, len(r.Organic))
+	for i, key := range r.CitationKeys() {
+		res := r.Organic[i]
+		item := CSLItem{
+			ID:             key,
+			Type:           "document",
+			Title:          res.Title,
+			ContainerTitle: res.Venue,
+			Publisher:      res.Publisher,
+			URL:            res.Link,
+			Abstract:       res.Snippet,
+		}
+		if res.Venue != "" {
+			item.Type = "article-journal"
+		}
+		for _, a := range res.Authors {
+			given, family := splitAuthorName(a)
+			item.Author = append(item.Author, CSLName{Family: family, Given: given})
+		}
+		if res.Year > 0 {
+			item.Issued = &CSLDate{DateParts: [][]int{{res.Year}}}
+		}
+		if res.CitedBy > 0 {
+			item.Note = fmt.Sprintf("Cited by %d", res.CitedBy)
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// CSLJSON encodes the results as a CSL-JSON array.
+func (r *ScholarResponse) CSLJSON() ([]byte, error) {
+	out, err := json.MarshalIndent(r.CSLItems(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("serper: marshal csl-json: %w", err)
+	}
+	return out, nil
+}
+
+// splitAuthorName splits "J Smith" or "Smith, J" into given and family names.
+func splitAuthorName(name string) (given, family string) {
+	name = strings.TrimSpace(name)
+	if i := strings.Index(name, ","); i >= 0 {
+		return strings.TrimSpace(name[i+1:]), strings.TrimSpace(name[:i])
+	}
+	fields := strings.Fields(name)
+	if len(fields) == 0 {
+		return "", ""
+	}
+	return strings.Join(fields[:len(fields)-1], " "), fields[len(fields)-1]
+}
+
+// keyPart lower-cases s, folds accents and keeps only ASCII letters and digits.
+func keyPart(s string) string {
+	s = asciiFold.Replace(strings.ToLower(s))
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// risValue flattens a value onto one line, as RIS tags are line-based.
+func risValue(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
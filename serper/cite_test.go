@@ -0,0 +1,125 @@
+package serper
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testScholarResponse() *ScholarResponse {
+	return &ScholarResponse{Organic: []ScholarResult{
+		{
+			Title:     "Deep Learning for 50% of Cases & More",
+			Link:      "https://example.com/paper?id=1_a",
+			Snippet:   "An abstract\nspanning lines.",
+			Authors:   []string{"J Müller", "A Doe"},
+			Venue:     "Nature",
+			Year:      2021,
+			Publisher: "nature.com",
+			CitedBy:   42,
+		},
+		{Title: "The Deep Sequel", Authors: []string{"K Muller"}, Year: 2021},
+		{Title: "Untitled notes"},
+	}}
+}
+
+func TestScholarResult_CitationKey(t *testing.T) {
+	resp := testScholarResponse()
+	if got := resp.Organic[0].CitationKey(); got != "muller2021deep" {
+		t.Errorf("key: got %q, want %q", got, "muller2021deep")
+	}
+	if got := resp.Organic[2].CitationKey(); got != "anonuntitled" {
+		t.Errorf("key without author or year: got %q, want %q", got, "anonuntitled")
+	}
+}
+
+func TestScholarResponse_CitationKeys_Unique(t *testing.T) {
+	keys := testScholarResponse().CitationKeys()
+	want := []string{"muller2021deepa", "muller2021deepb", "anonuntitled"}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("keys: got %v, want %v", keys, want)
+	}
+	if citationSuffix(26) != "aa" {
+		t.Errorf("citationSuffix(26): got %q, want %q", citationSuffix(26), "aa")
+	}
+}
+
+func TestScholarResponse_BibTeX(t *testing.T) {
+	bib := testScholarResponse().BibTeX()
+	for _, want := range []string{
+		"@article{muller2021deepa,\n",
+		`title = {Deep Learning for 50\% of Cases \& More},`,
+		"author = {J Müller and A Doe},",
+		"journal = {Nature},",
+		"year = {2021},",
+		"url = {https://example.com/paper?id=1_a},",
+		"note = {Cited by 42},",
+		"@misc{anonuntitled,\n",
+	} {
+		if !strings.Contains(bib, want) {
+			t.Errorf("BibTeX should contain %q:\n%s", want, bib)
+		}
+	}
+}
+
+func TestScholarResult_RIS(t *testing.T) {
+	ris := testScholarResponse().Organic[0].RIS()
+	want := strings.Join([]string{
+		"TY  - JOUR",
+		"ID  - muller2021deep",
+		"TI  - Deep Learning for 50% of Cases & More",
+		"AU  - J Müller",
+		"AU  - A Doe",
+		"PY  - 2021",
+		"T2  - Nature",
+		"PB  - nature.com",
+		"UR  - https://example.com/paper?id=1_a",
+		"AB  - An abstract spanning lines.",
+		"ER  - ",
+		"",
+	}, "\n")
+	if ris != want {
+		t.Errorf("RIS:\n got\n%s\n want\n%s", ris, want)
+	}
+}
+
+func TestScholarResponse_RIS_UniqueIDs(t *testing.T) {
+	ris := testScholarResponse().RIS()
+	var ids []string
+	for _, line := range strings.Split(ris, "\n") {
+		if id, ok := strings.CutPrefix(line, "ID  - "); ok {
+			ids = append(ids, id)
+		}
+	}
+	want := []string{"muller2021deepa", "muller2021deepb", "anonuntitled"}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Errorf("IDs: got %v, want %v", ids, want)
+	}
+}
+
+func TestScholarResponse_CSLJSON(t *testing.T) {
+	data, err := testScholarResponse().CSLJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var items []CSLItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("items: got %d, want 3", len(items))
+	}
+	first := items[0]
+	if first.ID != "muller2021deepa" || first.Type != "article-journal" || first.ContainerTitle != "Nature" {
+		t.Errorf("first item: got %+v", first)
+	}
+	if len(first.Author) != 2 || first.Author[0] != (CSLName{Family: "Müller", Given: "J"}) {
+		t.Errorf("authors: got %+v", first.Author)
+	}
+	if first.Issued == nil || first.Issued.DateParts[0][0] != 2021 {
+		t.Errorf("issued: got %+v", first.Issued)
+	}
+	if items[2].Type != "document" || items[2].Issued != nil {
+		t.Errorf("item without venue or year: got %+v", items[2])
+	}
+}
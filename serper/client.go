@@ -29,9 +29,12 @@ type Doer interface {
 
 // Client is a Serper.dev API client.
 type Client struct {
-	apiKey  string
-	baseURL string
-	doer    Doer
+	apiKey    string
+	baseURL   string
+	doer      Doer
+	cache     Cache
+	snapshots SnapshotStore
+	filter    *DomainFilter
 }
 
 // Option configures a Client.
@@ -115,6 +118,9 @@ func doSearch[T any](c *Client, ctx context.Context, endpoint string, req *Searc
 	if err := c.doRequest(ctx, endpoint, prepared, &resp); err != nil {
 		return nil, err
 	}
+	if c.filter != nil {
+		c.filter.Apply(&resp)
+	}
 	return &resp, nil
 }
 
@@ -139,8 +145,15 @@ func (c *Client) Places(ctx context.Context, req *SearchRequest) (*PlacesRespons
 }
 
 // Scholar performs a scholar search via Serper.dev.
+// Authors, Year, Venue and Publisher are filled from PublicationInfo where
+// Serper leaves them empty.
 func (c *Client) Scholar(ctx context.Context, req *SearchRequest) (*ScholarResponse, error) {
-	return doSearch[ScholarResponse](c, ctx, "/scholar", req)
+	resp, err := doSearch[ScholarResponse](c, ctx, "/scholar", req)
+	if err != nil {
+		return nil, err
+	}
+	resp.FillPublicationInfo()
+	return resp, nil
 }
 
 // Shopping performs a shopping search via Serper.dev.
@@ -153,6 +166,11 @@ func (c *Client) Videos(ctx context.Context, req *SearchRequest) (*VideosRespons
 	return doSearch[VideosResponse](c, ctx, "/videos", req)
 }
 
+// Autocomplete returns Google's query suggestions for req.Q via Serper.dev.
+func (c *Client) Autocomplete(ctx context.Context, req *SearchRequest) (*AutocompleteResponse, error) {
+	return doSearch[AutocompleteResponse](c, ctx, "/autocomplete", req)
+}
+
 // CheckConnectivity verifies the API key and connectivity to Serper.dev.
 // Note: this makes a real search request that counts toward your API usage.
 func (c *Client) CheckConnectivity(ctx context.Context) error {
@@ -168,6 +186,17 @@ func (c *Client) doRequest(ctx context.Context, endpoint string, reqBody, respBo
 		return fmt.Errorf("serper: marshal request: %w", err)
 	}
 
+	var key string
+	if c.cache != nil {
+		key = cacheKey(endpoint, c.cacheScope(ctx), jsonBody)
+		if cached, ok := c.cache.Get(key); ok {
+			if err := json.Unmarshal(cached, respBody); err != nil {
+				return fmt.Errorf("serper: unmarshal cached response: %w", err)
+			}
+			return nil
+		}
+	}
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+endpoint, bytes.NewReader(jsonBody))
 	if err != nil {
 		return fmt.Errorf("serper: create request: %w", err)
@@ -227,6 +256,17 @@ func (c *Client) doRequest(ctx context.Context, endpoint string, reqBody, respBo
 		return fmt.Errorf("serper: unmarshal response: %w", err)
 	}
 
+	// Save before caching: a failed save must not leave a cached response
+	// that later requests would be answered from without ever saving it.
+	if c.snapshots != nil {
+		if err := c.saveSnapshot(ctx, endpoint, reqBody, body); err != nil {
+			return err
+		}
+	}
+	if c.cache != nil {
+		c.cache.Set(key, body)
+	}
+
 	return nil
 }
 
@@ -898,6 +898,30 @@ func TestVideos_Success(t *testing.T) {
 	}
 }
 
+func TestAutocomplete_Success(t *testing.T) {
+	respJSON := `{
+		"searchParameters": {"q": "espresso", "type": "autocomplete"},
+		"suggestions": [{"value": "espresso machine"}, {"value": "espresso martini"}],
+		"credits": 1
+	}`
+	mock := &mockDoer{statusCode: 200, respBody: respJSON}
+	c := mustNew(t, "key", WithDoer(mock), WithBaseURL("https://api.test"))
+
+	resp, err := c.Autocomplete(context.Background(), &SearchRequest{Q: "espresso"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Suggestions) != 2 || resp.Suggestions[1].Value != "espresso martini" {
+		t.Errorf("suggestions: got %+v", resp.Suggestions)
+	}
+
+	// Verify /autocomplete endpoint was called.
+	wantURL := "https://api.test/autocomplete"
+	if mock.req.URL.String() != wantURL {
+		t.Errorf("URL: got %q, want %q", mock.req.URL.String(), wantURL)
+	}
+}
+
 func TestSearch_ResponseTooLarge(t *testing.T) {
 	largeBody := strings.Repeat("x", maxResponseBytes+20)
 	mock := &mockDoer{statusCode: 200, respBody: largeBody}
@@ -0,0 +1,167 @@
+package serper
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// DefaultNewsClusterDistance is the largest simhash Hamming distance at
+// which two news results are considered the same story.
+const DefaultNewsClusterDistance = 14
+
+// NewsCluster is a group of news results reporting the same story.
+type NewsCluster struct {
+	// Representative is the highest-ranked result in the cluster.
+	Representative NewsResult `json:"representative"`
+	// Items are all results in the cluster, including the representative,
+	// in their original order.
+	Items []NewsResult `json:"items"`
+	// Sources are the distinct outlets of the other items, in order,
+	// excluding the representative's own source.
+	Sources []string `json:"sources"`
+}
+
+// Clusters groups near-duplicate news results with
+// DefaultNewsClusterDistance. See ClusterNews.
+func (r *NewsResponse) Clusters() []NewsCluster {
+	return ClusterNews(r.News, DefaultNewsClusterDistance)
+}
+
+// ClusterNews groups results whose title and snippet simhashes differ in at
+// most maxDistance bits (of 64); zero or less uses
+// DefaultNewsClusterDistance. Grouping is transitive: if A matches B and B
+// matches C, all three share a cluster. Clusters are ordered by their
+// first item, and the output depends only on the input, so the same
+// results always cluster the same way.
+func ClusterNews(results []NewsResult, maxDistance int) []NewsCluster {
+	if maxDistance <= 0 {
+		maxDistance = DefaultNewsClusterDistance
+	}
+	hashes := make([]uint64, len(results))
+	for i, r := range results {
+		hashes[i] = r.SimHash()
+	}
+
+	parent := make([]int, len(results))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range results {
+		for j := i + 1; j < len(results); j++ {
+			if bits.OnesCount64(hashes[i]^hashes[j]) > maxDistance {
+				continue
+			}
+			// Keep the earliest index as the root so clusters are
+			// ordered by first appearance.
+			ri, rj := find(i), find(j)
+			if ri < rj {
+				parent[rj] = ri
+			} else if rj < ri {
+				parent[ri] = rj
+			}
+		}
+	}
+
+	var clusters []NewsCluster
+	index := make(map[int]int) // root -> position in clusters
+	for i, r := range results {
+		root := find(i)
+		ci, ok := index[root]
+		if !ok {
+			ci = len(clusters)
+			index[root] = ci
+			clusters = append(clusters, NewsCluster{})
+		}
+		clusters[ci].Items = append(clusters[ci].Items, r)
+	}
+	for i := range clusters {
+		c := &clusters[i]
+		rep := 0
+		for j, item := range c.Items {
+			if rankBefore(item, c.Items[rep]) {
+				rep = j
+			}
+		}
+		c.Representative = c.Items[rep]
+		seen := map[string]bool{strings.ToLower(c.Representative.Source): true}
+		for _, item := range c.Items {
+			key := strings.ToLower(item.Source)
+			if item.Source == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			c.Sources = append(c.Sources, item.Source)
+		}
+	}
+	return clusters
+}
+
+// rankBefore reports whether a ranks above b. Results without a position
+// rank after those with one.
+func rankBefore(a, b NewsResult) bool {
+	return a.Position > 0 && (b.Position <= 0 || a.Position < b.Position)
+}
+
+// SimHash returns a 64-bit simhash of the result's title and snippet. Title
+// words count twice, since outlets rewrite snippets more than headlines.
+// Similar texts have hashes differing in few bits.
+func (r NewsResult) SimHash() uint64 {
+	var weights [64]int
+	add := func(text string, weight int) {
+		for _, word := range simhashTokens(text) {
+			h := fnv.New64a()
+			h.Write([]byte(word))
+			sum := h.Sum64()
+			for bit := range weights {
+				if sum&(1<<bit) != 0 {
+					weights[bit] += weight
+				} else {
+					weights[bit] -= weight
+				}
+			}
+		}
+	}
+	add(r.Title, 2)
+	add(r.Snippet, 1)
+
+	var out uint64
+	for bit, w := range weights {
+		if w > 0 {
+			out |= 1 << bit
+		}
+	}
+	return out
+}
+
+// simhashStopWords are dropped before hashing; they carry no topic and
+// would pull unrelated stories together.
+var simhashStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "has": true, "in": true,
+	"is": true, "it": true, "its": true, "of": true, "on": true, "or": true,
+	"that": true, "the": true, "to": true, "was": true, "will": true, "with": true,
+}
+
+// simhashTokens splits text into lower-cased words, dropping punctuation
+// and stop words.
+func simhashTokens(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := words[:0]
+	for _, w := range words {
+		if !simhashStopWords[w] {
+			out = append(out, w)
+		}
+	}
+	return out
+}
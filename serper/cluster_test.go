@@ -0,0 +1,87 @@
+package serper
+
+import (
+	"math/bits"
+	"strings"
+	"testing"
+)
+
+func testWireNews() []NewsResult {
+	return []NewsResult{
+		{Position: 1, Title: "Fed holds interest rates steady, signals cuts later this year", Snippet: "The Federal Reserve left rates unchanged on Wednesday but signalled cuts.", Source: "CNBC"},
+		{Position: 2, Title: "Apple unveils iPhone 18 with satellite messaging", Snippet: "Apple Inc on Tuesday unveiled its iPhone 18, which adds satellite messaging and a faster chip.", Source: "Yahoo"},
+		{Position: 3, Title: "Tesla recalls 200,000 vehicles over camera issue", Snippet: "Tesla is recalling about 200,000 vehicles in the US because the rearview camera may fail.", Source: "Reuters"},
+		{Position: 4, Title: "Apple unveils new iPhone 18 with satellite messaging", Snippet: "Apple on Tuesday unveiled the iPhone 18, adding satellite messaging and a faster chip.", Source: "Reuters"},
+		{Position: 5, Title: "Apple stock falls after iPhone event", Snippet: "Shares of Apple fell 2% after the company's product launch.", Source: "Bloomberg"},
+		{Position: 6, Title: "Apple unveils new iPhone 18 with satellite messaging - Reuters", Snippet: "Apple on Tuesday unveiled the iPhone 18, adding satellite messaging and a faster processor.", Source: "MSN"},
+		{Position: 7, Title: "Tesla recalls nearly 200,000 vehicles over rearview camera issue", Snippet: "Tesla is recalling nearly 200,000 vehicles because the rearview camera may fail to display.", Source: "The Verge"},
+		{Position: 8, Title: "Apple unveils iPhone 18 with satellite messaging", Snippet: "Apple Inc on Tuesday unveiled its iPhone 18, which adds satellite messaging and a faster chip.", Source: "yahoo"},
+	}
+}
+
+func TestNewsResult_SimHash(t *testing.T) {
+	news := testWireNews()
+	if news[1].SimHash() != news[7].SimHash() {
+		t.Error("identical text should hash identically")
+	}
+	near := bits.OnesCount64(news[1].SimHash() ^ news[3].SimHash())
+	far := bits.OnesCount64(news[1].SimHash() ^ news[4].SimHash())
+	if near > DefaultNewsClusterDistance || far <= DefaultNewsClusterDistance {
+		t.Errorf("distances: rewrite %d, different story %d (threshold %d)", near, far, DefaultNewsClusterDistance)
+	}
+}
+
+func TestClusterNews(t *testing.T) {
+	resp := &NewsResponse{News: testWireNews()}
+	clusters := resp.Clusters()
+
+	var got []string
+	for _, c := range clusters {
+		var positions []string
+		for _, item := range c.Items {
+			positions = append(positions, string(rune('0'+item.Position)))
+		}
+		got = append(got, strings.Join(positions, ","))
+	}
+	want := []string{"1", "2,4,6,8", "3,7", "5"}
+	if strings.Join(got, " | ") != strings.Join(want, " | ") {
+		t.Fatalf("clusters: got %v, want %v", got, want)
+	}
+
+	apple := clusters[1]
+	if apple.Representative.Position != 2 {
+		t.Errorf("representative: got position %d, want 2", apple.Representative.Position)
+	}
+	if strings.Join(apple.Sources, ",") != "Reuters,MSN" {
+		t.Errorf("sources: got %v (duplicate and representative outlets should be dropped)", apple.Sources)
+	}
+	if len(clusters[3].Sources) != 0 {
+		t.Errorf("singleton sources: got %v", clusters[3].Sources)
+	}
+}
+
+func TestClusterNews_Deterministic(t *testing.T) {
+	first := ClusterNews(testWireNews(), 0)
+	for range 10 {
+		again := ClusterNews(testWireNews(), 0)
+		if len(again) != len(first) {
+			t.Fatalf("cluster count changed: %d vs %d", len(again), len(first))
+		}
+		for i := range first {
+			if again[i].Representative != first[i].Representative || len(again[i].Items) != len(first[i].Items) {
+				t.Fatalf("cluster %d changed between runs", i)
+			}
+		}
+	}
+}
+
+func TestClusterNews_RepresentativeWithoutPositions(t *testing.T) {
+	news := testWireNews()
+	for i := range news {
+		news[i].Position = 0
+	}
+	clusters := ClusterNews(news, 0)
+	if clusters[1].Representative.Source != "Yahoo" {
+		t.Errorf("without positions the first item should represent the cluster, got %q", clusters[1].Representative.Source)
+	}
+}
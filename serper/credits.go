@@ -0,0 +1,73 @@
+package serper
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrCreditLimit is returned when a request would exceed a CreditBudget.
+var ErrCreditLimit = errors.New("serper: credit limit reached")
+
+// CreditBudget caps the Serper credits a long-running job may spend.
+// It is safe for concurrent use. A nil *CreditBudget is unlimited.
+type CreditBudget struct {
+	mu    sync.Mutex
+	max   int
+	spent int
+}
+
+// NewCreditBudget returns a budget allowing up to max credits.
+// A max of zero or less means unlimited, but spending is still counted.
+func NewCreditBudget(max int) *CreditBudget {
+	return &CreditBudget{max: max}
+}
+
+// Reserve records n credits as spent, or returns ErrCreditLimit without
+// spending anything if that would exceed the budget.
+func (b *CreditBudget) Reserve(n int) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.max > 0 && b.spent+n > b.max {
+		return ErrCreditLimit
+	}
+	b.spent += n
+	return nil
+}
+
+// Spent returns the credits reserved so far.
+func (b *CreditBudget) Spent() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spent
+}
+
+// Remaining returns the credits left, or -1 if the budget is unlimited.
+func (b *CreditBudget) Remaining() int {
+	if b == nil || b.max <= 0 {
+		return -1
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.max - b.spent
+}
+
+// RequestCredits estimates what a request costs. Serper bills one credit
+// for up to 10 results and two for larger pages. Defaults are applied to
+// a copy first, so a zero Num counts as 10.
+func RequestCredits(req *SearchRequest) int {
+	if req == nil {
+		return 1
+	}
+	cp := *req
+	cp.SetDefaults()
+	if cp.Num > 10 {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,99 @@
+package serper
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var relativeDateRe = regexp.MustCompile(`^(\d+|an?|one)\s*(second|sec|s|minute|min|m|hour|hr|h|day|d|week|wk|w|month|mo|year|yr|y)s?\.?\s+ago$`)
+
+// absoluteDateLayouts are the date formats Serper returns for older results.
+var absoluteDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02",
+	"Jan 2, 2006",
+	"January 2, 2006",
+	"2 Jan 2006",
+	"2 January 2006",
+	"Jan 2006",
+	"01/02/2006",
+	"Jan 2",
+	"January 2",
+}
+
+// ParseResultDate parses a result date as shown by Google, such as
+// "2 hours ago", "yesterday", "Mar 15, 2024" or "2024-03-15", relative to
+// now. Dates without a year ("Mar 15") are placed in the most recent
+// matching year not after now. Relative months and years use calendar
+// arithmetic. ok is false if the string is not recognised.
+func ParseResultDate(s string, now time.Time) (t time.Time, ok bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "":
+		return time.Time{}, false
+	case "just now", "now":
+		return now, true
+	case "today":
+		return truncateDay(now), true
+	case "yesterday":
+		return truncateDay(now).AddDate(0, 0, -1), true
+	}
+
+	if m := relativeDateRe.FindStringSubmatch(s); m != nil {
+		n := 1
+		if m[1] != "a" && m[1] != "an" && m[1] != "one" {
+			n, _ = strconv.Atoi(m[1])
+		}
+		switch m[2] {
+		case "second", "sec", "s":
+			return now.Add(-time.Duration(n) * time.Second), true
+		case "minute", "min", "m":
+			return now.Add(-time.Duration(n) * time.Minute), true
+		case "hour", "hr", "h":
+			return now.Add(-time.Duration(n) * time.Hour), true
+		case "day", "d":
+			return now.AddDate(0, 0, -n), true
+		case "week", "wk", "w":
+			return now.AddDate(0, 0, -7*n), true
+		case "month", "mo":
+			return now.AddDate(0, -n, 0), true
+		default:
+			return now.AddDate(-n, 0, 0), true
+		}
+	}
+
+	s = strings.Join(strings.Fields(s), " ")
+	for _, layout := range absoluteDateLayouts {
+		parsed, err := time.ParseInLocation(layout, titleMonth(s), now.Location())
+		if err != nil {
+			continue
+		}
+		if parsed.Year() == 0 {
+			parsed = parsed.AddDate(now.Year(), 0, 0)
+			if parsed.After(now) {
+				parsed = parsed.AddDate(-1, 0, 0)
+			}
+		}
+		return parsed, true
+	}
+	return time.Time{}, false
+}
+
+// titleMonth restores the capitalisation time.Parse expects for month
+// names in an already lower-cased date.
+func titleMonth(s string) string {
+	b := []byte(s)
+	for i := range b {
+		if b[i] >= 'a' && b[i] <= 'z' && (i == 0 || b[i-1] == ' ' || b[i-1] >= '0' && b[i-1] <= '9') {
+			b[i] -= 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
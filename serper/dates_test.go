@@ -0,0 +1,45 @@
+package serper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseResultDate(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2 hours ago", now.Add(-2 * time.Hour)},
+		{"1 hour ago", now.Add(-time.Hour)},
+		{"45 mins ago", now.Add(-45 * time.Minute)},
+		{"an hour ago", now.Add(-time.Hour)},
+		{"3 days ago", now.AddDate(0, 0, -3)},
+		{"2 weeks ago", now.AddDate(0, 0, -14)},
+		{"1 month ago", now.AddDate(0, -1, 0)},
+		{"yesterday", time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)},
+		{"Mar 15, 2024", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"15 March 2024", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"2024-03-15", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"Feb 2", time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC)},
+		{"Dec 24", time.Date(2025, 12, 24, 0, 0, 0, 0, time.UTC)}, // no year: most recent past date
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := ParseResultDate(tt.in, now)
+			if !ok {
+				t.Fatalf("ParseResultDate(%q): not recognised", tt.in)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseResultDate(%q): got %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	for _, bad := range []string{"", "sometime", "13/45/2024"} {
+		if _, ok := ParseResultDate(bad, now); ok {
+			t.Errorf("ParseResultDate(%q): expected not ok", bad)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package serper
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// SearchDiff describes how a SERP changed between two responses for the
+// same query.
+type SearchDiff struct {
+	Query string `json:"query"`
+
+	// Organic results that appeared, disappeared or changed position,
+	// matched by normalised link. Entered and Moved are ordered by their
+	// new position, Left by the old one.
+	Entered []RankChange `json:"entered,omitempty"`
+	Left    []RankChange `json:"left,omitempty"`
+	Moved   []RankChange `json:"moved,omitempty"`
+
+	KnowledgeGraph *PanelChange `json:"knowledgeGraph,omitempty"`
+	AnswerBox      *PanelChange `json:"answerBox,omitempty"`
+
+	// Questions added to or removed from People Also Ask.
+	QuestionsAdded   []string `json:"questionsAdded,omitempty"`
+	QuestionsRemoved []string `json:"questionsRemoved,omitempty"`
+
+	// SERP features (e.g. "answer_box", "people_also_ask") that appeared
+	// or disappeared.
+	FeaturesAdded   []string `json:"featuresAdded,omitempty"`
+	FeaturesRemoved []string `json:"featuresRemoved,omitempty"`
+}
+
+// RankChange is an organic result's position before and after. A zero
+// position means the result was absent.
+type RankChange struct {
+	Link  string `json:"link"`
+	Title string `json:"title"`
+	From  int    `json:"from,omitempty"`
+	To    int    `json:"to,omitempty"`
+}
+
+// Delta returns how many places the result climbed; negative if it fell.
+// It is zero for results that entered or left.
+func (c RankChange) Delta() int {
+	if c.From == 0 || c.To == 0 {
+		return 0
+	}
+	return c.From - c.To
+}
+
+// PanelChange reports a knowledge graph or answer box that was added,
+// removed or edited. Fields lists the JSON names of edited fields.
+type PanelChange struct {
+	Status string   `json:"status"` // "added", "removed" or "changed"
+	Title  string   `json:"title"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Empty reports whether nothing changed.
+func (d *SearchDiff) Empty() bool {
+	return len(d.Entered) == 0 && len(d.Left) == 0 && len(d.Moved) == 0 &&
+		d.KnowledgeGraph == nil && d.AnswerBox == nil &&
+		len(d.QuestionsAdded) == 0 && len(d.QuestionsRemoved) == 0 &&
+		len(d.FeaturesAdded) == 0 && len(d.FeaturesRemoved) == 0
+}
+
+// DiffSearch compares an older response a with a newer response b.
+func DiffSearch(a, b *SearchResponse) *SearchDiff {
+	if a == nil {
+		a = &SearchResponse{}
+	}
+	if b == nil {
+		b = &SearchResponse{}
+	}
+	d := &SearchDiff{Query: b.SearchParameters.Q}
+	if d.Query == "" {
+		d.Query = a.SearchParameters.Q
+	}
+
+	before, after := organicPositions(a.Organic), organicPositions(b.Organic)
+	for _, r := range rankedOrganic(b.Organic) {
+		old, ok := before[normalizeLink(r.Link)]
+		switch {
+		case !ok:
+			d.Entered = append(d.Entered, RankChange{Link: r.Link, Title: r.Title, To: r.Position})
+		case old != r.Position:
+			d.Moved = append(d.Moved, RankChange{Link: r.Link, Title: r.Title, From: old, To: r.Position})
+		}
+	}
+	for _, r := range rankedOrganic(a.Organic) {
+		if _, ok := after[normalizeLink(r.Link)]; !ok {
+			d.Left = append(d.Left, RankChange{Link: r.Link, Title: r.Title, From: r.Position})
+		}
+	}
+
+	d.KnowledgeGraph = diffPanel(knowledgeGraphFields(a.KnowledgeGraph), knowledgeGraphFields(b.KnowledgeGraph))
+	d.AnswerBox = diffPanel(answerBoxFields(a.AnswerBox), answerBoxFields(b.AnswerBox))
+
+	d.QuestionsAdded, d.QuestionsRemoved = diffStrings(questions(a.PeopleAlsoAsk), questions(b.PeopleAlsoAsk))
+	d.FeaturesAdded, d.FeaturesRemoved = diffStrings(a.FeatureNames(), b.FeatureNames())
+	return d
+}
+
+// rankedOrganic returns the results with positions filled from their order
+// where Serper left them zero, keeping only the first result per link.
+func rankedOrganic(results []OrganicResult) []OrganicResult {
+	seen := make(map[string]bool, len(results))
+	out := make([]OrganicResult, 0, len(results))
+	for i, r := range results {
+		key := normalizeLink(r.Link)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if r.Position <= 0 {
+			r.Position = i + 1
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func organicPositions(results []OrganicResult) map[string]int {
+	pos := make(map[string]int, len(results))
+	for _, r := range rankedOrganic(results) {
+		pos[normalizeLink(r.Link)] = r.Position
+	}
+	return pos
+}
+
+// panelFields is a panel's title plus its comparable fields by JSON name.
+// A nil map means the panel is absent.
+type panelFields map[string]string
+
+func knowledgeGraphFields(kg *KnowledgeGraph) panelFields {
+	if kg == nil {
+		return nil
+	}
+	f := panelFields{
+		"title":       kg.Title,
+		"type":        kg.Type,
+		"description": kg.Description,
+		"website":     kg.Website,
+		"imageUrl":    kg.ImageURL,
+	}
+	for _, k := range slices.Sorted(maps.Keys(kg.Attributes)) {
+		f["attributes."+k] = kg.Attributes[k]
+	}
+	return f
+}
+
+func answerBoxFields(ab *AnswerBox) panelFields {
+	if ab == nil {
+		return nil
+	}
+	return panelFields{
+		"title":   ab.Title,
+		"answer":  ab.Answer,
+		"snippet": ab.Snippet,
+		"link":    ab.Link,
+		"date":    ab.Date,
+	}
+}
+
+func diffPanel(a, b panelFields) *PanelChange {
+	switch {
+	case a == nil && b == nil:
+		return nil
+	case a == nil:
+		return &PanelChange{Status: "added", Title: b["title"]}
+	case b == nil:
+		return &PanelChange{Status: "removed", Title: a["title"]}
+	}
+	var fields []string
+	for _, k := range slices.Sorted(maps.Keys(a)) {
+		if a[k] != b[k] {
+			fields = append(fields, k)
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(b)) {
+		if _, ok := a[k]; !ok {
+			fields = append(fields, k)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return &PanelChange{Status: "changed", Title: b["title"], Fields: fields}
+}
+
+func questions(paa []PeopleAlsoAsk) []string {
+	out := make([]string, 0, len(paa))
+	for _, q := range paa {
+		out = append(out, q.Question)
+	}
+	return out
+}
+
+// diffStrings returns the values of b missing from a, and of a missing
+// from b, comparing case-insensitively and preserving order.
+func diffStrings(a, b []string) (added, removed []string) {
+	inA := make(map[string]bool, len(a))
+	for _, s := range a {
+		inA[strings.ToLower(s)] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, s := range b {
+		inB[strings.ToLower(s)] = true
+	}
+	for _, s := range b {
+		if !inA[strings.ToLower(s)] {
+			added = append(added, s)
+		}
+	}
+	for _, s := range a {
+		if !inB[strings.ToLower(s)] {
+			removed = append(removed, s)
+		}
+	}
+	return added, removed
+}
+
+// Text renders the diff as plain text for terminals and emails.
+func (d *SearchDiff) Text() string {
+	var b strings.Builder
+	if d.Query != "" {
+		fmt.Fprintf(&b, "Query: %s\n", d.Query)
+	}
+	if d.Empty() {
+		b.WriteString("No changes.\n")
+		return b.String()
+	}
+	section := func(title string, changes []RankChange, line func(RankChange) string) {
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "%s:\n", title)
+		for _, c := range changes {
+			fmt.Fprintf(&b, "  %s %s (%s)\n", line(c), c.Title, c.Link)
+		}
+	}
+	section("Entered", d.Entered, func(c RankChange) string { return fmt.Sprintf("+ #%d", c.To) })
+	section("Left", d.Left, func(c RankChange) string { return fmt.Sprintf("- #%d", c.From) })
+	section("Moved", d.Moved, func(c RankChange) string { return fmt.Sprintf("#%d -> #%d (%+d)", c.From, c.To, c.Delta()) })
+	for _, p := range []struct {
+		name   string
+		change *PanelChange
+	}{{"Knowledge graph", d.KnowledgeGraph}, {"Answer box", d.AnswerBox}} {
+		if p.change != nil {
+			fmt.Fprintf(&b, "%s: %s\n", p.name, p.change.describe())
+		}
+	}
+	list := func(title string, added, removed []string) {
+		if len(added) == 0 && len(removed) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "%s:\n", title)
+		for _, s := range added {
+			fmt.Fprintf(&b, "  + %s\n", s)
+		}
+		for _, s := range removed {
+			fmt.Fprintf(&b, "  - %s\n", s)
+		}
+	}
+	list("People also ask", d.QuestionsAdded, d.QuestionsRemoved)
+	list("Features", d.FeaturesAdded, d.FeaturesRemoved)
+	return b.String()
+}
+
+// Markdown renders the diff as Markdown for reports.
+func (d *SearchDiff) Markdown() string {
+	var b strings.Builder
+	title := "SERP changes"
+	if d.Query != "" {
+		title += ": " + d.Query
+	}
+	fmt.Fprintf(&b, "## %s\n\n", markdownEscape(title))
+	if d.Empty() {
+		b.WriteString("No changes.\n")
+		return b.String()
+	}
+	if len(d.Entered)+len(d.Left)+len(d.Moved) > 0 {
+		b.WriteString("| Change | Before | After | Result |\n|---|---|---|---|\n")
+		row := func(kind string, c RankChange) {
+			fmt.Fprintf(&b, "| %s | %s | %s | [%s](%s) |\n", kind, positionCell(c.From), positionCell(c.To),
+				markdownEscape(c.Title), markdownURL(c.Link))
+		}
+		for _, c := range d.Entered {
+			row("entered", c)
+		}
+		for _, c := range d.Moved {
+			row(fmt.Sprintf("moved %+d", c.Delta()), c)
+		}
+		for _, c := range d.Left {
+			row("left", c)
+		}
+		b.WriteString("\n")
+	}
+	for _, p := range []struct {
+		name   string
+		change *PanelChange
+	}{{"Knowledge graph", d.KnowledgeGraph}, {"Answer box", d.AnswerBox}} {
+		if p.change != nil {
+			fmt.Fprintf(&b, "**%s:** %s\n\n", p.name, markdownEscape(p.change.describe()))
+		}
+	}
+	list := func(title string, added, removed []string) {
+		if len(added) == 0 && len(removed) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "**%s**\n\n", title)
+		for _, s := range added {
+			fmt.Fprintf(&b, "- added: %s\n", markdownEscape(s))
+		}
+		for _, s := range removed {
+			fmt.Fprintf(&b, "- removed: %s\n", markdownEscape(s))
+		}
+		b.WriteString("\n")
+	}
+	list("People also ask", d.QuestionsAdded, d.QuestionsRemoved)
+	list("Features", d.FeaturesAdded, d.FeaturesRemoved)
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+func (p *PanelChange) describe() string {
+	s := p.Status
+	if p.Title != "" {
+		s += fmt.Sprintf(" (%s)", p.Title)
+	}
+	if len(p.Fields) > 0 {
+		s += ": " + strings.Join(p.Fields, ", ")
+	}
+	return s
+}
+
+func positionCell(pos int) string {
+	if pos == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("#%d", pos)
+}
+
+// markdownEscaper escapes characters that would break a Markdown table or
+// link text, or start markup, and folds line breaks into spaces.
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "[", `\[`, "]", `\]`, "*", `\*`, "_", `\_`, "`", "\\`",
+	"<", `\<`, ">", `\>`, "\n", " ", "\r", " ")
+
+func markdownEscape(s string) string { return markdownEscaper.Replace(s) }
+
+// markdownURLEscaper percent-encodes what could end a <...> link
+// destination or a table cell.
+var markdownURLEscaper = strings.NewReplacer(`\`, "%5C", "<", "%3C", ">", "%3E", " ", "%20", "|", "%7C", "\n", "%0A", "\r", "%0D")
+
+// markdownURL returns link as a <...> link destination, in which
+// parentheses and the characters escaped above cannot end the link.
+func markdownURL(link string) string { return "<" + markdownURLEscaper.Replace(link) + ">" }
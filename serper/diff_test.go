@@ -0,0 +1,122 @@
+package serper
+
+import (
+	"strings"
+	"testing"
+)
+
+func diffFixtures() (a, b *SearchResponse) {
+	a = &SearchResponse{
+		SearchParameters: SearchParameters{Q: "best coffee"},
+		KnowledgeGraph:   &KnowledgeGraph{Title: "Coffee", Description: "A brewed drink", Attributes: map[string]string{"Origin": "Ethiopia"}},
+		Organic: []OrganicResult{
+			{Title: "Roasters", Link: "https://roasters.example/", Position: 1},
+			{Title: "Guide", Link: "https://guide.example/coffee", Position: 2},
+			{Title: "Old blog", Link: "https://blog.example/post", Position: 3},
+			{Title: "Shop", Link: "https://shop.example/", Position: 4},
+		},
+		PeopleAlsoAsk: []PeopleAlsoAsk{{Question: "What is the best coffee?"}, {Question: "Is coffee healthy?"}},
+	}
+	b = &SearchResponse{
+		SearchParameters: SearchParameters{Q: "best coffee"},
+		AnswerBox:        &AnswerBox{Title: "Top pick", Answer: "Ethiopian Yirgacheffe"},
+		KnowledgeGraph:   &KnowledgeGraph{Title: "Coffee", Description: "A brewed drink made from roasted beans", Attributes: map[string]string{"Origin": "Ethiopia"}},
+		Organic: []OrganicResult{
+			{Title: "Guide", Link: "https://guide.example/coffee#top", Position: 1},
+			{Title: "Roasters", Link: "https://Roasters.example", Position: 2},
+			{Title: "New review", Link: "https://review.example/", Position: 3},
+			{Title: "Shop", Link: "https://shop.example/", Position: 4},
+		},
+		PeopleAlsoAsk: []PeopleAlsoAsk{{Question: "what is the best coffee?"}, {Question: "How much caffeine is in coffee?"}},
+	}
+	return a, b
+}
+
+func TestDiffSearch(t *testing.T) {
+	d := DiffSearch(diffFixtures())
+
+	if len(d.Entered) != 1 || d.Entered[0].Link != "https://review.example/" || d.Entered[0].To != 3 {
+		t.Errorf("entered: got %+v", d.Entered)
+	}
+	if len(d.Left) != 1 || d.Left[0].Title != "Old blog" || d.Left[0].From != 3 {
+		t.Errorf("left: got %+v", d.Left)
+	}
+	if len(d.Moved) != 2 || d.Moved[0].Title != "Guide" || d.Moved[0].Delta() != 1 || d.Moved[1].Delta() != -1 {
+		t.Errorf("moved: got %+v", d.Moved)
+	}
+	if d.KnowledgeGraph == nil || d.KnowledgeGraph.Status != "changed" || strings.Join(d.KnowledgeGraph.Fields, ",") != "description" {
+		t.Errorf("knowledge graph: got %+v", d.KnowledgeGraph)
+	}
+	if d.AnswerBox == nil || d.AnswerBox.Status != "added" || d.AnswerBox.Title != "Top pick" {
+		t.Errorf("answer box: got %+v", d.AnswerBox)
+	}
+	if strings.Join(d.QuestionsAdded, "|") != "How much caffeine is in coffee?" || strings.Join(d.QuestionsRemoved, "|") != "Is coffee healthy?" {
+		t.Errorf("questions: added %v removed %v", d.QuestionsAdded, d.QuestionsRemoved)
+	}
+	if strings.Join(d.FeaturesAdded, ",") != "answer_box" || len(d.FeaturesRemoved) != 0 {
+		t.Errorf("features: added %v removed %v", d.FeaturesAdded, d.FeaturesRemoved)
+	}
+}
+
+func TestDiffSearch_Identical(t *testing.T) {
+	a, _ := diffFixtures()
+	d := DiffSearch(a, a)
+	if !d.Empty() {
+		t.Errorf("identical responses should produce an empty diff, got %+v", d)
+	}
+	if got := d.Text(); got != "Query: best coffee\nNo changes.\n" {
+		t.Errorf("Text: got %q", got)
+	}
+}
+
+func TestSearchDiff_Text(t *testing.T) {
+	text := DiffSearch(diffFixtures()).Text()
+	for _, want := range []string{
+		"Entered:\n  + #3 New review (https://review.example/)\n",
+		"Left:\n  - #3 Old blog (https://blog.example/post)\n",
+		"  #2 -> #1 (+1) Guide",
+		"  #1 -> #2 (-1) Roasters",
+		"Knowledge graph: changed (Coffee): description\n",
+		"Answer box: added (Top pick)\n",
+		"People also ask:\n  + How much caffeine is in coffee?\n  - Is coffee healthy?\n",
+		"Features:\n  + answer_box\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text should contain %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestSearchDiff_MarkdownEscapesHostileResults(t *testing.T) {
+	a, b := diffFixtures()
+	b.Organic[2].Title = "Win](https://evil.example) | <img src=x>\n# Pwned"
+	b.Organic[2].Link = "https://evil.example/a b) |\n<x>\\"
+	md := DiffSearch(a, b).Markdown()
+	want := `| entered | - | #3 | [Win\](https://evil.example) \| \<img src=x\> # Pwned](<https://evil.example/a%20b)%20%7C%0A%3Cx%3E%5C>) |`
+	if !strings.Contains(md, want+"\n") {
+		t.Errorf("Markdown should contain %q:\n%s", want, md)
+	}
+	for _, line := range strings.Split(md, "\n") {
+		if strings.HasPrefix(line, "# ") {
+			t.Errorf("title injected a heading: %q", line)
+		}
+	}
+}
+
+func TestSearchDiff_Markdown(t *testing.T) {
+	a, b := diffFixtures()
+	b.Organic[2].Title = "Review | 2026"
+	md := DiffSearch(a, b).Markdown()
+	for _, want := range []string{
+		"## SERP changes: best coffee\n",
+		"| entered | - | #3 | [Review \\| 2026](<https://review.example/>) |",
+		"| moved +1 | #2 | #1 | [Guide](<https://guide.example/coffee#top>) |",
+		"| left | #3 | - | [Old blog](<https://blog.example/post>) |",
+		"**Answer box:** added (Top pick)",
+		"- added: answer\\_box",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown should contain %q:\n%s", want, md)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package serper
+
+import (
+	"net/url"
+	"strings"
+)
+
+// websiteHost returns the lower-cased host of a URL or bare domain, without "www.".
+func websiteHost(s string) string {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if !strings.Contains(s, "://") {
+		s = "http://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
+
+// hostWithinDomain reports whether host is domain itself or, when
+// subdomains is set, any host below it. Both must already be normalised
+// by websiteHost.
+func hostWithinDomain(host, domain string, subdomains bool) bool {
+	if host == "" || domain == "" {
+		return false
+	}
+	return host == domain || subdomains && strings.HasSuffix(host, "."+domain)
+}
+
+// pathWithin reports whether path is prefix or lies below it, matching
+// whole segments: "/docs" covers "/docs" and "/docs/x" but not "/docsy".
+func pathWithin(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return true
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
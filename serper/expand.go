@@ -0,0 +1,264 @@
+package serper
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Query expansion defaults.
+const (
+	defaultExpandDepth       = 2
+	defaultExpandNodes       = 100
+	defaultExpandConcurrency = 4
+)
+
+// Query node kinds, also used as edge kinds.
+const (
+	QueryKindSeed     = "seed"
+	QueryKindQuestion = "question" // from People Also Ask
+	QueryKindRelated  = "related"  // from related searches
+)
+
+// Reasons an expansion stopped before exhausting its depth.
+const (
+	ExpandStoppedNodes   = "max_nodes"
+	ExpandStoppedCredits = "credit_limit"
+)
+
+// ExpandConfig configures ExpandQueries.
+type ExpandConfig struct {
+	Seeds       []string
+	Market      Market
+	MaxDepth    int           // levels of expansion below the seeds (default 2)
+	MaxNodes    int           // queries in the graph, seeds included (default 100)
+	Concurrency int           // parallel searches per level (default 4)
+	Budget      *CreditBudget // nil means unlimited; responses served from the client cache are free
+	Sink        Sink          // optional; receives EventExpandComplete
+}
+
+// QueryNode is a query in a QueryGraph.
+type QueryNode struct {
+	ID    int    `json:"id"`
+	Query string `json:"query"`
+	Kind  string `json:"kind"`  // how the query was first found
+	Depth int    `json:"depth"` // 0 for seeds
+	// Searched reports whether the query was expanded. Queries at
+	// MaxDepth, and those left when the expansion stopped, are not.
+	Searched bool   `json:"searched"`
+	Error    string `json:"error,omitempty"`
+}
+
+// QueryEdge links a query to one suggested by its results.
+type QueryEdge struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Kind string `json:"kind"`
+}
+
+// QueryGraph is the question graph found by ExpandQueries. A query found
+// from several parents appears once, with an edge from each.
+type QueryGraph struct {
+	Nodes []QueryNode `json:"nodes"`
+	Edges []QueryEdge `json:"edges"`
+	// Stopped is ExpandStoppedNodes or ExpandStoppedCredits if a limit
+	// ended the expansion early, and empty otherwise.
+	Stopped string `json:"stopped,omitempty"`
+}
+
+// ExpandQueries explores People Also Ask questions and related searches
+// breadth-first from cfg.Seeds, searching each level before the next.
+// Queries are deduplicated after case, whitespace and trailing punctuation
+// are folded. Node IDs, and the order of nodes and edges, follow discovery:
+// parents in order, each parent's questions before its related searches.
+//
+// Expansion stops at MaxDepth, when MaxNodes queries have been found, or
+// when the budget runs out. A failed search is recorded on its node. Only
+// invalid configuration or a cancelled context returns an error. If
+// cfg.Sink is set the graph is sent to it; a failed notification is
+// returned together with the graph.
+func (c *Client) ExpandQueries(ctx context.Context, cfg ExpandConfig) (*QueryGraph, error) {
+	if cfg.MaxDepth <= 0 {
+		cfg.MaxDepth = defaultExpandDepth
+	}
+	if cfg.MaxNodes <= 0 {
+		cfg.MaxNodes = defaultExpandNodes
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaultExpandConcurrency
+	}
+
+	g := &QueryGraph{}
+	ids := make(map[string]int)
+	edges := make(map[QueryEdge]bool)
+	// add returns the node for query, creating it unless the graph is full.
+	add := func(query, kind string, depth int) (int, bool) {
+		key := normalizeQuery(query)
+		if key == "" {
+			return 0, false
+		}
+		if id, ok := ids[key]; ok {
+			return id, true
+		}
+		if len(g.Nodes) >= cfg.MaxNodes {
+			g.Stopped = ExpandStoppedNodes
+			return 0, false
+		}
+		id := len(g.Nodes)
+		ids[key] = id
+		g.Nodes = append(g.Nodes, QueryNode{ID: id, Query: strings.TrimSpace(query), Kind: kind, Depth: depth})
+		return id, true
+	}
+	link := func(from, to int, kind string) {
+		e := QueryEdge{From: from, To: to, Kind: kind}
+		if from != to && !edges[e] {
+			edges[e] = true
+			g.Edges = append(g.Edges, e)
+		}
+	}
+
+	var frontier []int
+	for _, s := range cfg.Seeds {
+		if id, ok := add(s, QueryKindSeed, 0); ok && !slices.Contains(frontier, id) {
+			frontier = append(frontier, id)
+		}
+	}
+	if len(frontier) == 0 {
+		return nil, fmt.Errorf("serper: query expansion: no seeds")
+	}
+
+	for depth := 0; depth < cfg.MaxDepth && len(frontier) > 0; depth++ {
+		resps := make([]*SearchResponse, len(frontier))
+		errs := make([]error, len(frontier))
+		sem := make(chan struct{}, cfg.Concurrency)
+		var wg sync.WaitGroup
+		for i, id := range frontier {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				wg.Wait()
+				return nil, ctx.Err()
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				req := &SearchRequest{Q: g.Nodes[id].Query, GL: cfg.Market.GL, HL: cfg.Market.HL, Location: cfg.Market.Location}
+				if err := c.reserveUncached(ctx, cfg.Budget, "/search", req); err != nil {
+					errs[i] = err
+					return
+				}
+				resps[i], errs[i] = c.Search(ctx, req)
+			}()
+		}
+		wg.Wait()
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var next []int
+		for i, id := range frontier {
+			if errs[i] != nil {
+				g.Nodes[id].Error = errs[i].Error()
+				if errors.Is(errs[i], ErrCreditLimit) {
+					g.Stopped = ExpandStoppedCredits
+				}
+				continue
+			}
+			g.Nodes[id].Searched = true
+			child := func(query, kind string) {
+				to, ok := add(query, kind, depth+1)
+				if !ok {
+					return
+				}
+				link(id, to, kind)
+				if g.Nodes[to].Depth == depth+1 && !slices.Contains(next, to) {
+					next = append(next, to)
+				}
+			}
+			for _, q := range resps[i].PeopleAlsoAsk {
+				child(q.Question, QueryKindQuestion)
+			}
+			for _, r := range resps[i].RelatedSearches {
+				child(r.Query, QueryKindRelated)
+			}
+		}
+		// Out of credits, or a full graph that further searches cannot grow.
+		if g.Stopped != "" {
+			break
+		}
+		frontier = next
+	}
+	if err := Notify(ctx, cfg.Sink, EventExpandComplete, g); err != nil {
+		return g, fmt.Errorf("serper: query expansion: notify: %w", err)
+	}
+	return g, nil
+}
+
+// normalizeQuery folds case and whitespace and drops trailing punctuation,
+// so "What is Go?" and "what is go" are the same query.
+func normalizeQuery(q string) string {
+	q = strings.ToLower(strings.Join(strings.Fields(q), " "))
+	return strings.TrimRight(q, "?!.,;: ")
+}
+
+// DOT renders the graph in Graphviz DOT. Related-search edges are dashed.
+func (g *QueryGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph queries {\n  rankdir=LR;\n  node [shape=box];\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&b, "  n%d [label=%s", n.ID, dotQuote(n.Query))
+		if n.Kind == QueryKindSeed {
+			b.WriteString(", style=bold")
+		}
+		b.WriteString("];\n")
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  n%d -> n%d", e.From, e.To)
+		if e.Kind == QueryKindRelated {
+			b.WriteString(" [style=dashed]")
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotQuote returns s as a double-quoted DOT string.
+func dotQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
+
+// WriteCSV writes one row per edge: parent, query, kind, depth, error.
+// Seeds come first with an empty parent. Depth and error describe the
+// child query.
+func (g *QueryGraph) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"parent", "query", "kind", "depth", "error"}); err != nil {
+		return err
+	}
+	row := func(parent string, n QueryNode, kind string) error {
+		return cw.Write([]string{parent, n.Query, kind, strconv.Itoa(n.Depth), n.Error})
+	}
+	for _, n := range g.Nodes {
+		if n.Kind == QueryKindSeed {
+			if err := row("", n, n.Kind); err != nil {
+				return err
+			}
+		}
+	}
+	for _, e := range g.Edges {
+		if err := row(g.Nodes[e.From].Query, g.Nodes[e.To], e.Kind); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
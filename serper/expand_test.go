@@ -0,0 +1,175 @@
+package serper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// questionResponses builds fakeDoer responses carrying the given PAA
+// questions and related searches, keyed by query.
+func questionResponses(paa, related map[string][]string) map[string]any {
+	out := make(map[string]any)
+	for _, m := range []map[string][]string{paa, related} {
+		for q := range m {
+			var resp SearchResponse
+			for _, p := range paa[q] {
+				resp.PeopleAlsoAsk = append(resp.PeopleAlsoAsk, PeopleAlsoAsk{Question: p})
+			}
+			for _, r := range related[q] {
+				resp.RelatedSearches = append(resp.RelatedSearches, RelatedSearch{Query: r})
+			}
+			out[q] = resp
+		}
+	}
+	return out
+}
+
+var (
+	testPAA = map[string][]string{
+		"coffee":                {"Is coffee healthy?", "How much caffeine is in coffee?"},
+		"Is coffee healthy?":    {"Is coffee bad for your heart?", "how much caffeine is in coffee"},
+		"coffee beans":          {"Is coffee healthy"},
+		"decaf coffee":          {"Is decaf coffee healthy?"},
+		"espresso":              {"Is espresso stronger?"},
+		"Is espresso stronger?": {"too deep"},
+	}
+	testRelated = map[string][]string{
+		"coffee": {"coffee beans", "Coffee  Beans"},
+	}
+)
+
+func TestExpandQueries(t *testing.T) {
+	var calls atomic.Int32
+	c := mustNew(t, "key", WithDoer(fakeDoer(questionResponses(testPAA, testRelated), &calls)))
+	g, err := c.ExpandQueries(context.Background(), ExpandConfig{Seeds: []string{"coffee", "COFFEE"}, MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("ExpandQueries: %v", err)
+	}
+
+	var queries []string
+	for _, n := range g.Nodes {
+		queries = append(queries, n.Query)
+	}
+	want := []string{"coffee", "Is coffee healthy?", "How much caffeine is in coffee?", "coffee beans", "Is coffee bad for your heart?"}
+	if strings.Join(queries, "|") != strings.Join(want, "|") {
+		t.Errorf("nodes:\n got %q\nwant %q", queries, want)
+	}
+	// Depth 0 and 1 are searched; depth 2 is the frontier left unexpanded.
+	if calls.Load() != 4 {
+		t.Errorf("calls: got %d, want 4", calls.Load())
+	}
+	if n := g.Nodes[4]; n.Depth != 2 || n.Searched || n.Kind != QueryKindQuestion {
+		t.Errorf("leaf: got %+v", n)
+	}
+	if n := g.Nodes[3]; n.Kind != QueryKindRelated || !n.Searched {
+		t.Errorf("related node: got %+v", n)
+	}
+	wantEdges := []QueryEdge{
+		{0, 1, QueryKindQuestion}, {0, 2, QueryKindQuestion}, {0, 3, QueryKindRelated},
+		{1, 4, QueryKindQuestion}, {1, 2, QueryKindQuestion}, {3, 1, QueryKindQuestion},
+	}
+	if len(g.Edges) != len(wantEdges) {
+		t.Fatalf("edges: got %+v", g.Edges)
+	}
+	for i, e := range wantEdges {
+		if g.Edges[i] != e {
+			t.Errorf("edge %d: got %+v, want %+v", i, g.Edges[i], e)
+		}
+	}
+	if g.Stopped != "" {
+		t.Errorf("stopped: got %q", g.Stopped)
+	}
+}
+
+func TestExpandQueries_Limits(t *testing.T) {
+	t.Run("nodes", func(t *testing.T) {
+		var calls atomic.Int32
+		c := mustNew(t, "key", WithDoer(fakeDoer(questionResponses(testPAA, testRelated), &calls)))
+		g, err := c.ExpandQueries(context.Background(), ExpandConfig{Seeds: []string{"coffee"}, MaxNodes: 2})
+		if err != nil {
+			t.Fatalf("ExpandQueries: %v", err)
+		}
+		if len(g.Nodes) != 2 || g.Stopped != ExpandStoppedNodes || calls.Load() != 1 {
+			t.Errorf("got %d nodes, stopped %q, %d calls", len(g.Nodes), g.Stopped, calls.Load())
+		}
+	})
+	t.Run("credits", func(t *testing.T) {
+		var calls atomic.Int32
+		c := mustNew(t, "key", WithDoer(fakeDoer(questionResponses(testPAA, testRelated), &calls)))
+		g, err := c.ExpandQueries(context.Background(), ExpandConfig{
+			Seeds:       []string{"coffee", "decaf coffee", "espresso"},
+			Concurrency: 1,
+			Budget:      NewCreditBudget(2),
+		})
+		if err != nil {
+			t.Fatalf("ExpandQueries: %v", err)
+		}
+		if g.Stopped != ExpandStoppedCredits || calls.Load() != 2 {
+			t.Errorf("stopped %q after %d calls", g.Stopped, calls.Load())
+		}
+		if !strings.Contains(g.Nodes[2].Error, ErrCreditLimit.Error()) {
+			t.Errorf("espresso should record the credit limit, got %+v", g.Nodes[2])
+		}
+		for _, n := range g.Nodes[3:] {
+			if n.Searched {
+				t.Errorf("no query should be searched after the budget ran out: %+v", n)
+			}
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		c := mustNew(t, "key", WithDoer(doerFunc(func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("unreachable")
+		})))
+		if _, err := c.ExpandQueries(context.Background(), ExpandConfig{Seeds: []string{" ?"}}); err == nil {
+			t.Error("expected an error for no seeds")
+		}
+		g, err := c.ExpandQueries(context.Background(), ExpandConfig{Seeds: []string{"coffee"}})
+		if err != nil || !strings.Contains(g.Nodes[0].Error, "unreachable") {
+			t.Errorf("failed search: got %+v, %v", g, err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := c.ExpandQueries(ctx, ExpandConfig{Seeds: []string{"a", "b"}}); !errors.Is(err, context.Canceled) {
+			t.Errorf("cancelled: got %v", err)
+		}
+	})
+}
+
+func TestQueryGraph_Output(t *testing.T) {
+	g := &QueryGraph{
+		Nodes: []QueryNode{
+			{ID: 0, Query: "coffee", Kind: QueryKindSeed, Searched: true},
+			{ID: 1, Query: `what is "crema"?`, Kind: QueryKindQuestion, Depth: 1},
+			{ID: 2, Query: "coffee beans", Kind: QueryKindRelated, Depth: 1, Error: "boom"},
+		},
+		Edges: []QueryEdge{{0, 1, QueryKindQuestion}, {0, 2, QueryKindRelated}},
+	}
+	dot := g.DOT()
+	for _, want := range []string{
+		`n0 [label="coffee", style=bold];`,
+		`n1 [label="what is \"crema\"?"];`,
+		"n0 -> n1;",
+		"n0 -> n2 [style=dashed];",
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT should contain %q:\n%s", want, dot)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := g.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	want := "parent,query,kind,depth,error\n" +
+		",coffee,seed,0,\n" +
+		"coffee,\"what is \"\"crema\"\"?\",question,1,\n" +
+		"coffee,coffee beans,related,1,boom\n"
+	if buf.String() != want {
+		t.Errorf("CSV:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,220 @@
+package serper
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SERP feature names.
+const (
+	FeatureAnswerBox       = "answer_box"
+	FeatureKnowledgeGraph  = "knowledge_graph"
+	FeaturePeopleAlsoAsk   = "people_also_ask"
+	FeatureTopStories      = "top_stories"
+	FeatureImages          = "images"
+	FeatureVideos          = "videos"
+	FeatureSitelinks       = "sitelinks"
+	FeatureRelatedSearches = "related_searches"
+)
+
+// featureOrder is the order features are listed in.
+var featureOrder = []string{
+	FeatureAnswerBox, FeatureKnowledgeGraph, FeaturePeopleAlsoAsk, FeatureTopStories,
+	FeatureImages, FeatureVideos, FeatureSitelinks, FeatureRelatedSearches,
+}
+
+// Feature placements relative to the organic results.
+const (
+	PlacementTop    = "top"    // above the first organic result
+	PlacementInline = "inline" // among the organic results
+	PlacementSide   = "side"   // in the side panel
+	PlacementBottom = "bottom" // below the last organic result
+)
+
+// SERPFeature is a feature present on a results page.
+type SERPFeature struct {
+	Name      string `json:"name"`
+	Placement string `json:"placement"`
+	// Position is the organic position the feature is attached to, such as
+	// the result carrying sitelinks. It is zero when Serper does not report
+	// the slot, as for People Also Ask, top stories, and image and video
+	// packs, which Google mixes in among the organic results.
+	Position int `json:"position,omitempty"`
+	// Count is the number of items: questions, stories, images, videos,
+	// sitelinks or related searches; 1 for the answer box and knowledge
+	// graph.
+	Count int `json:"count"`
+}
+
+// Features lists the SERP features present in the response, in the order
+// of the Feature constants. Sitelinks are listed once, at the first result
+// carrying them, with Count totalled over all results.
+func (r *SearchResponse) Features() []SERPFeature {
+	var out []SERPFeature
+	if r.AnswerBox != nil {
+		out = append(out, SERPFeature{Name: FeatureAnswerBox, Placement: PlacementTop, Count: 1})
+	}
+	if r.KnowledgeGraph != nil {
+		out = append(out, SERPFeature{Name: FeatureKnowledgeGraph, Placement: PlacementSide, Count: 1})
+	}
+	if n := len(r.PeopleAlsoAsk); n > 0 {
+		out = append(out, SERPFeature{Name: FeaturePeopleAlsoAsk, Placement: PlacementInline, Count: n})
+	}
+	if n := len(r.TopStories); n > 0 {
+		out = append(out, SERPFeature{Name: FeatureTopStories, Placement: PlacementInline, Count: n})
+	}
+	if n := len(r.Images); n > 0 {
+		out = append(out, SERPFeature{Name: FeatureImages, Placement: PlacementInline, Count: n})
+	}
+	if n := len(r.Videos); n > 0 {
+		out = append(out, SERPFeature{Name: FeatureVideos, Placement: PlacementInline, Count: n})
+	}
+	sitelinks := SERPFeature{Name: FeatureSitelinks, Placement: PlacementInline}
+	for _, res := range rankedOrganic(r.Organic) {
+		if len(res.Sitelinks) == 0 {
+			continue
+		}
+		if sitelinks.Position == 0 {
+			sitelinks.Position = res.Position
+		}
+		sitelinks.Count += len(res.Sitelinks)
+	}
+	if sitelinks.Count > 0 {
+		out = append(out, sitelinks)
+	}
+	if n := len(r.RelatedSearches); n > 0 {
+		out = append(out, SERPFeature{Name: FeatureRelatedSearches, Placement: PlacementBottom, Count: n})
+	}
+	return out
+}
+
+// FeatureNames returns the names of the response's features.
+func (r *SearchResponse) FeatureNames() []string {
+	features := r.Features()
+	out := make([]string, len(features))
+	for i, f := range features {
+		out[i] = f.Name
+	}
+	return out
+}
+
+// FeatureStat summarises one feature across many responses.
+type FeatureStat struct {
+	Name string `json:"name"`
+	// Responses is how many responses showed the feature, and Rate that
+	// number as a fraction of all responses.
+	Responses int     `json:"responses"`
+	Rate      float64 `json:"rate"`
+	// AverageCount is the mean number of items where the feature appeared.
+	AverageCount float64 `json:"averageCount"`
+	// AveragePosition is the mean organic position the feature was attached
+	// to, over the responses that reported one; zero if none did.
+	AveragePosition float64 `json:"averagePosition,omitempty"`
+	// Keywords are the queries that triggered the feature, in input order.
+	Keywords []string `json:"keywords"`
+
+	count, positions, positioned int
+}
+
+// KeywordFeatures lists the features one query triggered.
+type KeywordFeatures struct {
+	Keyword  string        `json:"keyword"`
+	Features []SERPFeature `json:"features"`
+}
+
+// FeatureReport is the result of SummarizeFeatures.
+type FeatureReport struct {
+	Responses int `json:"responses"`
+	// Features has one entry per feature seen, in the order of the
+	// Feature constants.
+	Features []FeatureStat     `json:"features"`
+	Keywords []KeywordFeatures `json:"keywords"`
+}
+
+// SummarizeFeatures aggregates Features over many responses. Each response
+// is keyed by its echoed query (SearchParameters.Q). Nil responses are
+// skipped.
+func SummarizeFeatures(resps []*SearchResponse) *FeatureReport {
+	rep := &FeatureReport{}
+	stats := make(map[string]*FeatureStat)
+	for _, resp := range resps {
+		if resp == nil {
+			continue
+		}
+		rep.Responses++
+		kf := KeywordFeatures{Keyword: resp.SearchParameters.Q, Features: resp.Features()}
+		for _, f := range kf.Features {
+			st, ok := stats[f.Name]
+			if !ok {
+				st = &FeatureStat{Name: f.Name}
+				stats[f.Name] = st
+			}
+			st.Responses++
+			st.count += f.Count
+			if f.Position > 0 {
+				st.positions += f.Position
+				st.positioned++
+			}
+			st.Keywords = append(st.Keywords, kf.Keyword)
+		}
+		rep.Keywords = append(rep.Keywords, kf)
+	}
+	for _, name := range featureOrder {
+		st, ok := stats[name]
+		if !ok {
+			continue
+		}
+		st.Rate = float64(st.Responses) / float64(rep.Responses)
+		st.AverageCount = float64(st.count) / float64(st.Responses)
+		if st.positioned > 0 {
+			st.AveragePosition = float64(st.positions) / float64(st.positioned)
+		}
+		rep.Features = append(rep.Features, *st)
+	}
+	return rep
+}
+
+// WriteCSV writes a keyword-by-feature matrix: one row per response, with
+// the item count of each feature, or 0 if it did not appear.
+func (r *FeatureReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"keyword"}, featureOrder...)); err != nil {
+		return err
+	}
+	for _, kf := range r.Keywords {
+		counts := make(map[string]int, len(kf.Features))
+		for _, f := range kf.Features {
+			counts[f.Name] = f.Count
+		}
+		row := []string{kf.Keyword}
+		for _, name := range featureOrder {
+			row = append(row, strconv.Itoa(counts[name]))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Text renders the per-feature statistics as a plain-text table, most
+// frequent feature first.
+func (r *FeatureReport) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d responses\n", r.Responses)
+	rows := append([]FeatureStat(nil), r.Features...)
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Responses > rows[j].Responses })
+	for _, st := range rows {
+		fmt.Fprintf(&b, "  %-18s %6.1f%%  avg %.1f items", st.Name, 100*st.Rate, st.AverageCount)
+		if st.AveragePosition > 0 {
+			fmt.Fprintf(&b, " at #%.1f", st.AveragePosition)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,135 @@
+package serper
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSearchResponse_Features(t *testing.T) {
+	tests := []struct {
+		name string
+		resp SearchResponse
+		want []SERPFeature
+	}{
+		{"empty", SearchResponse{Organic: []OrganicResult{{Link: "https://a.com"}}}, nil},
+		{
+			name: "all features in order",
+			resp: SearchResponse{
+				RelatedSearches: []RelatedSearch{{Query: "x"}, {Query: "y"}},
+				Videos:          []VideoResult{{Title: "v"}},
+				Images:          []ImageResult{{Title: "i"}, {Title: "j"}, {Title: "k"}},
+				TopStories:      []TopStory{{Title: "s"}},
+				PeopleAlsoAsk:   []PeopleAlsoAsk{{Question: "q"}, {Question: "r"}},
+				KnowledgeGraph:  &KnowledgeGraph{Title: "kg"},
+				AnswerBox:       &AnswerBox{Answer: "42"},
+			},
+			want: []SERPFeature{
+				{Name: FeatureAnswerBox, Placement: PlacementTop, Count: 1},
+				{Name: FeatureKnowledgeGraph, Placement: PlacementSide, Count: 1},
+				{Name: FeaturePeopleAlsoAsk, Placement: PlacementInline, Count: 2},
+				{Name: FeatureTopStories, Placement: PlacementInline, Count: 1},
+				{Name: FeatureImages, Placement: PlacementInline, Count: 3},
+				{Name: FeatureVideos, Placement: PlacementInline, Count: 1},
+				{Name: FeatureRelatedSearches, Placement: PlacementBottom, Count: 2},
+			},
+		},
+		{
+			name: "sitelinks at first carrying result",
+			resp: SearchResponse{Organic: []OrganicResult{
+				{Link: "https://a.com", Position: 1},
+				{Link: "https://b.com", Position: 2, Sitelinks: []Sitelink{{Title: "1"}, {Title: "2"}}},
+				{Link: "https://c.com", Position: 3, Sitelinks: []Sitelink{{Title: "3"}}},
+			}},
+			want: []SERPFeature{{Name: FeatureSitelinks, Placement: PlacementInline, Position: 2, Count: 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.resp.Features(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Features() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeFeatures(t *testing.T) {
+	resps := []*SearchResponse{
+		{
+			SearchParameters: SearchParameters{Q: "what is go"},
+			AnswerBox:        &AnswerBox{Answer: "a language"},
+			PeopleAlsoAsk:    []PeopleAlsoAsk{{Question: "q1"}, {Question: "q2"}, {Question: "q3"}, {Question: "q4"}},
+		},
+		nil,
+		{
+			SearchParameters: SearchParameters{Q: "golang"},
+			PeopleAlsoAsk:    []PeopleAlsoAsk{{Question: "q1"}, {Question: "q2"}},
+			Organic: []OrganicResult{
+				{Link: "https://go.dev", Position: 1, Sitelinks: []Sitelink{{Title: "Docs"}}},
+			},
+		},
+		{
+			SearchParameters: SearchParameters{Q: "gopher"},
+			Organic: []OrganicResult{
+				{Link: "https://x.com", Position: 1},
+				{Link: "https://go.dev", Position: 2, Sitelinks: []Sitelink{{Title: "Blog"}, {Title: "Play"}}},
+			},
+		},
+	}
+	rep := SummarizeFeatures(resps)
+	if rep.Responses != 3 {
+		t.Errorf("Responses = %d, want 3", rep.Responses)
+	}
+	var names []string
+	for _, f := range rep.Features {
+		names = append(names, f.Name)
+	}
+	if want := []string{FeatureAnswerBox, FeaturePeopleAlsoAsk, FeatureSitelinks}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("features = %v, want %v", names, want)
+	}
+
+	paa := rep.Features[1]
+	if paa.Responses != 2 || paa.AverageCount != 3 || paa.AveragePosition != 0 {
+		t.Errorf("paa: got %+v", paa)
+	}
+	if want := []string{"what is go", "golang"}; !reflect.DeepEqual(paa.Keywords, want) {
+		t.Errorf("paa keywords = %v, want %v", paa.Keywords, want)
+	}
+	sl := rep.Features[2]
+	if sl.AveragePosition != 1.5 || sl.AverageCount != 1.5 {
+		t.Errorf("sitelinks: got %+v", sl)
+	}
+	if ab := rep.Features[0]; ab.Rate < 0.333 || ab.Rate > 0.334 {
+		t.Errorf("answer box rate = %v, want 1/3", ab.Rate)
+	}
+	if len(rep.Keywords) != 3 || rep.Keywords[2].Keyword != "gopher" || len(rep.Keywords[2].Features) != 1 {
+		t.Errorf("keywords: got %+v", rep.Keywords)
+	}
+
+	text := rep.Text()
+	if !strings.HasPrefix(text, "3 responses\n") || !strings.Contains(text, "sitelinks") || !strings.Contains(text, "at #1.5") {
+		t.Errorf("Text() =\n%s", text)
+	}
+	// People Also Ask shows on more pages, so it is listed first.
+	if strings.Index(text, FeaturePeopleAlsoAsk) > strings.Index(text, FeatureAnswerBox) {
+		t.Errorf("Text() not ordered by frequency:\n%s", text)
+	}
+}
+
+func TestFeatureReport_WriteCSV(t *testing.T) {
+	rep := SummarizeFeatures([]*SearchResponse{
+		{SearchParameters: SearchParameters{Q: "a"}, AnswerBox: &AnswerBox{}},
+		{SearchParameters: SearchParameters{Q: "b"}, RelatedSearches: []RelatedSearch{{Query: "x"}, {Query: "y"}}},
+	})
+	var buf bytes.Buffer
+	if err := rep.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "keyword,answer_box,knowledge_graph,people_also_ask,top_stories,images,videos,sitelinks,related_searches\n" +
+		"a,1,0,0,0,0,0,0,0\n" +
+		"b,0,0,0,0,0,0,0,2\n"
+	if buf.String() != want {
+		t.Errorf("WriteCSV =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
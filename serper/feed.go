@@ -0,0 +1,215 @@
+package serper
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// FeedOptions describes the channel of a generated news feed.
+type FeedOptions struct {
+	Title       string    // feed title; defaults to "News: <query>"
+	Link        string    // human-facing page for the feed
+	SelfLink    string    // URL the feed itself is served from (Atom rel="self")
+	Description string    // RSS channel description; defaults to Title
+	Now         time.Time // reference time for relative dates; defaults to time.Now()
+}
+
+func (o FeedOptions) withDefaults(q string) FeedOptions {
+	if o.Title == "" {
+		o.Title = "News: " + q
+	}
+	if o.Description == "" {
+		o.Description = o.Title
+	}
+	if o.Link == "" {
+		o.Link = "https://www.google.com/search?tbm=nws&q=" + url.QueryEscape(q)
+	}
+	if o.Now.IsZero() {
+		o.Now = time.Now()
+	}
+	return o
+}
+
+// PublishedAt parses the result's Date relative to now. ok is false if
+// the date is missing or not recognised.
+func (r NewsResult) PublishedAt(now time.Time) (time.Time, bool) {
+	return ParseResultDate(r.Date, now)
+}
+
+// GUID returns a stable identifier for the article derived from its link,
+// so a feed reader sees the same item across repeated searches.
+func (r NewsResult) GUID() string {
+	sum := sha1.Sum([]byte(normalizeLink(r.Link)))
+	return "urn:sha1:" + hex.EncodeToString(sum[:])
+}
+
+// normalizeLink drops the fragment and trailing slash and lower-cases the
+// host, so trivially different links to the same page compare equal.
+func normalizeLink(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return link
+	}
+	u.Fragment = ""
+	u.Host = strings.ToLower(u.Host)
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return u.String()
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Self          *atomLink `xml:"atom:link,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string     `xml:"title"`
+	Link        string     `xml:"link"`
+	Description string     `xml:"description,omitempty"`
+	GUID        rssGUID    `xml:"guid"`
+	PubDate     string     `xml:"pubDate,omitempty"`
+	Source      *rssSource `xml:"source,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssSource struct {
+	URL  string `xml:"url,attr"`
+	Name string `xml:",chardata"`
+}
+
+// RSS renders the news results as an RSS 2.0 feed.
+func (r *NewsResponse) RSS(opts FeedOptions) ([]byte, error) {
+	opts = opts.withDefaults(r.SearchParameters.Q)
+	doc := rssDocument{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         opts.Title,
+			Link:          opts.Link,
+			Description:   opts.Description,
+			LastBuildDate: opts.Now.UTC().Format(time.RFC1123Z),
+		},
+	}
+	if opts.SelfLink != "" {
+		doc.Channel.Self = &atomLink{Href: opts.SelfLink, Rel: "self", Type: "application/rss+xml"}
+	}
+	for _, n := range r.News {
+		item := rssItem{
+			Title:       n.Title,
+			Link:        n.Link,
+			Description: n.Snippet,
+			GUID:        rssGUID{Value: n.GUID()},
+		}
+		if t, ok := n.PublishedAt(opts.Now); ok {
+			item.PubDate = t.UTC().Format(time.RFC1123Z)
+		}
+		if n.Source != "" {
+			item.Source = &rssSource{URL: linkOrigin(n.Link), Name: n.Source}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+	return marshalFeed(doc)
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Link      atomLink    `xml:"link"`
+	Published string      `xml:"published,omitempty"`
+	Updated   string      `xml:"updated"`
+	Summary   string      `xml:"summary,omitempty"`
+	Author    *atomAuthor `xml:"author,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// Atom renders the news results as an Atom 1.0 feed. Entries without a
+// recognisable date use the feed's generation time as their updated time.
+func (r *NewsResponse) Atom(opts FeedOptions) ([]byte, error) {
+	opts = opts.withDefaults(r.SearchParameters.Q)
+	now := opts.Now.UTC().Format(time.RFC3339)
+	feedID := opts.SelfLink
+	if feedID == "" {
+		feedID = opts.Link
+	}
+	feed := atomFeed{
+		ID:      feedID,
+		Title:   opts.Title,
+		Updated: now,
+		Links:   []atomLink{{Href: opts.Link, Rel: "alternate"}},
+	}
+	if opts.SelfLink != "" {
+		feed.Links = append(feed.Links, atomLink{Href: opts.SelfLink, Rel: "self", Type: "application/atom+xml"})
+	}
+	for _, n := range r.News {
+		entry := atomEntry{
+			ID:      n.GUID(),
+			Title:   n.Title,
+			Link:    atomLink{Href: n.Link, Rel: "alternate"},
+			Updated: now,
+			Summary: n.Snippet,
+		}
+		if t, ok := n.PublishedAt(opts.Now); ok {
+			entry.Published = t.UTC().Format(time.RFC3339)
+			entry.Updated = entry.Published
+		}
+		if n.Source != "" {
+			entry.Author = &atomAuthor{Name: n.Source}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return marshalFeed(feed)
+}
+
+func marshalFeed(v any) ([]byte, error) {
+	out, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("serper: marshal feed: %w", err)
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// linkOrigin returns the scheme and host of link, e.g. "https://example.com".
+func linkOrigin(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return link
+	}
+	return u.Scheme + "://" + u.Host
+}
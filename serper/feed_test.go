@@ -0,0 +1,109 @@
+package serper
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testNewsResponse() *NewsResponse {
+	return &NewsResponse{
+		SearchParameters: SearchParameters{Q: "golang"},
+		News: []NewsResult{
+			{Title: "Go 2 & beyond", Link: "https://news.example.com/go2", Snippet: "Big <news>.", Source: "Example News", Date: "2 hours ago"},
+			{Title: "Undated", Link: "https://blog.example.org/post/", Source: "Blog", Date: "sometime"},
+		},
+	}
+}
+
+var feedNow = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func TestNewsResult_GUID_Stable(t *testing.T) {
+	a := NewsResult{Link: "https://Example.com/story/#comments"}
+	b := NewsResult{Link: "https://example.com/story"}
+	if a.GUID() != b.GUID() {
+		t.Errorf("GUIDs for equivalent links differ: %s vs %s", a.GUID(), b.GUID())
+	}
+	if !strings.HasPrefix(a.GUID(), "urn:sha1:") {
+		t.Errorf("GUID: got %q", a.GUID())
+	}
+	if c := (NewsResult{Link: "https://example.com/other"}); c.GUID() == a.GUID() {
+		t.Error("different links should have different GUIDs")
+	}
+}
+
+func TestNewsResponse_RSS(t *testing.T) {
+	data, err := testNewsResponse().RSS(FeedOptions{Now: feedNow, SelfLink: "https://feeds.test/news?q=golang"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var doc struct {
+		Channel struct {
+			Title string `xml:"title"`
+			Items []struct {
+				Title   string `xml:"title"`
+				GUID    string `xml:"guid"`
+				PubDate string `xml:"pubDate"`
+				Source  struct {
+					URL  string `xml:"url,attr"`
+					Name string `xml:",chardata"`
+				} `xml:"source"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("RSS is not valid XML: %v\n%s", err, data)
+	}
+	if doc.Channel.Title != "News: golang" {
+		t.Errorf("title: got %q", doc.Channel.Title)
+	}
+	if len(doc.Channel.Items) != 2 {
+		t.Fatalf("items: got %d, want 2", len(doc.Channel.Items))
+	}
+	first := doc.Channel.Items[0]
+	if first.Title != "Go 2 & beyond" || first.GUID != testNewsResponse().News[0].GUID() {
+		t.Errorf("first item: got %+v", first)
+	}
+	if first.PubDate != "Tue, 10 Mar 2026 10:00:00 +0000" {
+		t.Errorf("pubDate: got %q", first.PubDate)
+	}
+	if first.Source.Name != "Example News" || first.Source.URL != "https://news.example.com" {
+		t.Errorf("source: got %+v", first.Source)
+	}
+	if doc.Channel.Items[1].PubDate != "" {
+		t.Errorf("unparseable date should omit pubDate, got %q", doc.Channel.Items[1].PubDate)
+	}
+	if !strings.Contains(string(data), `<atom:link href="https://feeds.test/news?q=golang" rel="self"`) {
+		t.Errorf("RSS should carry an atom self link:\n%s", data)
+	}
+}
+
+func TestNewsResponse_Atom(t *testing.T) {
+	data, err := testNewsResponse().Atom(FeedOptions{Now: feedNow})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var feed struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		Updated string   `xml:"updated"`
+		Entries []struct {
+			ID        string `xml:"id"`
+			Published string `xml:"published"`
+			Updated   string `xml:"updated"`
+			Author    string `xml:"author>name"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		t.Fatalf("Atom is not valid XML: %v\n%s", err, data)
+	}
+	if feed.Updated != "2026-03-10T12:00:00Z" || len(feed.Entries) != 2 {
+		t.Fatalf("feed: got updated=%q entries=%d", feed.Updated, len(feed.Entries))
+	}
+	if e := feed.Entries[0]; e.Published != "2026-03-10T10:00:00Z" || e.Author != "Example News" {
+		t.Errorf("first entry: got %+v", e)
+	}
+	if e := feed.Entries[1]; e.Published != "" || e.Updated != feed.Updated {
+		t.Errorf("undated entry should use feed time, got %+v", e)
+	}
+}
@@ -0,0 +1,187 @@
+package serper
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DomainRule matches result links for a DomainFilter. It is written as a
+// domain ("example.com", with or without "www."), a domain and everything
+// below it ("*.example.com"), a domain and path prefix
+// ("example.com/blog"), or a regular expression between slashes
+// ("/spam[0-9]*\./"), which is matched against the whole link.
+type DomainRule struct {
+	target RankTarget
+	re     *regexp.Regexp
+}
+
+// ParseDomainRule parses a rule in one of the forms described on
+// DomainRule.
+func ParseDomainRule(s string) (DomainRule, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/") {
+		re, err := regexp.Compile(s[1 : len(s)-1])
+		if err != nil {
+			return DomainRule{}, fmt.Errorf("serper: invalid domain rule %q: %w", s, err)
+		}
+		return DomainRule{re: re}, nil
+	}
+	t, err := ParseRankTarget(s)
+	if err != nil {
+		return DomainRule{}, fmt.Errorf("serper: invalid domain rule %q", s)
+	}
+	return DomainRule{target: t}, nil
+}
+
+// ParseDomainRules parses one rule per entry, skipping blank entries and
+// those starting with "#".
+func ParseDomainRules(lines []string) ([]DomainRule, error) {
+	var out []DomainRule
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		r, err := ParseDomainRule(line)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// LoadDomainRules reads rules from a file with one rule per line, as
+// accepted by ParseDomainRules.
+func LoadDomainRules(path string) ([]DomainRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("serper: domain rules: %w", err)
+	}
+	defer f.Close()
+	var lines []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("serper: domain rules: %s: %w", path, err)
+	}
+	rules, err := ParseDomainRules(lines)
+	if err != nil {
+		return nil, fmt.Errorf("%w (in %s)", err, path)
+	}
+	return rules, nil
+}
+
+// String returns the rule in the form accepted by ParseDomainRule.
+func (r DomainRule) String() string {
+	if r.re != nil {
+		return "/" + r.re.String() + "/"
+	}
+	return r.target.String()
+}
+
+// Matches reports whether link is covered by the rule. Links without a
+// scheme, such as some place websites, are read as http URLs.
+func (r DomainRule) Matches(link string) bool {
+	link = strings.TrimSpace(link)
+	if link == "" {
+		return false
+	}
+	if r.re != nil {
+		return r.re.MatchString(link)
+	}
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+	return r.target.Matches(link)
+}
+
+// DomainFilter drops results by the domain of their link. A result is kept
+// when Allow is empty or one of its rules matches, and no Block rule
+// matches; Block wins over Allow. A result without a link, such as a place
+// with no website, is dropped only when Allow is set.
+type DomainFilter struct {
+	Allow []DomainRule
+	Block []DomainRule
+	// Renumber sets Position to 1, 2, 3, ... over the kept results, so
+	// positions have no gaps where results were dropped.
+	Renumber bool
+}
+
+// WithDomainFilter applies f to every response the client returns. The
+// cache and snapshot store still hold the unfiltered response, so changing
+// the filter never needs a fresh search.
+func WithDomainFilter(f *DomainFilter) Option {
+	return func(c *Client) { c.filter = f }
+}
+
+// Keep reports whether a result linking to link passes the filter.
+func (f *DomainFilter) Keep(link string) bool {
+	for _, r := range f.Block {
+		if r.Matches(link) {
+			return false
+		}
+	}
+	if len(f.Allow) == 0 {
+		return true
+	}
+	for _, r := range f.Allow {
+		if r.Matches(link) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply filters the result lists of resp in place. resp is a pointer to
+// any of the response types; web search filters its organic results, top
+// stories, image pack and video carousel. Other values are left alone.
+func (f *DomainFilter) Apply(resp any) {
+	switch r := resp.(type) {
+	case *SearchResponse:
+		r.Organic = filterResults(f, r.Organic, func(x *OrganicResult) (string, *int) { return x.Link, &x.Position })
+		r.TopStories = filterResults(f, r.TopStories, func(x *TopStory) (string, *int) { return x.Link, nil })
+		r.Images = filterResults(f, r.Images, func(x *ImageResult) (string, *int) { return x.Link, &x.Position })
+		r.Videos = filterResults(f, r.Videos, func(x *VideoResult) (string, *int) { return x.Link, &x.Position })
+	case *ImagesResponse:
+		r.Images = filterResults(f, r.Images, func(x *ImageResult) (string, *int) { return x.Link, &x.Position })
+	case *NewsResponse:
+		r.News = filterResults(f, r.News, func(x *NewsResult) (string, *int) { return x.Link, &x.Position })
+	case *PlacesResponse:
+		r.Places = filterResults(f, r.Places, func(x *PlaceResult) (string, *int) { return x.Website, &x.Position })
+	case *ScholarResponse:
+		r.Organic = filterResults(f, r.Organic, func(x *ScholarResult) (string, *int) { return x.Link, &x.Position })
+	case *ShoppingResponse:
+		r.Shopping = filterResults(f, r.Shopping, func(x *ShoppingResult) (string, *int) { return x.Link, &x.Position })
+	case *VideosResponse:
+		r.Videos = filterResults(f, r.Videos, func(x *VideoResult) (string, *int) { return x.Link, &x.Position })
+	}
+}
+
+// filterResults keeps the items whose link passes f, reusing the backing
+// array, and renumbers them if f.Renumber is set. field returns an item's
+// link and a pointer to its position, or nil if it has none.
+func filterResults[T any](f *DomainFilter, items []T, field func(*T) (string, *int)) []T {
+	if items == nil {
+		return nil
+	}
+	out := items[:0]
+	for i := range items {
+		if link, _ := field(&items[i]); f.Keep(link) {
+			out = append(out, items[i])
+		}
+	}
+	if f.Renumber {
+		for i := range out {
+			if _, pos := field(&out[i]); pos != nil {
+				*pos = i + 1
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,167 @@
+package serper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDomainRule_Matches(t *testing.T) {
+	tests := []struct {
+		rule, link string
+		want       bool
+	}{
+		{"example.com", "https://www.example.com/a", true},
+		{"example.com", "https://blog.example.com/a", false},
+		{"*.example.com", "https://blog.example.com/a", true},
+		{"*.example.com", "https://example.com/", true},
+		{"example.com/blog", "https://example.com/blog/post", true},
+		{"example.com/blog", "https://example.com/shop", false},
+		{"example.com", "example.com/menu", true},
+		{"/spam[0-9]*\\./", "https://spam42.net/x", true},
+		{"/spam[0-9]*\\./", "https://example.com/spam", false},
+		{"example.com", "", false},
+	}
+	for _, tt := range tests {
+		r, err := ParseDomainRule(tt.rule)
+		if err != nil {
+			t.Fatalf("ParseDomainRule(%q): %v", tt.rule, err)
+		}
+		if got := r.Matches(tt.link); got != tt.want {
+			t.Errorf("%s.Matches(%q) = %v, want %v", r, tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestParseDomainRule_Invalid(t *testing.T) {
+	for _, s := range []string{"", "/[/", "*.", "exa*mple.com"} {
+		if _, err := ParseDomainRule(s); err == nil {
+			t.Errorf("ParseDomainRule(%q): expected error", s)
+		}
+	}
+}
+
+func TestLoadDomainRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "block.txt")
+	if err := os.WriteFile(path, []byte("# content farms\nfarm.com\n\n*.spam.net\n/ads?\\./\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := LoadDomainRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range rules {
+		got = append(got, r.String())
+	}
+	if want := []string{"farm.com", "*.spam.net", "/ads?\\./"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rules = %v, want %v", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte("ok.com\n/(/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDomainRules(path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("LoadDomainRules: got %v, want an error naming the file", err)
+	}
+}
+
+func mustRules(t *testing.T, s ...string) []DomainRule {
+	t.Helper()
+	rules, err := ParseDomainRules(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rules
+}
+
+func TestDomainFilter_Apply(t *testing.T) {
+	organic := func() []OrganicResult {
+		return []OrganicResult{
+			{Link: "https://a.com/1", Position: 1},
+			{Link: "https://farm.com/2", Position: 2},
+			{Link: "https://docs.b.org/3", Position: 3},
+			{Link: "https://c.net/4", Position: 4},
+		}
+	}
+	tests := []struct {
+		name      string
+		filter    DomainFilter
+		wantLinks []string
+		wantPos   []int
+	}{
+		{"block", DomainFilter{Block: mustRules(t, "farm.com")}, []string{"https://a.com/1", "https://docs.b.org/3", "https://c.net/4"}, []int{1, 3, 4}},
+		{"block renumbered", DomainFilter{Block: mustRules(t, "farm.com"), Renumber: true}, []string{"https://a.com/1", "https://docs.b.org/3", "https://c.net/4"}, []int{1, 2, 3}},
+		{"allow", DomainFilter{Allow: mustRules(t, "*.b.org", "c.net")}, []string{"https://docs.b.org/3", "https://c.net/4"}, []int{3, 4}},
+		{"block beats allow", DomainFilter{Allow: mustRules(t, "*.b.org", "c.net"), Block: mustRules(t, "/docs/"), Renumber: true}, []string{"https://c.net/4"}, []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &SearchResponse{Organic: organic()}
+			tt.filter.Apply(resp)
+			var links []string
+			var pos []int
+			for _, r := range resp.Organic {
+				links = append(links, r.Link)
+				pos = append(pos, r.Position)
+			}
+			if !reflect.DeepEqual(links, tt.wantLinks) || !reflect.DeepEqual(pos, tt.wantPos) {
+				t.Errorf("got %v %v, want %v %v", links, pos, tt.wantLinks, tt.wantPos)
+			}
+		})
+	}
+}
+
+func TestDomainFilter_ApplyVerticals(t *testing.T) {
+	f := &DomainFilter{Allow: mustRules(t, "good.com"), Renumber: true}
+
+	places := &PlacesResponse{Places: []PlaceResult{
+		{Title: "no site", Position: 1},
+		{Title: "good", Website: "good.com", Position: 2},
+	}}
+	f.Apply(places)
+	if len(places.Places) != 1 || places.Places[0].Title != "good" || places.Places[0].Position != 1 {
+		t.Errorf("places: got %+v", places.Places)
+	}
+
+	news := &NewsResponse{News: []NewsResult{{Link: "https://bad.com"}, {Link: "https://good.com/x"}}}
+	f.Apply(news)
+	if len(news.News) != 1 || news.News[0].Position != 1 {
+		t.Errorf("news: got %+v", news.News)
+	}
+
+	web := &SearchResponse{TopStories: []TopStory{{Link: "https://bad.com"}, {Link: "https://good.com/s"}}}
+	f.Apply(web)
+	if len(web.TopStories) != 1 || web.Organic != nil {
+		t.Errorf("web: got %+v", web)
+	}
+}
+
+func TestWithDomainFilter(t *testing.T) {
+	doer := &mockDoer{statusCode: 200, respBody: `{"organic":[{"link":"https://farm.com","position":1},{"link":"https://a.com","position":2}]}`}
+	cache := NewMemoryCache(time.Minute, 10)
+	f := &DomainFilter{Block: mustRules(t, "farm.com"), Renumber: true}
+	c := mustNew(t, "key", WithDoer(doer), WithCache(cache), WithDomainFilter(f))
+
+	resp, err := c.Search(context.Background(), &SearchRequest{Q: "q"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Organic) != 1 || resp.Organic[0].Link != "https://a.com" || resp.Organic[0].Position != 1 {
+		t.Errorf("organic: got %+v", resp.Organic)
+	}
+
+	// The cache keeps the unfiltered response.
+	unfiltered := mustNew(t, "key", WithDoer(&mockDoer{err: context.Canceled}), WithCache(cache))
+	resp, err = unfiltered.Search(context.Background(), &SearchRequest{Q: "q"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Organic) != 2 {
+		t.Errorf("cached organic: got %+v", resp.Organic)
+	}
+}
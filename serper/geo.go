@@ -0,0 +1,95 @@
+package serper
+
+import (
+	"math"
+	"sort"
+)
+
+// earthRadiusMeters is the mean Earth radius used for great-circle distances.
+const earthRadiusMeters = 6371008.8
+
+// LatLng is a WGS84 coordinate in decimal degrees.
+type LatLng struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// BoundingBox is a rectangle between two corners. If SouthWest.Lng is
+// greater than NorthEast.Lng the box crosses the antimeridian.
+type BoundingBox struct {
+	SouthWest LatLng `json:"southWest"`
+	NorthEast LatLng `json:"northEast"`
+}
+
+// Contains reports whether p lies inside the box, edges included.
+func (b BoundingBox) Contains(p LatLng) bool {
+	if p.Lat < b.SouthWest.Lat || p.Lat > b.NorthEast.Lat {
+		return false
+	}
+	if b.SouthWest.Lng <= b.NorthEast.Lng {
+		return p.Lng >= b.SouthWest.Lng && p.Lng <= b.NorthEast.Lng
+	}
+	return p.Lng >= b.SouthWest.Lng || p.Lng <= b.NorthEast.Lng
+}
+
+// Distance returns the great-circle distance between a and b in meters,
+// using the haversine formula.
+func Distance(a, b LatLng) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Coordinates returns the place's position.
+func (p PlaceResult) Coordinates() LatLng {
+	return LatLng{Lat: p.Latitude, Lng: p.Longitude}
+}
+
+// HasCoordinates reports whether Serper returned a position for the place.
+// Serper omits the fields when unknown, which decodes as (0, 0).
+func (p PlaceResult) HasCoordinates() bool {
+	return p.Latitude != 0 || p.Longitude != 0
+}
+
+// SortedByDistance returns the places ordered by distance from the given
+// point, nearest first. Places without coordinates keep their relative
+// order at the end. The response is not modified.
+func (r *PlacesResponse) SortedByDistance(from LatLng) []PlaceResult {
+	out := make([]PlaceResult, len(r.Places))
+	copy(out, r.Places)
+	sort.SliceStable(out, func(i, j int) bool {
+		hi, hj := out[i].HasCoordinates(), out[j].HasCoordinates()
+		if hi != hj {
+			return hi
+		}
+		return hi && Distance(from, out[i].Coordinates()) < Distance(from, out[j].Coordinates())
+	})
+	return out
+}
+
+// WithinRadius returns the places at most meters from center. Places
+// without coordinates are dropped. The response is not modified.
+func (r *PlacesResponse) WithinRadius(center LatLng, meters float64) []PlaceResult {
+	var out []PlaceResult
+	for _, p := range r.Places {
+		if p.HasCoordinates() && Distance(center, p.Coordinates()) <= meters {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// WithinBounds returns the places inside the bounding box. Places without
+// coordinates are dropped. The response is not modified.
+func (r *PlacesResponse) WithinBounds(b BoundingBox) []PlaceResult {
+	var out []PlaceResult
+	for _, p := range r.Places {
+		if p.HasCoordinates() && b.Contains(p.Coordinates()) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+package serper
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func testPlaces() *PlacesResponse {
+	return &PlacesResponse{
+		SearchParameters: SearchParameters{Q: "coffee"},
+		Places: []PlaceResult{
+			{Title: "Far", Latitude: 40.7580, Longitude: -73.9855, Position: 1},  // Times Square
+			{Title: "Unknown", Position: 2},                                      // no coordinates
+			{Title: "Near", Latitude: 40.7061, Longitude: -74.0087, Position: 3}, // Wall St
+			{
+				Title: "Nearest", Latitude: 40.7033, Longitude: -74.0170, Position: 4, // Battery Park
+				Category: "Cafe", Phone: "+1 212-555-0100", Rating: 4.5, RatingCount: 10,
+			},
+		},
+	}
+}
+
+var batteryPark = LatLng{Lat: 40.7033, Lng: -74.0170}
+
+func TestDistance(t *testing.T) {
+	// London to Paris is roughly 343.5 km.
+	d := Distance(LatLng{51.5074, -0.1278}, LatLng{48.8566, 2.3522})
+	if math.Abs(d-343_500) > 1_000 {
+		t.Errorf("London-Paris: got %.0f m, want ~343500 m", d)
+	}
+	if d := Distance(batteryPark, batteryPark); d != 0 {
+		t.Errorf("same point: got %f, want 0", d)
+	}
+}
+
+func TestBoundingBox_Contains(t *testing.T) {
+	box := BoundingBox{SouthWest: LatLng{40, -75}, NorthEast: LatLng{41, -73}}
+	if !box.Contains(batteryPark) {
+		t.Error("box should contain Battery Park")
+	}
+	if box.Contains(LatLng{42, -74}) {
+		t.Error("box should not contain a point north of it")
+	}
+
+	antimeridian := BoundingBox{SouthWest: LatLng{-20, 170}, NorthEast: LatLng{-10, -170}}
+	if !antimeridian.Contains(LatLng{-15, 179}) || !antimeridian.Contains(LatLng{-15, -179}) {
+		t.Error("antimeridian box should contain points on both sides of 180")
+	}
+	if antimeridian.Contains(LatLng{-15, 0}) {
+		t.Error("antimeridian box should not contain longitude 0")
+	}
+}
+
+func TestPlacesResponse_SortedByDistance(t *testing.T) {
+	resp := testPlaces()
+	got := resp.SortedByDistance(batteryPark)
+	var titles []string
+	for _, p := range got {
+		titles = append(titles, p.Title)
+	}
+	if want := "Nearest,Near,Far,Unknown"; strings.Join(titles, ",") != want {
+		t.Errorf("order: got %s, want %s", strings.Join(titles, ","), want)
+	}
+	if resp.Places[0].Title != "Far" {
+		t.Error("SortedByDistance should not modify the response")
+	}
+}
+
+func TestPlacesResponse_WithinRadiusAndBounds(t *testing.T) {
+	resp := testPlaces()
+	if got := resp.WithinRadius(batteryPark, 2_000); len(got) != 2 {
+		t.Errorf("WithinRadius 2km: got %d places, want 2", len(got))
+	}
+	box := BoundingBox{SouthWest: LatLng{40.75, -74}, NorthEast: LatLng{40.77, -73.98}}
+	got := resp.WithinBounds(box)
+	if len(got) != 1 || got[0].Title != "Far" {
+		t.Errorf("WithinBounds: got %+v, want [Far]", got)
+	}
+}
+
+func TestPlacesResponse_GeoJSON(t *testing.T) {
+	data, err := testPlaces().GeoJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var fc FeatureCollection
+	if err := json.Unmarshal(data, &fc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if fc.Type != "FeatureCollection" {
+		t.Errorf("type: got %q", fc.Type)
+	}
+	if len(fc.Features) != 3 {
+		t.Fatalf("features: got %d, want 3 (place without coordinates skipped)", len(fc.Features))
+	}
+	f := fc.Features[2]
+	if f.Geometry.Type != "Point" || f.Geometry.Coordinates[0] != -74.0170 || f.Geometry.Coordinates[1] != 40.7033 {
+		t.Errorf("geometry should be [lng, lat], got %+v", f.Geometry)
+	}
+	if f.Properties["category"] != "Cafe" || f.Properties["rating"] != 4.5 {
+		t.Errorf("properties: got %v", f.Properties)
+	}
+}
+
+func TestPlacesResponse_KML(t *testing.T) {
+	data, err := testPlaces().KML()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	kml := string(data)
+	for _, want := range []string{
+		`<kml xmlns="http://www.opengis.net/kml/2.2">`,
+		"<name>coffee</name>",
+		"<coordinates>-74.017,40.7033</coordinates>",
+		`<Data name="category">`,
+	} {
+		if !strings.Contains(kml, want) {
+			t.Errorf("KML should contain %q:\n%s", want, kml)
+		}
+	}
+	if strings.Count(kml, "<Placemark>") != 3 {
+		t.Errorf("expected 3 placemarks:\n%s", kml)
+	}
+}
@@ -0,0 +1,143 @@
+package serper
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+)
+
+// FeatureCollection is a GeoJSON (RFC 7946) feature collection.
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a GeoJSON feature with a point geometry.
+type Feature struct {
+	Type       string         `json:"type"`
+	Geometry   Geometry       `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+// Geometry is a GeoJSON point. Coordinates are [longitude, latitude].
+type Geometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+// NewPointFeature returns a point feature at p with the given properties.
+func NewPointFeature(p LatLng, props map[string]any) Feature {
+	if props == nil {
+		props = map[string]any{}
+	}
+	return Feature{
+		Type:       "Feature",
+		Geometry:   Geometry{Type: "Point", Coordinates: []float64{p.Lng, p.Lat}},
+		Properties: props,
+	}
+}
+
+// FeatureCollection converts the places to GeoJSON features. Places without
+// coordinates are skipped.
+func (r *PlacesResponse) FeatureCollection() *FeatureCollection {
+	fc := &FeatureCollection{Type: "FeatureCollection", Features: []Feature{}}
+	for _, p := range r.Places {
+		if !p.HasCoordinates() {
+			continue
+		}
+		props := map[string]any{
+			"title":    p.Title,
+			"position": p.Position,
+		}
+		for k, v := range placeAttributes(p) {
+			props[k] = v
+		}
+		if p.Rating != 0 {
+			props["rating"] = p.Rating
+			props["ratingCount"] = p.RatingCount
+		}
+		fc.Features = append(fc.Features, NewPointFeature(p.Coordinates(), props))
+	}
+	return fc
+}
+
+// GeoJSON encodes the places as a GeoJSON FeatureCollection.
+func (r *PlacesResponse) GeoJSON() ([]byte, error) {
+	out, err := json.Marshal(r.FeatureCollection())
+	if err != nil {
+		return nil, fmt.Errorf("serper: marshal geojson: %w", err)
+	}
+	return out, nil
+}
+
+// kmlDocument mirrors the subset of KML 2.2 we emit.
+type kmlDocument struct {
+	XMLName  xml.Name `xml:"kml"`
+	XMLNS    string   `xml:"xmlns,attr"`
+	Document struct {
+		Name       string         `xml:"name,omitempty"`
+		Placemarks []kmlPlacemark `xml:"Placemark"`
+	} `xml:"Document"`
+}
+
+type kmlPlacemark struct {
+	Name        string    `xml:"name"`
+	Description string    `xml:"description,omitempty"`
+	Data        []kmlData `xml:"ExtendedData>Data,omitempty"`
+	Point       struct {
+		Coordinates string `xml:"coordinates"`
+	} `xml:"Point"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+// KML encodes the places as a KML 2.2 document, one Placemark per place.
+// Places without coordinates are skipped.
+func (r *PlacesResponse) KML() ([]byte, error) {
+	doc := kmlDocument{XMLNS: "http://www.opengis.net/kml/2.2"}
+	doc.Document.Name = r.SearchParameters.Q
+	for _, p := range r.Places {
+		if !p.HasCoordinates() {
+			continue
+		}
+		pm := kmlPlacemark{Name: p.Title, Description: p.Address}
+		attrs := placeAttributes(p)
+		for _, k := range []string{"category", "phoneNumber", "website"} {
+			if v, ok := attrs[k]; ok {
+				pm.Data = append(pm.Data, kmlData{Name: k, Value: v})
+			}
+		}
+		if p.Rating != 0 {
+			pm.Data = append(pm.Data, kmlData{Name: "rating", Value: strconv.FormatFloat(p.Rating, 'f', -1, 64)})
+		}
+		pm.Point.Coordinates = strconv.FormatFloat(p.Longitude, 'f', -1, 64) + "," +
+			strconv.FormatFloat(p.Latitude, 'f', -1, 64)
+		doc.Document.Placemarks = append(doc.Document.Placemarks, pm)
+	}
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("serper: marshal kml: %w", err)
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// placeAttributes returns the non-empty descriptive string fields of a place,
+// keyed by their JSON names.
+func placeAttributes(p PlaceResult) map[string]string {
+	attrs := map[string]string{}
+	for k, v := range map[string]string{
+		"address":     p.Address,
+		"category":    p.Category,
+		"phoneNumber": p.Phone,
+		"website":     p.Website,
+	} {
+		if v != "" {
+			attrs[k] = v
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,303 @@
+package serper
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	defaultGridSize        = 7
+	defaultGridSpacing     = 1000 // meters
+	defaultGridZoom        = 14
+	defaultGridConcurrency = 4
+	maxGridSize            = 21
+)
+
+// BusinessMatcher identifies a target business in places results. A place
+// matches if any non-empty field matches: Name by normalized equality,
+// Phone by its trailing digits, Website by host (ignoring "www.").
+type BusinessMatcher struct {
+	Name    string `json:"name,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+	Website string `json:"website,omitempty"`
+}
+
+// Matches reports whether p is the target business.
+func (m BusinessMatcher) Matches(p PlaceResult) bool {
+	if m.Name != "" && normalizeBusinessName(m.Name) == normalizeBusinessName(p.Title) {
+		return true
+	}
+	if m.Phone != "" && p.Phone != "" {
+		a, b := digitsOnly(m.Phone), digitsOnly(p.Phone)
+		if len(a) > 10 {
+			a = a[len(a)-10:]
+		}
+		if len(a) >= 7 && strings.HasSuffix(b, a) {
+			return true
+		}
+	}
+	if m.Website != "" && p.Website != "" && websiteHost(m.Website) == websiteHost(p.Website) {
+		return true
+	}
+	return false
+}
+
+// GridScanConfig configures a local rank scan across a square grid.
+type GridScanConfig struct {
+	Query       string          // search query, e.g. "coffee shop"
+	Center      LatLng          // center of the grid, usually the storefront
+	Size        int             // points per side (default 7, max 21)
+	Spacing     float64         // meters between adjacent points (default 1000)
+	Zoom        int             // map zoom sent with each search (default 14)
+	GL, HL      string          // country and language codes
+	Target      BusinessMatcher // business to locate in each result set
+	Concurrency int             // parallel searches (default 4)
+	Budget      *CreditBudget   // optional credit cap shared with other jobs
+	Sink        Sink            // optional; receives EventGridScanComplete
+}
+
+// GridPoint is one coordinate of a scan grid. Row 0 is the northern edge
+// and column 0 the western edge.
+type GridPoint struct {
+	Row    int    `json:"row"`
+	Col    int    `json:"col"`
+	LatLng LatLng `json:"latLng"`
+}
+
+// GridCell is the scan outcome at one grid point. Rank is the target's
+// 1-based position, or 0 if it was not found or the search failed.
+type GridCell struct {
+	GridPoint
+	Rank  int          `json:"rank"`
+	Match *PlaceResult `json:"match,omitempty"`
+	Err   error        `json:"-"`
+}
+
+// GridScanResult holds the cells of a completed scan, indexed [row][col].
+type GridScanResult struct {
+	Query        string          `json:"query"`
+	Target       BusinessMatcher `json:"target"`
+	Cells        [][]GridCell    `json:"cells"`
+	CreditsSpent int             `json:"creditsSpent"`
+}
+
+// GridPoints generates a size x size grid of points centered on center
+// with the given spacing in meters.
+func GridPoints(center LatLng, size int, spacing float64) []GridPoint {
+	points := make([]GridPoint, 0, size*size)
+	half := float64(size-1) / 2
+	metersPerDegLat := earthRadiusMeters * math.Pi / 180
+	metersPerDegLng := metersPerDegLat * math.Cos(center.Lat*math.Pi/180)
+	for row := 0; row < size; row++ {
+		for col := 0; col < size; col++ {
+			north := (half - float64(row)) * spacing
+			east := (float64(col) - half) * spacing
+			p := LatLng{Lat: center.Lat + north/metersPerDegLat, Lng: center.Lng}
+			if metersPerDegLng > 0 {
+				p.Lng += east / metersPerDegLng
+			}
+			points = append(points, GridPoint{Row: row, Col: col, LatLng: p})
+		}
+	}
+	return points
+}
+
+// GridScan runs a Places search at every point of the configured grid and
+// records where the target business ranks. Searches run with bounded
+// concurrency; once the credit budget is exhausted the remaining cells are
+// marked with ErrCreditLimit. Per-cell failures are recorded in the cell
+// rather than aborting the scan; only invalid configuration or a cancelled
+// context returns an error. If cfg.Sink is set the finished result is sent
+// to it; a failed notification is returned together with the result.
+func (c *Client) GridScan(ctx context.Context, cfg GridScanConfig) (*GridScanResult, error) {
+	if strings.TrimSpace(cfg.Query) == "" {
+		return nil, fmt.Errorf("serper: grid scan: query must not be empty")
+	}
+	if cfg.Target == (BusinessMatcher{}) {
+		return nil, fmt.Errorf("serper: grid scan: target must set name, phone or website")
+	}
+	if cfg.Size <= 0 {
+		cfg.Size = defaultGridSize
+	}
+	if cfg.Size > maxGridSize {
+		return nil, fmt.Errorf("serper: grid scan: size %d exceeds maximum %d", cfg.Size, maxGridSize)
+	}
+	if cfg.Spacing <= 0 {
+		cfg.Spacing = defaultGridSpacing
+	}
+	if cfg.Zoom <= 0 {
+		cfg.Zoom = defaultGridZoom
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaultGridConcurrency
+	}
+	budget := cfg.Budget
+	if budget == nil {
+		budget = NewCreditBudget(0)
+	}
+	spentBefore := budget.Spent()
+
+	res := &GridScanResult{Query: cfg.Query, Target: cfg.Target, Cells: make([][]GridCell, cfg.Size)}
+	for i := range res.Cells {
+		res.Cells[i] = make([]GridCell, cfg.Size)
+	}
+
+	sem := make(chan struct{}, cfg.Concurrency)
+	var wg sync.WaitGroup
+	for _, pt := range GridPoints(cfg.Center, cfg.Size, cfg.Spacing) {
+		cell := &res.Cells[pt.Row][pt.Col]
+		cell.GridPoint = pt
+		req := &SearchRequest{
+			Q:  cfg.Query,
+			GL: cfg.GL,
+			HL: cfg.HL,
+			LL: fmt.Sprintf("@%.7f,%.7f,%dz", pt.LatLng.Lat, pt.LatLng.Lng, cfg.Zoom),
+		}
+		if err := c.reserveUncached(ctx, budget, "/places", req); err != nil {
+			cell.Err = err
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			resp, err := c.Places(ctx, req)
+			if err != nil {
+				cell.Err = err
+				return
+			}
+			for i, p := range resp.Places {
+				if cfg.Target.Matches(p) {
+					match := p
+					cell.Match = &match
+					cell.Rank = p.Position
+					if cell.Rank <= 0 {
+						cell.Rank = i + 1
+					}
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	res.CreditsSpent = budget.Spent() - spentBefore
+	if err := Notify(ctx, cfg.Sink, EventGridScanComplete, res); err != nil {
+		return res, fmt.Errorf("serper: grid scan: notify: %w", err)
+	}
+	return res, nil
+}
+
+// Ranks returns the rank matrix indexed [row][col]; 0 means not found.
+func (r *GridScanResult) Ranks() [][]int {
+	out := make([][]int, len(r.Cells))
+	for i, row := range r.Cells {
+		out[i] = make([]int, len(row))
+		for j, cell := range row {
+			out[i][j] = cell.Rank
+		}
+	}
+	return out
+}
+
+// FeatureCollection converts the scan to GeoJSON points suitable for a
+// heatmap layer. Each feature carries its rank and a weight of 1/rank
+// (0 when the target was not found).
+func (r *GridScanResult) FeatureCollection() *FeatureCollection {
+	fc := &FeatureCollection{Type: "FeatureCollection", Features: []Feature{}}
+	for _, row := range r.Cells {
+		for _, cell := range row {
+			weight := 0.0
+			if cell.Rank > 0 {
+				weight = 1 / float64(cell.Rank)
+			}
+			props := map[string]any{
+				"row":    cell.Row,
+				"col":    cell.Col,
+				"rank":   cell.Rank,
+				"found":  cell.Rank > 0,
+				"weight": weight,
+			}
+			if cell.Err != nil {
+				props["error"] = cell.Err.Error()
+			}
+			fc.Features = append(fc.Features, NewPointFeature(cell.LatLng, props))
+		}
+	}
+	return fc
+}
+
+// GeoJSON encodes the scan as a GeoJSON FeatureCollection.
+func (r *GridScanResult) GeoJSON() ([]byte, error) {
+	out, err := json.Marshal(r.FeatureCollection())
+	if err != nil {
+		return nil, fmt.Errorf("serper: marshal geojson: %w", err)
+	}
+	return out, nil
+}
+
+// WriteCSV writes one row per grid cell: row, col, lat, lng, rank, title, error.
+func (r *GridScanResult) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"row", "col", "lat", "lng", "rank", "title", "error"}); err != nil {
+		return err
+	}
+	for _, row := range r.Cells {
+		for _, cell := range row {
+			title, errMsg := "", ""
+			if cell.Match != nil {
+				title = cell.Match.Title
+			}
+			if cell.Err != nil {
+				errMsg = cell.Err.Error()
+			}
+			if err := cw.Write([]string{
+				strconv.Itoa(cell.Row),
+				strconv.Itoa(cell.Col),
+				strconv.FormatFloat(cell.LatLng.Lat, 'f', 7, 64),
+				strconv.FormatFloat(cell.LatLng.Lng, 'f', 7, 64),
+				strconv.Itoa(cell.Rank),
+				title,
+				errMsg,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func normalizeBusinessName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+func digitsOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
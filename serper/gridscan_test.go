@@ -0,0 +1,249 @@
+package serper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// doerFunc adapts a function to the Doer interface.
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+// jsonResponse builds a 200 response with the given JSON body.
+func jsonResponse(body string) *http.Response {
+	return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+// fakeDoer answers each request with the JSON of responses[q], or {} for
+// a query it does not know. A response that is an error fails the request
+// with it. Every request is counted in calls.
+func fakeDoer(responses map[string]any, calls *atomic.Int32) Doer {
+	return doerFunc(func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		var sr SearchRequest
+		body, _ := io.ReadAll(req.Body)
+		if err := json.Unmarshal(body, &sr); err != nil {
+			return nil, err
+		}
+		resp, ok := responses[sr.Q]
+		if !ok {
+			return jsonResponse("{}"), nil
+		}
+		if err, ok := resp.(error); ok {
+			return nil, err
+		}
+		out, _ := json.Marshal(resp)
+		return jsonResponse(string(out)), nil
+	})
+}
+
+func TestGridPoints(t *testing.T) {
+	center := LatLng{Lat: 40.0, Lng: -74.0}
+	points := GridPoints(center, 3, 1000)
+	if len(points) != 9 {
+		t.Fatalf("points: got %d, want 9", len(points))
+	}
+	mid := points[4]
+	if mid.Row != 1 || mid.Col != 1 || mid.LatLng != center {
+		t.Errorf("middle point should be the center, got %+v", mid)
+	}
+	if points[0].LatLng.Lat <= center.Lat || points[0].LatLng.Lng >= center.Lng {
+		t.Errorf("row 0, col 0 should be north-west of center, got %+v", points[0].LatLng)
+	}
+	if d := Distance(points[3].LatLng, points[4].LatLng); math.Abs(d-1000) > 1 {
+		t.Errorf("east-west spacing: got %.2f m, want 1000 m", d)
+	}
+	if d := Distance(points[1].LatLng, points[4].LatLng); math.Abs(d-1000) > 1 {
+		t.Errorf("north-south spacing: got %.2f m, want 1000 m", d)
+	}
+}
+
+func TestBusinessMatcher_Matches(t *testing.T) {
+	place := PlaceResult{Title: "Joe's Coffee & Tea", Phone: "(212) 555-0100", Website: "https://www.joescoffee.com/nyc"}
+	tests := []struct {
+		name string
+		m    BusinessMatcher
+		want bool
+	}{
+		{"name normalized", BusinessMatcher{Name: "joes coffee tea"}, true},
+		{"name mismatch", BusinessMatcher{Name: "Joe's Bagels"}, false},
+		{"phone with country code", BusinessMatcher{Phone: "+1 212 555 0100"}, true},
+		{"website host", BusinessMatcher{Website: "joescoffee.com"}, true},
+		{"website other host", BusinessMatcher{Website: "example.com"}, false},
+		{"any field matches", BusinessMatcher{Name: "Other", Website: "http://joescoffee.com"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.Matches(place); got != tt.want {
+				t.Errorf("Matches: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// gridDoer returns places where the target ranks first north of the center
+// and is absent elsewhere.
+func gridDoer(calls *atomic.Int32) Doer {
+	return doerFunc(func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		var sent SearchRequest
+		body, _ := io.ReadAll(req.Body)
+		_ = json.Unmarshal(body, &sent)
+		var lat, lng float64
+		var zoom int
+		if _, err := fmt.Sscanf(sent.LL, "@%f,%f,%dz", &lat, &lng, &zoom); err != nil {
+			return nil, err
+		}
+		if lat > 40.0 {
+			return jsonResponse(`{"places":[{"title":"Target Cafe","position":1},{"title":"Other","position":2}]}`), nil
+		}
+		return jsonResponse(`{"places":[{"title":"Other","position":1}]}`), nil
+	})
+}
+
+func TestGridScan(t *testing.T) {
+	var calls atomic.Int32
+	c := mustNew(t, "key", WithDoer(gridDoer(&calls)))
+
+	res, err := c.GridScan(context.Background(), GridScanConfig{
+		Query:  "cafe",
+		Center: LatLng{Lat: 40.0, Lng: -74.0},
+		Size:   3,
+		Target: BusinessMatcher{Name: "Target Cafe"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 9 {
+		t.Errorf("searches: got %d, want 9", calls.Load())
+	}
+	if res.CreditsSpent != 9 {
+		t.Errorf("CreditsSpent: got %d, want 9", res.CreditsSpent)
+	}
+	want := [][]int{{1, 1, 1}, {0, 0, 0}, {0, 0, 0}}
+	got := res.Ranks()
+	for i := range want {
+		for j := range want[i] {
+			if got[i][j] != want[i][j] {
+				t.Fatalf("ranks: got %v, want %v", got, want)
+			}
+		}
+	}
+	if res.Cells[0][0].Match == nil || res.Cells[0][0].Match.Title != "Target Cafe" {
+		t.Errorf("expected match recorded in north-west cell, got %+v", res.Cells[0][0].Match)
+	}
+}
+
+func TestGridScan_CreditCap(t *testing.T) {
+	var calls atomic.Int32
+	c := mustNew(t, "key", WithDoer(gridDoer(&calls)))
+	budget := NewCreditBudget(4)
+
+	res, err := c.GridScan(context.Background(), GridScanConfig{
+		Query:  "cafe",
+		Center: LatLng{Lat: 40.0, Lng: -74.0},
+		Size:   3,
+		Target: BusinessMatcher{Name: "Target Cafe"},
+		Budget: budget,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 4 || budget.Spent() != 4 || budget.Remaining() != 0 {
+		t.Errorf("calls=%d spent=%d remaining=%d, want 4/4/0", calls.Load(), budget.Spent(), budget.Remaining())
+	}
+	capped := 0
+	for _, row := range res.Cells {
+		for _, cell := range row {
+			if errors.Is(cell.Err, ErrCreditLimit) {
+				capped++
+			}
+		}
+	}
+	if capped != 5 {
+		t.Errorf("cells skipped by credit cap: got %d, want 5", capped)
+	}
+}
+
+func TestGridScan_CachedCellsAreFree(t *testing.T) {
+	var calls atomic.Int32
+	c := mustNew(t, "key", WithDoer(gridDoer(&calls)), WithCache(NewMemoryCache(time.Minute, 100)))
+	cfg := GridScanConfig{
+		Query:  "cafe",
+		Center: LatLng{Lat: 40.0, Lng: -74.0},
+		Size:   3,
+		Target: BusinessMatcher{Name: "Target Cafe"},
+	}
+	if _, err := c.GridScan(context.Background(), cfg); err != nil {
+		t.Fatalf("first scan: %v", err)
+	}
+
+	cfg.Budget = NewCreditBudget(1)
+	res, err := c.GridScan(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("second scan: %v", err)
+	}
+	if calls.Load() != 9 || res.CreditsSpent != 0 {
+		t.Errorf("calls=%d CreditsSpent=%d, want 9/0", calls.Load(), res.CreditsSpent)
+	}
+	if got := res.Ranks(); got[0][0] != 1 {
+		t.Errorf("ranks from cache: got %v", got)
+	}
+}
+
+func TestGridScan_InvalidConfig(t *testing.T) {
+	c := mustNew(t, "key", WithDoer(&mockDoer{}))
+	if _, err := c.GridScan(context.Background(), GridScanConfig{Query: "cafe"}); err == nil {
+		t.Error("expected error for missing target")
+	}
+	if _, err := c.GridScan(context.Background(), GridScanConfig{Target: BusinessMatcher{Name: "x"}}); err == nil {
+		t.Error("expected error for missing query")
+	}
+}
+
+func TestGridScanResult_Exports(t *testing.T) {
+	res := &GridScanResult{Cells: [][]GridCell{{
+		{GridPoint: GridPoint{Row: 0, Col: 0, LatLng: LatLng{1, 2}}, Rank: 2, Match: &PlaceResult{Title: "Target"}},
+		{GridPoint: GridPoint{Row: 0, Col: 1, LatLng: LatLng{1, 3}}, Err: ErrCreditLimit},
+	}}}
+
+	fc := res.FeatureCollection()
+	if len(fc.Features) != 2 || fc.Features[0].Properties["weight"] != 0.5 || fc.Features[1].Properties["found"] != false {
+		t.Errorf("heatmap features: got %+v", fc.Features)
+	}
+
+	var buf bytes.Buffer
+	if err := res.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("csv lines: got %d, want 3:\n%s", len(lines), buf.String())
+	}
+	if lines[1] != "0,0,1.0000000,2.0000000,2,Target," {
+		t.Errorf("csv row: got %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "credit limit") {
+		t.Errorf("csv should record the cell error, got %q", lines[2])
+	}
+}
+
+func TestRequestCredits(t *testing.T) {
+	if got := RequestCredits(&SearchRequest{Q: "x"}); got != 1 {
+		t.Errorf("default num: got %d, want 1", got)
+	}
+	if got := RequestCredits(&SearchRequest{Q: "x", Num: 100}); got != 2 {
+		t.Errorf("num=100: got %d, want 2", got)
+	}
+}
@@ -0,0 +1,249 @@
+package serper
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const minutesPerDay = 24 * 60
+
+// OpenInterval is a span of opening time in minutes since midnight of the
+// day it starts on. Close is greater than Open; a Close beyond 1440 means
+// the span runs past midnight into the next day.
+type OpenInterval struct {
+	Open  int `json:"open"`
+	Close int `json:"close"`
+}
+
+// WeeklySchedule is a parsed form of PlaceResult.Hours.
+type WeeklySchedule struct {
+	Days     [7][]OpenInterval `json:"days"`               // indexed by time.Weekday; empty means closed
+	Known    [7]bool           `json:"known"`              // whether any line described the day
+	Unparsed []string          `json:"unparsed,omitempty"` // lines that could not be understood
+}
+
+var (
+	dayNames = [7]string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+	daySpecRe   = regexp.MustCompile(`^((?:[a-z]+\.?)(?:\s*(?:-|,|&|and|to|through)\s*[a-z]+\.?)*)\s*:?\s*(.*)$`)
+	daySplitRe  = regexp.MustCompile(`\s*(?:,|&|\band\b)\s*`)
+	dayRangeRe  = regexp.MustCompile(`\s*(?:-|\bto\b|\bthrough\b)\s*`)
+	shiftSplit  = regexp.MustCompile(`\s*(?:,|;|\band\b)\s*`)
+	spanSplitRe = regexp.MustCompile(`\s*(?:-|\bto\b)\s*`)
+	clockRe     = regexp.MustCompile(`^(\d{1,2})(?:[:.](\d{2}))?\s*(a\.?m\.?|p\.?m\.?|a|p)?$`)
+)
+
+// ParseHours parses free-text opening hours such as "Monday: 9 AM–5 PM",
+// "Friday: 11 AM–2 PM, 5–10 PM", "Saturday: 6 PM–2 AM", "Sunday: Closed" or
+// "Mon-Fri: Open 24 hours" into a weekly schedule. Lines that cannot be
+// parsed are kept in Unparsed rather than dropped.
+func ParseHours(lines []string) *WeeklySchedule {
+	s := &WeeklySchedule{}
+	for _, line := range lines {
+		days, intervals, ok := parseHoursLine(line)
+		if !ok {
+			s.Unparsed = append(s.Unparsed, line)
+			continue
+		}
+		for _, d := range days {
+			s.Known[d] = true
+			s.Days[d] = append(s.Days[d], intervals...)
+		}
+	}
+	return s
+}
+
+// Schedule parses the place's Hours into a weekly schedule.
+func (p PlaceResult) Schedule() *WeeklySchedule {
+	return ParseHours(p.Hours)
+}
+
+// IsOpenAt reports whether the schedule is open at t, interpreted in loc.
+// A nil loc uses t's own location. Spans that started the previous day and
+// run past midnight are taken into account. Days with no parsed line are
+// treated as closed; check Known to tell the two apart.
+func (s *WeeklySchedule) IsOpenAt(t time.Time, loc *time.Location) bool {
+	if loc != nil {
+		t = t.In(loc)
+	}
+	day := t.Weekday()
+	minute := t.Hour()*60 + t.Minute()
+	for _, iv := range s.Days[day] {
+		if minute >= iv.Open && minute < iv.Close {
+			return true
+		}
+	}
+	prev := (day + 6) % 7
+	for _, iv := range s.Days[prev] {
+		if iv.Close > minutesPerDay && minute+minutesPerDay >= iv.Open && minute+minutesPerDay < iv.Close {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHoursLine parses a single "<days>: <times>" line.
+func parseHoursLine(line string) ([]time.Weekday, []OpenInterval, bool) {
+	norm := strings.ToLower(strings.TrimSpace(line))
+	norm = strings.NewReplacer("–", "-", "—", "-", "\u2011", "-", "\u202f", " ", "\u00a0", " ").Replace(norm)
+
+	var days []time.Weekday
+	var rest string
+	switch {
+	case strings.HasPrefix(norm, "daily"):
+		days, rest = allWeekdays(), strings.TrimPrefix(norm, "daily")
+	case strings.HasPrefix(norm, "every day"):
+		days, rest = allWeekdays(), strings.TrimPrefix(norm, "every day")
+	default:
+		m := daySpecRe.FindStringSubmatch(norm)
+		if m == nil {
+			return nil, nil, false
+		}
+		var ok bool
+		if days, ok = parseDaySpec(m[1]); !ok {
+			return nil, nil, false
+		}
+		rest = m[2]
+	}
+	rest = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rest), ":"))
+
+	switch rest {
+	case "closed":
+		return days, nil, true
+	case "open 24 hours", "24 hours", "open 24h", "24h", "24/7":
+		return days, []OpenInterval{{Open: 0, Close: minutesPerDay}}, true
+	case "":
+		return nil, nil, false
+	}
+
+	var intervals []OpenInterval
+	for _, shift := range shiftSplit.Split(rest, -1) {
+		iv, ok := parseShift(shift)
+		if !ok {
+			return nil, nil, false
+		}
+		intervals = append(intervals, iv)
+	}
+	return days, intervals, true
+}
+
+// parseDaySpec parses "monday", "mon-fri", "sat, sun" or "fri-mon".
+func parseDaySpec(spec string) ([]time.Weekday, bool) {
+	var days []time.Weekday
+	for _, part := range daySplitRe.Split(spec, -1) {
+		bounds := dayRangeRe.Split(part, -1)
+		switch len(bounds) {
+		case 1:
+			d, ok := parseDayName(bounds[0])
+			if !ok {
+				return nil, false
+			}
+			days = append(days, d)
+		case 2:
+			from, ok1 := parseDayName(bounds[0])
+			to, ok2 := parseDayName(bounds[1])
+			if !ok1 || !ok2 {
+				return nil, false
+			}
+			for d := from; ; d = (d + 1) % 7 {
+				days = append(days, d)
+				if d == to {
+					break
+				}
+			}
+		default:
+			return nil, false
+		}
+	}
+	return days, len(days) > 0
+}
+
+// parseDayName accepts full names and abbreviations such as "tue", "tues" or "thu.".
+func parseDayName(s string) (time.Weekday, bool) {
+	s = strings.TrimSuffix(strings.TrimSpace(s), ".")
+	if len(s) < 2 {
+		return 0, false
+	}
+	for i, name := range dayNames {
+		if strings.HasPrefix(name, s) && (len(s) >= 3 || s == name[:2]) {
+			return time.Weekday(i), true
+		}
+	}
+	return 0, false
+}
+
+// parseShift parses "9 am-5 pm", "5-10 pm" or "09:00-17:30".
+func parseShift(shift string) (OpenInterval, bool) {
+	bounds := spanSplitRe.Split(strings.TrimSpace(shift), -1)
+	if len(bounds) != 2 {
+		return OpenInterval{}, false
+	}
+	open, openMer, ok1 := parseClock(bounds[0])
+	closing, closeMer, ok2 := parseClock(bounds[1])
+	if !ok1 || !ok2 {
+		return OpenInterval{}, false
+	}
+	// "5-10 pm": the opening time inherits the closing meridiem.
+	if openMer == "" && closeMer != "" {
+		openMer = closeMer
+	}
+	open = applyMeridiem(open, openMer)
+	closing = applyMeridiem(closing, closeMer)
+	if closing <= open {
+		closing += minutesPerDay
+	}
+	return OpenInterval{Open: open, Close: closing}, true
+}
+
+// parseClock parses a clock time into minutes since midnight, returning the
+// meridiem ("a", "p" or "") separately so it can be applied later.
+func parseClock(s string) (int, string, bool) {
+	s = strings.TrimSpace(s)
+	switch s {
+	case "noon":
+		return 12 * 60, "", true
+	case "midnight":
+		return 0, "", true
+	}
+	m := clockRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0, "", false
+	}
+	h, _ := strconv.Atoi(m[1])
+	min := 0
+	if m[2] != "" {
+		min, _ = strconv.Atoi(m[2])
+	}
+	mer := ""
+	if m[3] != "" {
+		mer = m[3][:1]
+	}
+	if min > 59 || h > 24 || (mer != "" && (h < 1 || h > 12)) {
+		return 0, "", false
+	}
+	return h*60 + min, mer, true
+}
+
+func applyMeridiem(minutes int, mer string) int {
+	switch mer {
+	case "a":
+		if minutes >= 12*60 {
+			minutes -= 12 * 60
+		}
+	case "p":
+		if minutes < 12*60 {
+			minutes += 12 * 60
+		}
+	}
+	return minutes
+}
+
+func allWeekdays() []time.Weekday {
+	days := make([]time.Weekday, 7)
+	for i := range days {
+		days[i] = time.Weekday(i)
+	}
+	return days
+}
@@ -0,0 +1,102 @@
+package serper
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseHours(t *testing.T) {
+	s := ParseHours([]string{
+		"Monday: 9 AM–5 PM",
+		"Tuesday: Open 24 hours",
+		"Wednesday: Closed",
+		"Thursday: 09:00–17:30",
+		"Friday: 11 AM–2 PM, 5–10 PM",
+		"Saturday: 6 PM–2 AM",
+		"Sunday: whenever we feel like it",
+	})
+
+	tests := []struct {
+		day  time.Weekday
+		want []OpenInterval
+	}{
+		{time.Monday, []OpenInterval{{9 * 60, 17 * 60}}},
+		{time.Tuesday, []OpenInterval{{0, 24 * 60}}},
+		{time.Wednesday, nil},
+		{time.Thursday, []OpenInterval{{9 * 60, 17*60 + 30}}},
+		{time.Friday, []OpenInterval{{11 * 60, 14 * 60}, {17 * 60, 22 * 60}}},
+		{time.Saturday, []OpenInterval{{18 * 60, 26 * 60}}},
+	}
+	for _, tt := range tests {
+		if !s.Known[tt.day] {
+			t.Errorf("%s: expected day to be known", tt.day)
+		}
+		if !reflect.DeepEqual(s.Days[tt.day], tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.day, s.Days[tt.day], tt.want)
+		}
+	}
+	if s.Known[time.Sunday] {
+		t.Error("Sunday should not be known")
+	}
+	if len(s.Unparsed) != 1 || s.Unparsed[0] != "Sunday: whenever we feel like it" {
+		t.Errorf("Unparsed: got %q", s.Unparsed)
+	}
+}
+
+func TestParseHours_DayRanges(t *testing.T) {
+	s := ParseHours([]string{"Mon-Fri: 8am-6pm", "Sat, Sun: Closed"})
+	for d := time.Monday; d <= time.Friday; d++ {
+		if len(s.Days[d]) != 1 || s.Days[d][0] != (OpenInterval{8 * 60, 18 * 60}) {
+			t.Errorf("%s: got %v", d, s.Days[d])
+		}
+	}
+	if !s.Known[time.Saturday] || !s.Known[time.Sunday] || len(s.Days[time.Sunday]) != 0 {
+		t.Errorf("weekend should be known and closed, got %v / %v", s.Days[time.Saturday], s.Days[time.Sunday])
+	}
+	if len(s.Unparsed) != 0 {
+		t.Errorf("Unparsed: got %q", s.Unparsed)
+	}
+}
+
+func TestWeeklySchedule_IsOpenAt(t *testing.T) {
+	place := PlaceResult{Hours: []string{
+		"Friday: 11 AM–2 PM, 5–10 PM",
+		"Saturday: 6 PM–2 AM",
+		"Sunday: Closed",
+	}}
+	s := place.Schedule()
+	loc := time.FixedZone("test", -5*60*60)
+	at := func(day, hour, min int) time.Time {
+		// 2026-10-16 is a Friday.
+		return time.Date(2026, 10, 16+day, hour, min, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"friday lunch", at(0, 12, 0), true},
+		{"friday split gap", at(0, 15, 0), false},
+		{"friday dinner", at(0, 21, 59), true},
+		{"friday close is exclusive", at(0, 22, 0), false},
+		{"saturday before opening", at(1, 17, 0), false},
+		{"saturday late", at(1, 23, 30), true},
+		{"overnight into closed sunday", at(2, 1, 30), true},
+		{"sunday after overnight span", at(2, 2, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.IsOpenAt(tt.t, nil); got != tt.want {
+				t.Errorf("IsOpenAt(%s): got %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+
+	// The same instant seen from UTC is 17:00 Friday local time.
+	utc := time.Date(2026, 10, 16, 22, 0, 0, 0, time.UTC)
+	if !s.IsOpenAt(utc, loc) {
+		t.Error("IsOpenAt should convert to the given location")
+	}
+}
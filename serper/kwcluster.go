@@ -0,0 +1,325 @@
+package serper
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Keyword clustering defaults.
+const (
+	defaultKeywordClusterTopN        = 10
+	defaultKeywordClusterMinShared   = 3
+	defaultKeywordClusterConcurrency = 4
+)
+
+// KeywordClusterConfig configures ClusterKeywords.
+type KeywordClusterConfig struct {
+	Keywords    []string
+	Market      Market
+	TopN        int           // organic links compared per keyword, 1-100 (default 10)
+	MinShared   int           // links two keywords must share to be grouped (default 3)
+	Strict      bool          // require every pair in a cluster to share MinShared, not just a chain
+	Concurrency int           // parallel searches (default 4)
+	Budget      *CreditBudget // nil means unlimited; responses served from the client cache are free
+	Sink        Sink          // optional; receives EventClusterComplete
+}
+
+// KeywordCluster is a group of keywords whose top results overlap, so one
+// page can target them all.
+type KeywordCluster struct {
+	// Lead is the first keyword of the cluster in input order.
+	Lead     string   `json:"lead"`
+	Keywords []string `json:"keywords"`
+	// Links are the normalised links ranking for at least two of the
+	// keywords, most shared first.
+	Links []string `json:"links,omitempty"`
+}
+
+// KeywordOverlap is the number of top links two keywords share.
+type KeywordOverlap struct {
+	A      string `json:"a"`
+	B      string `json:"b"`
+	Shared int    `json:"shared"`
+}
+
+// KeywordFailure is a keyword whose search failed. It is left out of the
+// clusters.
+type KeywordFailure struct {
+	Keyword string `json:"keyword"`
+	Error   string `json:"error"`
+}
+
+// KeywordClusterResult is the outcome of ClusterKeywords.
+type KeywordClusterResult struct {
+	Clusters []KeywordCluster `json:"clusters"`
+	// Overlaps lists every pair sharing at least one link.
+	Overlaps []KeywordOverlap `json:"overlaps"`
+	Failed   []KeywordFailure `json:"failed,omitempty"`
+}
+
+// ClusterKeywords searches each keyword in cfg.Market and groups keywords
+// sharing at least cfg.MinShared of their top cfg.TopN organic links. By
+// default grouping is transitive, like ClusterNews; with Strict a keyword
+// joins the first cluster whose every member it overlaps with enough.
+// Clusters are ordered by their lead keyword, and keywords keep their input
+// order; duplicate keywords (after case and whitespace folding) are
+// searched once.
+//
+// A keyword whose search fails, including on ErrCreditLimit, is reported in
+// Failed. Only invalid configuration or a cancelled context returns an
+// error. If cfg.Sink is set the result is sent to it; a failed notification
+// is returned together with the result.
+func (c *Client) ClusterKeywords(ctx context.Context, cfg KeywordClusterConfig) (*KeywordClusterResult, error) {
+	if cfg.TopN <= 0 {
+		cfg.TopN = defaultKeywordClusterTopN
+	}
+	if cfg.TopN > 100 {
+		return nil, fmt.Errorf("serper: keyword clustering: TopN must be at most 100, got %d", cfg.TopN)
+	}
+	if cfg.MinShared <= 0 {
+		cfg.MinShared = defaultKeywordClusterMinShared
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaultKeywordClusterConcurrency
+	}
+	var keywords []string
+	seen := make(map[string]bool, len(cfg.Keywords))
+	for _, kw := range cfg.Keywords {
+		key := strings.ToLower(strings.Join(strings.Fields(kw), " "))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keywords = append(keywords, strings.TrimSpace(kw))
+	}
+	if len(keywords) == 0 {
+		return nil, fmt.Errorf("serper: keyword clustering: no keywords")
+	}
+
+	links := make([][]string, len(keywords))
+	errs := make([]error, len(keywords))
+	sem := make(chan struct{}, cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i, kw := range keywords {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			req := &SearchRequest{Q: kw, GL: cfg.Market.GL, HL: cfg.Market.HL, Location: cfg.Market.Location, Num: cfg.TopN}
+			if err := c.reserveUncached(ctx, cfg.Budget, "/search", req); err != nil {
+				errs[i] = err
+				return
+			}
+			resp, err := c.Search(ctx, req)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			links[i] = topLinks(resp.Organic, cfg.TopN)
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	res := &KeywordClusterResult{}
+	var ok []int // indexes of keywords that were searched
+	for i, kw := range keywords {
+		if errs[i] != nil {
+			res.Failed = append(res.Failed, KeywordFailure{Keyword: kw, Error: errs[i].Error()})
+			continue
+		}
+		ok = append(ok, i)
+	}
+	shared := make(map[[2]int]int)
+	for x, i := range ok {
+		for _, j := range ok[x+1:] {
+			n := sharedLinks(links[i], links[j])
+			if n == 0 {
+				continue
+			}
+			shared[[2]int{i, j}] = n
+			res.Overlaps = append(res.Overlaps, KeywordOverlap{A: keywords[i], B: keywords[j], Shared: n})
+		}
+	}
+	related := func(i, j int) bool {
+		if i > j {
+			i, j = j, i
+		}
+		return shared[[2]int{i, j}] >= cfg.MinShared
+	}
+
+	var groups [][]int
+	if cfg.Strict {
+		groups = strictKeywordGroups(ok, related)
+	} else {
+		groups = linkedKeywordGroups(ok, related)
+	}
+	for _, g := range groups {
+		cl := KeywordCluster{Lead: keywords[g[0]]}
+		var members [][]string
+		for _, i := range g {
+			cl.Keywords = append(cl.Keywords, keywords[i])
+			members = append(members, links[i])
+		}
+		cl.Links = commonLinks(members)
+		res.Clusters = append(res.Clusters, cl)
+	}
+	if err := Notify(ctx, cfg.Sink, EventClusterComplete, res); err != nil {
+		return res, fmt.Errorf("serper: keyword clustering: notify: %w", err)
+	}
+	return res, nil
+}
+
+// linkedKeywordGroups returns the connected components of related over
+// idx, each in input order, ordered by first member.
+func linkedKeywordGroups(idx []int, related func(i, j int) bool) [][]int {
+	parent := make(map[int]int, len(idx))
+	for _, i := range idx {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for x, i := range idx {
+		for _, j := range idx[x+1:] {
+			if !related(i, j) {
+				continue
+			}
+			// Keep the earliest index as the root so groups are ordered
+			// by first appearance.
+			ri, rj := find(i), find(j)
+			if ri < rj {
+				parent[rj] = ri
+			} else if rj < ri {
+				parent[ri] = rj
+			}
+		}
+	}
+	var groups [][]int
+	pos := make(map[int]int) // root -> position in groups
+	for _, i := range idx {
+		root := find(i)
+		g, ok := pos[root]
+		if !ok {
+			g = len(groups)
+			pos[root] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
+
+// strictKeywordGroups adds each keyword, in input order, to the first group
+// whose every member it is related to, or starts a new group.
+func strictKeywordGroups(idx []int, related func(i, j int) bool) [][]int {
+	var groups [][]int
+next:
+	for _, i := range idx {
+		for g, members := range groups {
+			all := true
+			for _, j := range members {
+				if !related(i, j) {
+					all = false
+					break
+				}
+			}
+			if all {
+				groups[g] = append(groups[g], i)
+				continue next
+			}
+		}
+		groups = append(groups, []int{i})
+	}
+	return groups
+}
+
+// sharedLinks counts the links present in both lists.
+func sharedLinks(a, b []string) int {
+	in := make(map[string]bool, len(a))
+	for _, l := range a {
+		in[l] = true
+	}
+	n := 0
+	for _, l := range b {
+		if in[l] {
+			n++
+			delete(in, l)
+		}
+	}
+	return n
+}
+
+// commonLinks returns the links appearing in at least two of lists, by
+// number of lists descending, then by best rank.
+func commonLinks(lists [][]string) []string {
+	count := make(map[string]int)
+	best := make(map[string]int)
+	for _, list := range lists {
+		for rank, l := range list {
+			count[l]++
+			if b, ok := best[l]; !ok || rank < b {
+				best[l] = rank
+			}
+		}
+	}
+	var out []string
+	for l, n := range count {
+		if n >= 2 {
+			out = append(out, l)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if count[out[i]] != count[out[j]] {
+			return count[out[i]] > count[out[j]]
+		}
+		if best[out[i]] != best[out[j]] {
+			return best[out[i]] < best[out[j]]
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
+
+// WriteCSV writes one row per keyword: cluster (1-based), lead, keyword,
+// size, links (space-separated), error. Failed keywords come last, with
+// only keyword and error set.
+func (r *KeywordClusterResult) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"cluster", "lead", "keyword", "size", "links", "error"}); err != nil {
+		return err
+	}
+	for i, cl := range r.Clusters {
+		links := strings.Join(cl.Links, " ")
+		for _, kw := range cl.Keywords {
+			if err := cw.Write([]string{strconv.Itoa(i + 1), cl.Lead, kw, strconv.Itoa(len(cl.Keywords)), links, ""}); err != nil {
+				return err
+			}
+		}
+	}
+	for _, f := range r.Failed {
+		if err := cw.Write([]string{"", "", f.Keyword, "", "", f.Error}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,144 @@
+package serper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serpResponses builds fakeDoer responses listing organic results for the
+// given hosts of each query.
+func serpResponses(serps map[string][]string) map[string]any {
+	out := make(map[string]any, len(serps))
+	for q, hosts := range serps {
+		var resp SearchResponse
+		for _, host := range hosts {
+			resp.Organic = append(resp.Organic, OrganicResult{Link: "https://" + host + "/"})
+		}
+		out[q] = resp
+	}
+	return out
+}
+
+func TestClusterKeywords(t *testing.T) {
+	serps := map[string][]string{
+		"espresso machine":      {"a.com", "b.com", "c.com", "d.com"},
+		"best espresso machine": {"b.com", "a.com", "c.com", "x.com"},
+		"espresso maker":        {"x.com", "b.com", "c.com", "y.com"},
+		"burr grinder":          {"g.com", "h.com", "i.com", "a.com"},
+	}
+	tests := []struct {
+		name      string
+		strict    bool
+		want      [][]string
+		firstLink string
+	}{
+		// espresso maker shares 3 links with the second keyword only, so
+		// it chains in unless clustering is strict.
+		{"transitive", false, [][]string{{"espresso machine", "best espresso machine", "espresso maker"}, {"burr grinder"}}, "https://b.com"},
+		{"strict", true, [][]string{{"espresso machine", "best espresso machine"}, {"espresso maker"}, {"burr grinder"}}, "https://a.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			c := mustNew(t, "key", WithDoer(fakeDoer(serpResponses(serps), &calls)))
+			res, err := c.ClusterKeywords(context.Background(), KeywordClusterConfig{
+				Keywords: []string{"espresso machine", "best espresso machine", "Espresso  Machine", "espresso maker", "burr grinder"},
+				Strict:   tt.strict,
+			})
+			if err != nil {
+				t.Fatalf("ClusterKeywords: %v", err)
+			}
+			if calls.Load() != 4 {
+				t.Errorf("duplicate keyword should be searched once, got %d calls", calls.Load())
+			}
+			var got [][]string
+			for _, cl := range res.Clusters {
+				got = append(got, cl.Keywords)
+				if cl.Lead != cl.Keywords[0] {
+					t.Errorf("lead: got %q, want %q", cl.Lead, cl.Keywords[0])
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("clusters: got %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if strings.Join(got[i], "|") != strings.Join(tt.want[i], "|") {
+					t.Errorf("cluster %d: got %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+			if links := res.Clusters[0].Links; len(links) == 0 || links[0] != tt.firstLink {
+				t.Errorf("first cluster links: got %q", links)
+			}
+		})
+	}
+}
+
+func TestClusterKeywords_BudgetAndCache(t *testing.T) {
+	serps := map[string][]string{"a": {"x.com"}, "b": {"x.com"}, "c": {"x.com"}}
+	var calls atomic.Int32
+	c := mustNew(t, "key", WithDoer(fakeDoer(serpResponses(serps), &calls)), WithCache(NewMemoryCache(time.Hour, 0)))
+	cfg := KeywordClusterConfig{Keywords: []string{"a", "b", "c"}, MinShared: 1, Concurrency: 1, Budget: NewCreditBudget(2)}
+
+	res, err := c.ClusterKeywords(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("ClusterKeywords: %v", err)
+	}
+	if len(res.Failed) != 1 || res.Failed[0].Keyword != "c" || !strings.Contains(res.Failed[0].Error, ErrCreditLimit.Error()) {
+		t.Errorf("failed: got %+v", res.Failed)
+	}
+	if len(res.Clusters) != 1 || len(res.Clusters[0].Keywords) != 2 {
+		t.Errorf("clusters: got %+v", res.Clusters)
+	}
+
+	// Cached responses are free, so a second run with a fresh budget of
+	// one credit only needs to pay for "c".
+	cfg.Budget = NewCreditBudget(1)
+	res, err = c.ClusterKeywords(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("ClusterKeywords: %v", err)
+	}
+	if len(res.Failed) != 0 || calls.Load() != 3 {
+		t.Errorf("second run: failed %+v, calls %d", res.Failed, calls.Load())
+	}
+}
+
+func TestClusterKeywords_Errors(t *testing.T) {
+	c := mustNew(t, "key", WithDoer(doerFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("unreachable")
+	})))
+	if _, err := c.ClusterKeywords(context.Background(), KeywordClusterConfig{Keywords: []string{" "}}); err == nil {
+		t.Error("expected an error for no keywords")
+	}
+	if _, err := c.ClusterKeywords(context.Background(), KeywordClusterConfig{Keywords: []string{"a"}, TopN: 101}); err == nil {
+		t.Error("expected an error for TopN over 100")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.ClusterKeywords(ctx, KeywordClusterConfig{Keywords: []string{"a", "b"}}); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: got %v", err)
+	}
+}
+
+func TestKeywordClusterResult_WriteCSV(t *testing.T) {
+	res := &KeywordClusterResult{
+		Clusters: []KeywordCluster{{Lead: "a", Keywords: []string{"a", "b"}, Links: []string{"https://x.com", "https://y.com"}}},
+		Failed:   []KeywordFailure{{Keyword: "c", Error: "boom"}},
+	}
+	var buf bytes.Buffer
+	if err := res.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	want := "cluster,lead,keyword,size,links,error\n" +
+		"1,a,a,2,https://x.com https://y.com,\n" +
+		"1,a,b,2,https://x.com https://y.com,\n" +
+		",,c,,,boom\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
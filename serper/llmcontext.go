@@ -0,0 +1,343 @@
+package serper
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Context formats for ContextConfig.
+const (
+	ContextMarkdown = "markdown"
+	ContextText     = "text"
+)
+
+// charsPerToken is the rough ratio of characters to tokens in English
+// text, used for ContextConfig.MaxTokens and ApproxTokens.
+const charsPerToken = 4
+
+// minTruncatedBody is the least body text worth keeping when the last
+// source is cut to fit the budget; below it the source is left out.
+const minTruncatedBody = 40
+
+// ContextConfig configures BuildContext.
+type ContextConfig struct {
+	Format string // ContextMarkdown (default) or ContextText
+	// MaxChars and MaxTokens cap the length of the context; the tighter one
+	// applies and zero means no cap. Tokens are approximated as four
+	// characters each.
+	MaxChars  int
+	MaxTokens int
+	// MaxResults caps each list of results, such as the organic results or
+	// People Also Ask; zero means no cap.
+	MaxResults int
+	// IncludeURLs adds each source's URL under its title. Citations holds
+	// them either way.
+	IncludeURLs bool
+}
+
+// LLMContext is search results formatted for a language model prompt.
+type LLMContext struct {
+	Text string `json:"text"`
+	// Citations maps each citation number used in Text to its URL.
+	Citations map[int]string `json:"citations"`
+	// Truncated reports whether sources were cut or left out to fit the
+	// budget.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// ApproxTokens estimates the number of tokens in s at four characters a
+// token.
+func ApproxTokens(s string) int {
+	return (utf8.RuneCountInString(s) + charsPerToken - 1) / charsPerToken
+}
+
+// contextSource is one cited entry in a context, rendered as a title, a
+// line of details and a body.
+type contextSource struct {
+	title string
+	link  string
+	meta  []string
+	body  string
+}
+
+// BuildContext formats resp, a pointer to any of the search response types,
+// as numbered sources for a prompt. A web search lists the answer box, the
+// knowledge graph, the organic results, People Also Ask and top stories,
+// in that order; other verticals list their results. Sources that share a
+// URL share a citation number, and sources without one are not numbered.
+//
+// Sources are added in order until the budget runs out. The first source
+// that does not fit has its body cut at a word boundary, or is left out
+// with the rest if too little room remains.
+func BuildContext(resp any, cfg ContextConfig) (*LLMContext, error) {
+	if cfg.Format == "" {
+		cfg.Format = ContextMarkdown
+	}
+	if cfg.Format != ContextMarkdown && cfg.Format != ContextText {
+		return nil, fmt.Errorf("serper: context: unknown format %q (want %s or %s)", cfg.Format, ContextMarkdown, ContextText)
+	}
+	query, sources, err := contextSources(resp, cfg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	limit := cfg.MaxChars
+	if t := cfg.MaxTokens * charsPerToken; cfg.MaxTokens > 0 && (limit <= 0 || t < limit) {
+		limit = t
+	}
+
+	out := &LLMContext{Citations: make(map[int]string)}
+	var b strings.Builder
+	used := 0
+	fits := func(s string) bool { return limit <= 0 || used+utf8.RuneCountInString(s) <= limit }
+	write := func(s string) {
+		b.WriteString(s)
+		used += utf8.RuneCountInString(s)
+	}
+
+	if query != "" {
+		heading := fmt.Sprintf("Search results for %q\n\n", query)
+		if cfg.Format == ContextMarkdown {
+			heading = "# " + heading
+		}
+		if fits(heading) {
+			write(heading)
+		}
+	}
+	numbers := make(map[string]int)
+	for _, s := range sources {
+		num := 0
+		key := normalizeLink(s.link)
+		if s.link != "" {
+			if num = numbers[key]; num == 0 {
+				num = len(out.Citations) + 1
+			}
+		}
+		head := cfg.sourceHead(s, num)
+		block := head + s.body + "\n\n"
+		if !fits(block) {
+			out.Truncated = true
+			room := limit - used - utf8.RuneCountInString(head) - 2
+			if s.body == "" || room < minTruncatedBody {
+				break
+			}
+			block = head + truncateText(s.body, room) + "\n\n"
+		}
+		write(block)
+		if num > 0 {
+			numbers[key] = num
+			out.Citations[num] = s.link
+		}
+		if out.Truncated {
+			break
+		}
+	}
+	out.Text = strings.TrimRight(b.String(), "\n")
+	if out.Text != "" {
+		out.Text += "\n"
+	}
+	return out, nil
+}
+
+// SearchContext runs a web search and formats the response with
+// BuildContext.
+func (c *Client) SearchContext(ctx context.Context, req *SearchRequest, cfg ContextConfig) (*LLMContext, error) {
+	resp, err := c.Search(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return BuildContext(resp, cfg)
+}
+
+// sourceHead renders a source's title line, details and URL, each ending
+// in a newline.
+func (cfg ContextConfig) sourceHead(s contextSource, num int) string {
+	title := s.title
+	if num > 0 {
+		title = "[" + strconv.Itoa(num) + "] " + title
+	}
+	var b strings.Builder
+	if cfg.Format == ContextMarkdown {
+		b.WriteString("### " + title + "\n")
+	} else {
+		b.WriteString(title + "\n")
+	}
+	if meta := joinNonEmpty(s.meta, " · "); meta != "" {
+		if cfg.Format == ContextMarkdown {
+			meta = "_" + meta + "_"
+		}
+		b.WriteString(meta + "\n")
+	}
+	if cfg.IncludeURLs && s.link != "" {
+		b.WriteString(s.link + "\n")
+	}
+	return b.String()
+}
+
+// contextSources lists the sources of resp in the order BuildContext adds
+// them, capping each result list at maxResults entries when positive.
+func contextSources(resp any, maxResults int) (query string, out []contextSource, err error) {
+	add := func(n int, source func(i int) contextSource) {
+		if maxResults > 0 && n > maxResults {
+			n = maxResults
+		}
+		for i := 0; i < n; i++ {
+			s := source(i)
+			s.title = oneLine(s.title)
+			s.body = oneLine(s.body)
+			if s.title == "" && s.body == "" {
+				continue
+			}
+			out = append(out, s)
+		}
+	}
+	switch r := resp.(type) {
+	case *SearchResponse:
+		query = r.SearchParameters.Q
+		if ab := r.AnswerBox; ab != nil {
+			body := ab.Answer
+			if ab.Snippet != "" && ab.Snippet != ab.Answer {
+				body = strings.TrimSpace(body + " " + ab.Snippet)
+			}
+			add(1, func(int) contextSource {
+				return contextSource{title: labelled("Answer", ab.Title), link: ab.Link, meta: []string{ab.Date}, body: body}
+			})
+		}
+		if kg := r.KnowledgeGraph; kg != nil {
+			add(1, func(int) contextSource {
+				title := kg.Title
+				if kg.Type != "" {
+					title += " (" + kg.Type + ")"
+				}
+				return contextSource{title: labelled("Knowledge graph", title), link: kg.Website, body: kgBody(kg)}
+			})
+		}
+		add(len(r.Organic), func(i int) contextSource {
+			o := r.Organic[i]
+			return contextSource{title: o.Title, link: o.Link, meta: []string{o.Date}, body: o.Snippet}
+		})
+		add(len(r.PeopleAlsoAsk), func(i int) contextSource {
+			q := r.PeopleAlsoAsk[i]
+			return contextSource{title: labelled("Question", q.Question), link: q.Link, body: q.Snippet}
+		})
+		add(len(r.TopStories), func(i int) contextSource {
+			s := r.TopStories[i]
+			return contextSource{title: s.Title, link: s.Link, meta: []string{s.Source, s.Date}}
+		})
+	case *NewsResponse:
+		query = r.SearchParameters.Q
+		add(len(r.News), func(i int) contextSource {
+			n := r.News[i]
+			return contextSource{title: n.Title, link: n.Link, meta: []string{n.Source, n.Date}, body: n.Snippet}
+		})
+	case *ScholarResponse:
+		query = r.SearchParameters.Q
+		add(len(r.Organic), func(i int) contextSource {
+			s := r.Organic[i]
+			var cited string
+			if s.CitedBy > 0 {
+				cited = "cited by " + strconv.Itoa(s.CitedBy)
+			}
+			return contextSource{title: s.Title, link: s.Link, meta: []string{s.PublicationInfo, cited}, body: s.Snippet}
+		})
+	case *PlacesResponse:
+		query = r.SearchParameters.Q
+		add(len(r.Places), func(i int) contextSource {
+			p := r.Places[i]
+			return contextSource{title: p.Title, link: p.Website, meta: []string{p.Category, ratingText(p.Rating, p.RatingCount), p.Phone}, body: p.Address}
+		})
+	case *ShoppingResponse:
+		query = r.SearchParameters.Q
+		add(len(r.Shopping), func(i int) contextSource {
+			s := r.Shopping[i]
+			return contextSource{title: s.Title, link: s.Link, meta: []string{s.Price, s.Source, ratingText(s.Rating, s.RatingCount)}, body: s.Delivery}
+		})
+	case *VideosResponse:
+		query = r.SearchParameters.Q
+		add(len(r.Videos), func(i int) contextSource {
+			v := r.Videos[i]
+			return contextSource{title: v.Title, link: v.Link, meta: []string{v.Channel, v.Duration, v.Date}, body: v.Snippet}
+		})
+	case *ImagesResponse:
+		query = r.SearchParameters.Q
+		add(len(r.Images), func(i int) contextSource {
+			im := r.Images[i]
+			return contextSource{title: im.Title, link: im.Link, meta: []string{im.Source}}
+		})
+	default:
+		return "", nil, fmt.Errorf("serper: context: unsupported response type %T", resp)
+	}
+	return query, out, nil
+}
+
+// kgBody renders a knowledge graph's description followed by its
+// attributes, sorted by name.
+func kgBody(kg *KnowledgeGraph) string {
+	parts := []string{kg.Description}
+	names := make([]string, 0, len(kg.Attributes))
+	for k := range kg.Attributes {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		parts = append(parts, k+": "+kg.Attributes[k]+".")
+	}
+	return joinNonEmpty(parts, " ")
+}
+
+// labelled prefixes title with label, or returns label alone.
+func labelled(label, title string) string {
+	if title == "" {
+		return label
+	}
+	return label + ": " + title
+}
+
+// ratingText renders a star rating and review count, or "" without one.
+func ratingText(rating float64, count int) string {
+	if rating <= 0 {
+		return ""
+	}
+	s := strconv.FormatFloat(rating, 'f', -1, 64) + "★"
+	if count > 0 {
+		s += " (" + strconv.Itoa(count) + ")"
+	}
+	return s
+}
+
+// joinNonEmpty joins the non-blank parts with sep.
+func joinNonEmpty(parts []string, sep string) string {
+	var keep []string
+	for _, p := range parts {
+		if p = oneLine(p); p != "" {
+			keep = append(keep, p)
+		}
+	}
+	return strings.Join(keep, sep)
+}
+
+// oneLine collapses runs of whitespace, including newlines, to one space.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// truncateText shortens s to at most n characters, cutting at the last
+// word boundary in the second half of the allowance where there is one,
+// and marks the cut with an ellipsis.
+func truncateText(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n <= 1 {
+		return "…"
+	}
+	cut := string(r[:n-1])
+	if i := strings.LastIndexByte(cut, ' '); i > 0 && utf8.RuneCountInString(cut[:i]) >= (n-1)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:.-") + "…"
+}
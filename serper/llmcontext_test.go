@@ -0,0 +1,165 @@
+package serper
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func contextResponse() *SearchResponse {
+	return &SearchResponse{
+		SearchParameters: SearchParameters{Q: "go language"},
+		AnswerBox:        &AnswerBox{Title: "Go", Answer: "A programming language.", Link: "https://go.dev/"},
+		KnowledgeGraph: &KnowledgeGraph{
+			Title: "Go", Type: "Programming language", Description: "Go is a statically typed language.",
+			Website: "https://go.dev", Attributes: map[string]string{"Designed by": "Robert Griesemer", "First appeared": "2009"},
+		},
+		Organic: []OrganicResult{
+			{Title: "The Go Programming Language", Link: "https://go.dev/", Snippet: "Build simple,\n secure, scalable systems."},
+			{Title: "Go (programming language) - Wikipedia", Link: "https://en.wikipedia.org/wiki/Go", Snippet: "Go is a high-level general purpose programming language.", Date: "Mar 1, 2024"},
+		},
+		PeopleAlsoAsk: []PeopleAlsoAsk{{Question: "Is Go hard to learn?", Snippet: "No.", Link: "https://example.com/faq"}},
+	}
+}
+
+func TestBuildContext_Markdown(t *testing.T) {
+	got, err := BuildContext(contextResponse(), ContextConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# Search results for "go language"
+
+### [1] Answer: Go
+A programming language.
+
+### [1] Knowledge graph: Go (Programming language)
+Go is a statically typed language. Designed by: Robert Griesemer. First appeared: 2009.
+
+### [1] The Go Programming Language
+Build simple, secure, scalable systems.
+
+### [2] Go (programming language) - Wikipedia
+_Mar 1, 2024_
+Go is a high-level general purpose programming language.
+
+### [3] Question: Is Go hard to learn?
+No.
+`
+	if got.Text != want {
+		t.Errorf("Text =\n%s\nwant\n%s", got.Text, want)
+	}
+	wantCitations := map[int]string{1: "https://go.dev/", 2: "https://en.wikipedia.org/wiki/Go", 3: "https://example.com/faq"}
+	if !reflect.DeepEqual(got.Citations, wantCitations) {
+		t.Errorf("Citations = %v, want %v", got.Citations, wantCitations)
+	}
+	if got.Truncated {
+		t.Error("Truncated without a budget")
+	}
+}
+
+func TestBuildContext_Text(t *testing.T) {
+	resp := &NewsResponse{News: []NewsResult{
+		{Title: "Go 1.23 released", Link: "https://go.dev/blog", Source: "Go Blog", Date: "2 days ago", Snippet: "Iterators arrive."},
+		{Title: "Untitled", Snippet: ""},
+		{Title: "", Snippet: ""},
+	}}
+	got, err := BuildContext(resp, ContextConfig{Format: ContextText, IncludeURLs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "[1] Go 1.23 released\nGo Blog · 2 days ago\nhttps://go.dev/blog\nIterators arrive.\n\nUntitled\n"
+	if got.Text != want {
+		t.Errorf("Text =\n%q\nwant\n%q", got.Text, want)
+	}
+}
+
+func TestBuildContext_Budget(t *testing.T) {
+	resp := contextResponse()
+	resp.Organic[1].Snippet = strings.Repeat("lorem ipsum ", 30)
+	full, _ := BuildContext(resp, ContextConfig{})
+
+	tests := []struct {
+		name       string
+		cfg        ContextConfig
+		wantLast   int // highest citation number kept
+		wantSuffix string
+	}{
+		{"chars cut mid-body", ContextConfig{MaxChars: strings.Index(full.Text, "### [3]") - 200}, 2, "…\n"},
+		{"tokens leave out", ContextConfig{MaxTokens: (strings.Index(full.Text, "### [2]") + 20) / 4}, 1, "systems.\n"},
+		{"tighter cap wins", ContextConfig{MaxChars: 60, MaxTokens: 1000}, 0, "\"go language\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildContext(resp, tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			limit := tt.cfg.MaxChars
+			if tt.cfg.MaxTokens > 0 && (limit == 0 || tt.cfg.MaxTokens*4 < limit) {
+				limit = tt.cfg.MaxTokens * 4
+			}
+			if n := utf8.RuneCountInString(got.Text); n > limit {
+				t.Errorf("len = %d, over the limit %d", n, limit)
+			}
+			if !got.Truncated {
+				t.Error("Truncated = false")
+			}
+			if len(got.Citations) != tt.wantLast {
+				t.Errorf("citations = %v, want %d", got.Citations, tt.wantLast)
+			}
+			if !strings.HasSuffix(got.Text, tt.wantSuffix) {
+				t.Errorf("Text ends %q, want suffix %q", got.Text[max(0, len(got.Text)-30):], tt.wantSuffix)
+			}
+		})
+	}
+}
+
+func TestBuildContext_MaxResultsAndErrors(t *testing.T) {
+	got, err := BuildContext(contextResponse(), ContextConfig{MaxResults: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got.Text, "Wikipedia") || !strings.Contains(got.Text, "Is Go hard") {
+		t.Errorf("MaxResults 1 should keep one result per list:\n%s", got.Text)
+	}
+	if _, err := BuildContext(contextResponse(), ContextConfig{Format: "html"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if _, err := BuildContext(&AutocompleteResponse{}, ContextConfig{}); err == nil {
+		t.Error("expected an error for an unsupported response")
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"the quick brown fox jumps", 12, "the quick…"},
+		{"abcdefghijklmnop", 6, "abcde…"},
+	}
+	for _, tt := range tests {
+		if got := truncateText(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncateText(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestClient_SearchContext(t *testing.T) {
+	doer := &mockDoer{statusCode: 200, respBody: `{"organic":[{"title":"Go","link":"https://go.dev","snippet":"Go."}]}`}
+	c := mustNew(t, "key", WithDoer(doer))
+	got, err := c.SearchContext(context.Background(), &SearchRequest{Q: "go"}, ContextConfig{Format: ContextText})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Text != "[1] Go\nGo.\n" || got.Citations[1] != "https://go.dev" {
+		t.Errorf("got %+v", got)
+	}
+	if ApproxTokens(got.Text) != 3 {
+		t.Errorf("ApproxTokens(%q) = %d, want 3", got.Text, ApproxTokens(got.Text))
+	}
+}
@@ -0,0 +1,419 @@
+package serper
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Price is a structured form of a shopping result's display price.
+// Amounts are in the currency's minor units (cents for USD, yen for JPY).
+type Price struct {
+	Amount         int64  `json:"amount"`                   // current price, or the lower bound of a range
+	MaxAmount      int64  `json:"maxAmount"`                // upper bound of a range; equals Amount otherwise
+	OriginalAmount int64  `json:"originalAmount,omitempty"` // pre-sale price, when the display string carries one
+	Currency       string `json:"currency,omitempty"`       // ISO 4217 code; empty if it cannot be determined
+	From           bool   `json:"from,omitempty"`           // "From $20", "Starting at $20"
+	Range          bool   `json:"range,omitempty"`          // "$10 - $20"
+	Sale           bool   `json:"sale,omitempty"`           // "Sale", "was $30 now $20"
+	Raw            string `json:"raw"`
+}
+
+// currencySymbols maps display symbols to ISO codes. Longer symbols are
+// matched first so "R$" wins over "$". An empty code means the currency
+// depends on the country (see dollarCurrency and kronaCurrency).
+var currencySymbols = []struct {
+	symbol string
+	code   string
+}{
+	{"us$", "USD"}, {"ca$", "CAD"}, {"au$", "AUD"}, {"nz$", "NZD"},
+	{"hk$", "HKD"}, {"mx$", "MXN"}, {"r$", "BRL"}, {"c$", "CAD"},
+	{"a$", "AUD"}, {"s$", "SGD"}, {"zł", "PLN"}, {"kč", "CZK"},
+	{"chf", "CHF"}, {"rs.", "INR"}, {"rs", "INR"}, {"rm", "MYR"},
+	{"kr", ""}, {"€", "EUR"}, {"£", "GBP"}, {"¥", ""}, {"￥", ""},
+	{"₹", "INR"}, {"₩", "KRW"}, {"₽", "RUB"}, {"₺", "TRY"}, {"₪", "ILS"},
+	{"₫", "VND"}, {"₱", "PHP"}, {"฿", "THB"}, {"$", ""},
+}
+
+// isoCurrencies lists ISO codes recognised when written out in the price.
+var isoCurrencies = map[string]bool{
+	"USD": true, "EUR": true, "GBP": true, "JPY": true, "CNY": true, "CAD": true,
+	"AUD": true, "NZD": true, "CHF": true, "SEK": true, "NOK": true, "DKK": true,
+	"PLN": true, "CZK": true, "HUF": true, "INR": true, "BRL": true, "MXN": true,
+	"KRW": true, "RUB": true, "TRY": true, "ZAR": true, "SGD": true, "HKD": true,
+	"ILS": true, "THB": true, "PHP": true, "MYR": true, "IDR": true, "VND": true,
+	"CLP": true, "COP": true, "ARS": true, "ISK": true, "AED": true, "SAR": true,
+}
+
+// countryCurrencies is the fallback currency when a price has no symbol.
+var countryCurrencies = map[string]string{
+	"us": "USD", "gb": "GBP", "uk": "GBP", "ie": "EUR", "de": "EUR", "fr": "EUR",
+	"es": "EUR", "it": "EUR", "nl": "EUR", "be": "EUR", "at": "EUR", "pt": "EUR",
+	"fi": "EUR", "gr": "EUR", "ca": "CAD", "au": "AUD", "nz": "NZD", "jp": "JPY",
+	"cn": "CNY", "in": "INR", "br": "BRL", "mx": "MXN", "ch": "CHF", "se": "SEK",
+	"no": "NOK", "dk": "DKK", "is": "ISK", "pl": "PLN", "cz": "CZK", "hu": "HUF",
+	"kr": "KRW", "ru": "RUB", "tr": "TRY", "za": "ZAR", "sg": "SGD", "hk": "HKD",
+	"il": "ILS", "th": "THB", "ph": "PHP", "my": "MYR", "id": "IDR", "vn": "VND",
+	"cl": "CLP", "co": "COP", "ar": "ARS", "ae": "AED", "sa": "SAR",
+}
+
+// zeroDecimalCurrencies have no minor unit.
+var zeroDecimalCurrencies = map[string]bool{
+	"JPY": true, "KRW": true, "VND": true, "CLP": true, "ISK": true, "IDR": true,
+}
+
+// commaDecimalLanguages write "1.299,99" rather than "1,299.99".
+var commaDecimalLanguages = map[string]bool{
+	"de": true, "fr": true, "es": true, "it": true, "nl": true, "pt": true,
+	"ru": true, "pl": true, "sv": true, "da": true, "nb": true, "no": true,
+	"fi": true, "cs": true, "tr": true, "id": true, "vi": true, "el": true,
+	"hu": true, "ro": true, "uk": true, "is": true,
+}
+
+// commaDecimalCountries is consulted when no language is given.
+var commaDecimalCountries = map[string]bool{
+	"de": true, "fr": true, "es": true, "it": true, "nl": true, "be": true,
+	"at": true, "pt": true, "br": true, "ru": true, "pl": true, "se": true,
+	"dk": true, "no": true, "fi": true, "cz": true, "tr": true, "id": true,
+	"vn": true, "gr": true, "hu": true, "ro": true, "ar": true, "cl": true,
+	"co": true, "is": true,
+}
+
+// Keyword lists are matched as whole words against the lower-cased display string.
+var (
+	priceFromWords  = []string{"from", "starting at", "starts at", "as low as", "ab", "à partir de", "a partir de", "desde", "vanaf"}
+	priceSaleWords  = []string{"sale", "was", "now", "reduced", "save", "deal", "angebot", "promo", "soldes", "oferta"}
+	priceRangeWords = []string{"-", "–", "—", "to", "bis", "à", "a"}
+)
+
+// ParsePrice parses a display price such as "$1,299.99", "€45,00" or
+// "From $20" into a Price. gl and hl are the request's country and language
+// codes; they resolve ambiguous separators ("1.299" is 1299 in German but
+// 1.299 in English) and ambiguous symbols ("$" is CAD for gl=ca).
+func ParsePrice(s, gl, hl string) (Price, error) {
+	p := Price{Raw: s}
+	gl = strings.ToLower(gl)
+	hl = strings.ToLower(hl)
+	if i := strings.IndexAny(hl, "-_"); i > 0 {
+		hl = hl[:i]
+	}
+	lower := strings.ToLower(strings.TrimSpace(s))
+	if lower == "" {
+		return p, fmt.Errorf("serper: parse price: empty string")
+	}
+
+	p.From = containsAnyWord(lower, priceFromWords)
+	p.Sale = containsAnyWord(lower, priceSaleWords)
+	p.Currency = detectCurrency(s, lower, gl)
+
+	decimalComma := commaDecimalLanguages[hl] || (hl == "" && commaDecimalCountries[gl])
+	numbers := scanPriceNumbers(lower)
+	if len(numbers) == 0 {
+		if strings.Contains(lower, "free") {
+			return p, nil
+		}
+		return p, fmt.Errorf("serper: parse price %q: no amount found", sanitizeForLog(s))
+	}
+
+	exp := 2
+	if zeroDecimalCurrencies[p.Currency] {
+		exp = 0
+	}
+	amounts := make([]int64, 0, len(numbers))
+	for _, n := range numbers {
+		a, err := parseMinorUnits(n.text, exp, decimalComma)
+		if err != nil {
+			return p, fmt.Errorf("serper: parse price %q: %w", sanitizeForLog(s), err)
+		}
+		amounts = append(amounts, a)
+	}
+
+	p.Amount = amounts[0]
+	p.MaxAmount = amounts[0]
+	if len(amounts) >= 2 {
+		between := lower[numbers[0].end:numbers[1].start]
+		lo, hi := amounts[0], amounts[1]
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		if !p.Sale && containsAnyWord(between, priceRangeWords) {
+			p.Range = true
+			p.Amount, p.MaxAmount = lo, hi
+		} else {
+			// Two prices without a range separator are a sale price shown
+			// next to the original, in either order.
+			p.Sale = true
+			p.Amount, p.MaxAmount, p.OriginalAmount = lo, lo, hi
+		}
+	}
+	return p, nil
+}
+
+// ParsedPrice parses the result's Price using the given country and language.
+func (r ShoppingResult) ParsedPrice(gl, hl string) (Price, error) {
+	return ParsePrice(r.Price, gl, hl)
+}
+
+// SortedByPrice returns the shopping results ordered by ascending price,
+// parsed with the response's echoed gl/hl. Results whose price cannot be
+// parsed keep their relative order at the end. The response is not modified.
+func (r *ShoppingResponse) SortedByPrice() []ShoppingResult {
+	type priced struct {
+		result ShoppingResult
+		amount int64
+		ok     bool
+	}
+	items := make([]priced, len(r.Shopping))
+	for i, res := range r.Shopping {
+		p, err := res.ParsedPrice(r.SearchParameters.GL, r.SearchParameters.HL)
+		items[i] = priced{result: res, amount: p.Amount, ok: err == nil}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].ok != items[j].ok {
+			return items[i].ok
+		}
+		return items[i].ok && items[i].amount < items[j].amount
+	})
+	out := make([]ShoppingResult, len(items))
+	for i, it := range items {
+		out[i] = it.result
+	}
+	return out
+}
+
+// FilterByPrice returns the shopping results whose price lies within
+// [min, max] minor units. A max of zero or less means no upper bound. If
+// currency is non-empty, only prices in that ISO currency match. Results
+// whose price cannot be parsed are dropped. The response is not modified.
+func (r *ShoppingResponse) FilterByPrice(currency string, min, max int64) []ShoppingResult {
+	var out []ShoppingResult
+	for _, res := range r.Shopping {
+		p, err := res.ParsedPrice(r.SearchParameters.GL, r.SearchParameters.HL)
+		if err != nil {
+			continue
+		}
+		if currency != "" && !strings.EqualFold(p.Currency, currency) {
+			continue
+		}
+		if p.Amount < min || (max > 0 && p.Amount > max) {
+			continue
+		}
+		out = append(out, res)
+	}
+	return out
+}
+
+// priceNumber is a numeric run found in a display price, with its byte offsets.
+type priceNumber struct {
+	text       string
+	start, end int
+}
+
+// scanPriceNumbers finds digit runs, including grouping and decimal
+// separators, in s.
+func scanPriceNumbers(s string) []priceNumber {
+	var out []priceNumber
+	runes := []rune(s)
+	offsets := make([]int, len(runes)+1)
+	for i, pos := 0, 0; i < len(runes); i++ {
+		offsets[i] = pos
+		pos += len(string(runes[i]))
+		offsets[i+1] = pos
+	}
+	for i := 0; i < len(runes); {
+		if !unicode.IsDigit(runes[i]) {
+			i++
+			continue
+		}
+		start, last := i, i
+		for j := i; j < len(runes); j++ {
+			r := runes[j]
+			if unicode.IsDigit(r) {
+				last = j
+				continue
+			}
+			if isPriceSeparator(r) && j+1 < len(runes) && unicode.IsDigit(runes[j+1]) {
+				continue
+			}
+			break
+		}
+		out = append(out, priceNumber{
+			text:  string(runes[start : last+1]),
+			start: offsets[start],
+			end:   offsets[last+1],
+		})
+		i = last + 1
+	}
+	return out
+}
+
+func isPriceSeparator(r rune) bool {
+	switch r {
+	case '.', ',', '\'', ' ', '\u00a0', '\u202f':
+		return true
+	}
+	return false
+}
+
+// parseMinorUnits converts a numeric string to minor units with exp decimal
+// places. Separators are disambiguated as follows: when both '.' and ','
+// appear, the last one is the decimal mark; a separator repeated more than
+// once is grouping; a single separator followed by exactly three digits is
+// resolved by the locale's decimal mark.
+func parseMinorUnits(num string, exp int, decimalComma bool) (int64, error) {
+	num = strings.Map(func(r rune) rune {
+		switch r {
+		case '\'', ' ', '\u00a0', '\u202f':
+			return -1
+		}
+		return r
+	}, num)
+
+	decimal := rune(0)
+	lastDot, lastComma := strings.LastIndex(num, "."), strings.LastIndex(num, ",")
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		decimal = '.'
+		if lastComma > lastDot {
+			decimal = ','
+		}
+	case lastDot >= 0 || lastComma >= 0:
+		sep, idx := '.', lastDot
+		if lastComma >= 0 {
+			sep, idx = ',', lastComma
+		}
+		digitsAfter := len(num) - idx - 1
+		switch {
+		case strings.Count(num, string(sep)) > 1:
+			// repeated separator: grouping
+		case digitsAfter != 3:
+			decimal = sep
+		case exp > 0 && (sep == ',') == decimalComma:
+			decimal = sep
+		}
+	}
+
+	intPart, fracPart := num, ""
+	if decimal != 0 {
+		idx := strings.LastIndex(num, string(decimal))
+		intPart, fracPart = num[:idx], num[idx+1:]
+	}
+	intPart = strings.NewReplacer(".", "", ",", "").Replace(intPart)
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	whole, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", num)
+	}
+	if whole > math.MaxInt64/100 {
+		return 0, fmt.Errorf("amount %q out of range", num)
+	}
+	scale := int64(1)
+	for i := 0; i < exp; i++ {
+		scale *= 10
+	}
+	minor := whole * scale
+
+	// Round the fractional part to exp digits, half up.
+	if fracPart != "" {
+		for len(fracPart) < exp+1 {
+			fracPart += "0"
+		}
+		frac, err := strconv.ParseInt(fracPart[:exp+1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount %q", num)
+		}
+		minor += (frac + 5) / 10
+	}
+	return minor, nil
+}
+
+// detectCurrency finds the ISO currency of a display price, falling back to
+// the country's currency when no symbol or code is present.
+func detectCurrency(raw, lower, gl string) string {
+	for _, f := range strings.FieldsFunc(raw, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if len(f) == 3 && isoCurrencies[f] {
+			return f
+		}
+	}
+	for _, cs := range currencySymbols {
+		idx := strings.Index(lower, cs.symbol)
+		if idx < 0 || !isSymbolBoundary(lower, idx, len(cs.symbol)) {
+			continue
+		}
+		if cs.code != "" {
+			return cs.code
+		}
+		switch cs.symbol {
+		case "$":
+			return dollarCurrency(gl)
+		case "kr":
+			return kronaCurrency(gl)
+		default: // ¥
+			if gl == "cn" {
+				return "CNY"
+			}
+			return "JPY"
+		}
+	}
+	return countryCurrencies[gl]
+}
+
+// isSymbolBoundary reports whether a letter-based symbol at s[idx:idx+n]
+// stands alone, so "kr" is not matched inside a word like "kredit".
+func isSymbolBoundary(s string, idx, n int) bool {
+	isLetter := func(r rune) bool { return unicode.IsLetter(r) }
+	first := []rune(s[idx : idx+n])[0]
+	if !isLetter(first) {
+		return true
+	}
+	if idx > 0 {
+		prev := []rune(s[:idx])
+		if isLetter(prev[len(prev)-1]) {
+			return false
+		}
+	}
+	if rest := []rune(s[idx+n:]); len(rest) > 0 && isLetter(rest[0]) {
+		return false
+	}
+	return true
+}
+
+func dollarCurrency(gl string) string {
+	switch gl {
+	case "ca", "au", "nz", "mx", "sg", "hk", "ar", "cl", "co":
+		return countryCurrencies[gl]
+	}
+	return "USD"
+}
+
+func kronaCurrency(gl string) string {
+	switch gl {
+	case "no", "dk", "is":
+		return countryCurrencies[gl]
+	}
+	return "SEK"
+}
+
+// containsAnyWord reports whether s contains any of words at word boundaries.
+func containsAnyWord(s string, words []string) bool {
+	for _, w := range words {
+		for from := 0; ; {
+			idx := strings.Index(s[from:], w)
+			if idx < 0 {
+				break
+			}
+			idx += from
+			if isSymbolBoundary(s, idx, len(w)) {
+				return true
+			}
+			from = idx + len(w)
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package serper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		gl, hl string
+		want   Price
+	}{
+		{
+			name: "us dollars with grouping",
+			raw:  "$1,299.99", gl: "us", hl: "en",
+			want: Price{Amount: 129999, MaxAmount: 129999, Currency: "USD"},
+		},
+		{
+			name: "euro decimal comma",
+			raw:  "€45,00", gl: "de", hl: "de",
+			want: Price{Amount: 4500, MaxAmount: 4500, Currency: "EUR"},
+		},
+		{
+			name: "german grouping dot resolved by hl",
+			raw:  "1.299 €", gl: "de", hl: "de",
+			want: Price{Amount: 129900, MaxAmount: 129900, Currency: "EUR"},
+		},
+		{
+			name: "english three decimals resolved by hl",
+			raw:  "£1.299", gl: "gb", hl: "en",
+			want: Price{Amount: 130, MaxAmount: 130, Currency: "GBP"},
+		},
+		{
+			name: "german full format",
+			raw:  "1.299,95 €", gl: "de", hl: "de-DE",
+			want: Price{Amount: 129995, MaxAmount: 129995, Currency: "EUR"},
+		},
+		{
+			name: "from price",
+			raw:  "From $20", gl: "us", hl: "en",
+			want: Price{Amount: 2000, MaxAmount: 2000, Currency: "USD", From: true},
+		},
+		{
+			name: "range",
+			raw:  "$10 - $25.50", gl: "us", hl: "en",
+			want: Price{Amount: 1000, MaxAmount: 2550, Currency: "USD", Range: true},
+		},
+		{
+			name: "sale with original",
+			raw:  "$19.99 was $29.99", gl: "us", hl: "en",
+			want: Price{Amount: 1999, MaxAmount: 1999, OriginalAmount: 2999, Currency: "USD", Sale: true},
+		},
+		{
+			name: "sale keyword only",
+			raw:  "Sale $5.00", gl: "us", hl: "en",
+			want: Price{Amount: 500, MaxAmount: 500, Currency: "USD", Sale: true},
+		},
+		{
+			name: "dollar resolved by country",
+			raw:  "$49.99", gl: "ca", hl: "en",
+			want: Price{Amount: 4999, MaxAmount: 4999, Currency: "CAD"},
+		},
+		{
+			name: "zero decimal currency",
+			raw:  "¥1,280", gl: "jp", hl: "ja",
+			want: Price{Amount: 1280, MaxAmount: 1280, Currency: "JPY"},
+		},
+		{
+			name: "iso code",
+			raw:  "CHF 89.90", gl: "ch", hl: "de",
+			want: Price{Amount: 8990, MaxAmount: 8990, Currency: "CHF"},
+		},
+		{
+			name: "krona by country",
+			raw:  "499 kr", gl: "no", hl: "no",
+			want: Price{Amount: 49900, MaxAmount: 49900, Currency: "NOK"},
+		},
+		{
+			name: "no symbol falls back to country",
+			raw:  "12.50", gl: "us", hl: "en",
+			want: Price{Amount: 1250, MaxAmount: 1250, Currency: "USD"},
+		},
+		{
+			name: "free",
+			raw:  "Free", gl: "us", hl: "en",
+			want: Price{Currency: "USD"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePrice(tt.raw, tt.gl, tt.hl)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tt.want.Raw = tt.raw
+			if got != tt.want {
+				t.Errorf("ParsePrice(%q):\n got  %+v\n want %+v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePrice_Errors(t *testing.T) {
+	for _, raw := range []string{"", "   ", "Call for price"} {
+		_, err := ParsePrice(raw, "us", "en")
+		if err == nil {
+			t.Errorf("ParsePrice(%q): expected error", raw)
+			continue
+		}
+		if !strings.Contains(err.Error(), "parse price") {
+			t.Errorf("error should mention 'parse price', got: %v", err)
+		}
+	}
+}
+
+func TestShoppingResponse_SortedByPrice(t *testing.T) {
+	resp := &ShoppingResponse{
+		SearchParameters: SearchParameters{GL: "us", HL: "en"},
+		Shopping: []ShoppingResult{
+			{Title: "mid", Price: "$20.00"},
+			{Title: "unknown", Price: "See website"},
+			{Title: "cheap", Price: "From $5"},
+			{Title: "dear", Price: "$1,000"},
+		},
+	}
+	got := resp.SortedByPrice()
+	var titles []string
+	for _, r := range got {
+		titles = append(titles, r.Title)
+	}
+	want := "cheap,mid,dear,unknown"
+	if strings.Join(titles, ",") != want {
+		t.Errorf("order: got %s, want %s", strings.Join(titles, ","), want)
+	}
+	if resp.Shopping[0].Title != "mid" {
+		t.Error("SortedByPrice should not modify the response")
+	}
+}
+
+func TestShoppingResponse_FilterByPrice(t *testing.T) {
+	resp := &ShoppingResponse{
+		SearchParameters: SearchParameters{GL: "us", HL: "en"},
+		Shopping: []ShoppingResult{
+			{Title: "a", Price: "$5.00"},
+			{Title: "b", Price: "$15.00"},
+			{Title: "c", Price: "€15,00"},
+			{Title: "d", Price: "$50.00"},
+			{Title: "e", Price: "n/a"},
+		},
+	}
+
+	got := resp.FilterByPrice("USD", 1000, 2000)
+	if len(got) != 1 || got[0].Title != "b" {
+		t.Errorf("USD 10-20: got %+v, want [b]", got)
+	}
+
+	got = resp.FilterByPrice("", 1000, 0)
+	if len(got) != 3 {
+		t.Errorf("any currency >= 10 with no max: got %d results, want 3", len(got))
+	}
+}
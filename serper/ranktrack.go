@@ -0,0 +1,544 @@
+package serper
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rank tracking defaults.
+const (
+	defaultRankDepth       = 100
+	defaultRankConcurrency = 4
+)
+
+// SearchPages fetches organic results for req page by page until
+// maxResults are collected, a page adds nothing new, or the budget runs
+// out. Pages are req.Num results long (default 10) and at most
+// ceil(maxResults/Num) pages are requested, each costing RequestCredits
+// unless it is served from the client's cache.
+// Results repeated across pages are dropped and positions are renumbered
+// 1..n over the whole list. If the budget runs out the results so far are
+// returned together with ErrCreditLimit.
+func (c *Client) SearchPages(ctx context.Context, req *SearchRequest, maxResults int, budget *CreditBudget) ([]OrganicResult, error) {
+	page, err := prepareRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if maxResults <= 0 {
+		maxResults = page.Num
+	}
+	pages := (maxResults + page.Num - 1) / page.Num
+
+	var out []OrganicResult
+	seen := make(map[string]bool)
+	for p := page.Page; p < page.Page+pages; p++ {
+		pageReq := *page
+		pageReq.Page = p
+		if err := c.reserveUncached(ctx, budget, "/search", &pageReq); err != nil {
+			return out, err
+		}
+		resp, err := c.Search(ctx, &pageReq)
+		if err != nil {
+			return out, err
+		}
+		added := 0
+		for _, r := range resp.Organic {
+			key := normalizeLink(r.Link)
+			if seen[key] || len(out) == maxResults {
+				continue
+			}
+			seen[key] = true
+			r.Position = len(out) + 1
+			out = append(out, r)
+			added++
+		}
+		if added == 0 || len(out) == maxResults {
+			break
+		}
+	}
+	return out, nil
+}
+
+// Market is a country, language and optional location to track ranks in.
+type Market struct {
+	GL       string `json:"gl"`
+	HL       string `json:"hl"`
+	Location string `json:"location,omitempty"`
+}
+
+// String returns a compact label such as "us-en" or "de-de/Berlin".
+func (m Market) String() string {
+	s := m.GL + "-" + m.HL
+	if m.Location != "" {
+		s += "/" + m.Location
+	}
+	return s
+}
+
+// RankTarget describes which result URLs count as a site. Domain matches
+// the host with or without "www."; Subdomains extends it to every host
+// below Domain; PathPrefix, if set, also requires the path to lie under it.
+type RankTarget struct {
+	Domain     string `json:"domain"`
+	Subdomains bool   `json:"subdomains,omitempty"`
+	PathPrefix string `json:"pathPrefix,omitempty"`
+}
+
+// ParseRankTarget parses a target written as "example.com",
+// "*.example.com" (any subdomain) or "example.com/blog" (path prefix).
+// A scheme, if present, is ignored. The host is lowercased; the path is
+// kept as written, since URL paths are case-sensitive.
+func ParseRankTarget(s string) (RankTarget, error) {
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+3:]
+	}
+	var t RankTarget
+	if rest, ok := strings.CutPrefix(s, "*."); ok {
+		t.Subdomains = true
+		s = rest
+	}
+	host, path, _ := strings.Cut(s, "/")
+	t.Domain = websiteHost(host)
+	if path != "" {
+		t.PathPrefix = "/" + strings.TrimSuffix(path, "/")
+	}
+	if t.Domain == "" || strings.ContainsAny(t.Domain, "*") {
+		return RankTarget{}, fmt.Errorf("serper: invalid rank target %q", s)
+	}
+	return t, nil
+}
+
+// String returns the target in the form accepted by ParseRankTarget. It is
+// used as the target's key in RankRecord.
+func (t RankTarget) String() string {
+	s := t.Domain + t.PathPrefix
+	if t.Subdomains {
+		s = "*." + s
+	}
+	return s
+}
+
+// Matches reports whether link belongs to the target.
+func (t RankTarget) Matches(link string) bool {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if !hostWithinDomain(websiteHost(u.Host), websiteHost(t.Domain), t.Subdomains) {
+		return false
+	}
+	return pathWithin(u.EscapedPath(), t.PathPrefix)
+}
+
+// RankTrackConfig configures a rank-tracking run.
+type RankTrackConfig struct {
+	Keywords    []string
+	Targets     []RankTarget
+	Markets     []Market      // default: us-en
+	Depth       int           // results scanned per keyword (default and max 100)
+	Concurrency int           // parallel keyword searches (default 4)
+	Budget      *CreditBudget // optional credit cap
+	Store       RankStore     // optional; the run's records are appended to it
+	Sink        Sink          // optional; receives EventRankTrackComplete
+}
+
+// RankRecord is the best position of one target for one keyword and
+// market at the time of a run. Position 0 means the target was not found
+// within the configured depth; Error is set if the search failed, in which
+// case the position is unknown.
+type RankRecord struct {
+	Time     time.Time `json:"time"`
+	Keyword  string    `json:"keyword"`
+	Market   Market    `json:"market"`
+	Target   string    `json:"target"`
+	Position int       `json:"position"`
+	URL      string    `json:"url,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// TrackRanks searches every keyword in every market for the top cfg.Depth
+// results and records the best position of each target. Each search asks
+// for all cfg.Depth results at once, which costs 2 credits for up to 100
+// results where paging by 10 would cost one per page. All records of a run
+// share one Time. Keywords that cannot be searched, including those
+// skipped once the budget is exhausted, are recorded with an Error rather
+// than failing the run; only invalid configuration, a cancelled context or
+// a store failure returns an error. If cfg.Sink is set the run's records
+// are sent to it; a failed notification is returned together with them.
+func (c *Client) TrackRanks(ctx context.Context, cfg RankTrackConfig) ([]RankRecord, error) {
+	if len(cfg.Keywords) == 0 {
+		return nil, fmt.Errorf("serper: rank tracking: no keywords")
+	}
+	if len(cfg.Targets) == 0 {
+		return nil, fmt.Errorf("serper: rank tracking: no targets")
+	}
+	if len(cfg.Markets) == 0 {
+		cfg.Markets = []Market{{}}
+	}
+	markets := make([]Market, len(cfg.Markets))
+	for i, m := range cfg.Markets {
+		// Spell out the API defaults so records key on the real market.
+		if m.GL == "" {
+			m.GL = "us"
+		}
+		if m.HL == "" {
+			m.HL = "en"
+		}
+		markets[i] = m
+	}
+	if cfg.Depth <= 0 || cfg.Depth > defaultRankDepth {
+		cfg.Depth = defaultRankDepth
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaultRankConcurrency
+	}
+
+	now := time.Now().UTC()
+	type job struct {
+		keyword string
+		market  Market
+		records []RankRecord
+	}
+	var jobs []*job
+	for _, m := range markets {
+		for _, kw := range cfg.Keywords {
+			jobs = append(jobs, &job{keyword: kw, market: m})
+		}
+	}
+
+	sem := make(chan struct{}, cfg.Concurrency)
+	var wg sync.WaitGroup
+	for _, j := range jobs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			// Depth is capped at 100, the most one request returns, so
+			// this is a single search.
+			req := &SearchRequest{Q: j.keyword, Num: cfg.Depth, GL: j.market.GL, HL: j.market.HL, Location: j.market.Location}
+			results, err := c.SearchPages(ctx, req, cfg.Depth, cfg.Budget)
+			for _, t := range cfg.Targets {
+				rec := RankRecord{Time: now, Keyword: j.keyword, Market: j.market, Target: t.String()}
+				// A match on the pages fetched before an error is still
+				// the target's true position.
+				if r, ok := firstMatch(t, results); ok {
+					rec.Position, rec.URL = r.Position, r.Link
+				} else if err != nil {
+					rec.Error = err.Error()
+				}
+				j.records = append(j.records, rec)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var records []RankRecord
+	for _, j := range jobs {
+		records = append(records, j.records...)
+	}
+	if cfg.Store != nil {
+		if err := cfg.Store.Append(ctx, records); err != nil {
+			return records, fmt.Errorf("serper: rank tracking: store: %w", err)
+		}
+	}
+	if err := Notify(ctx, cfg.Sink, EventRankTrackComplete, records); err != nil {
+		return records, fmt.Errorf("serper: rank tracking: notify: %w", err)
+	}
+	return records, nil
+}
+
+func firstMatch(t RankTarget, results []OrganicResult) (OrganicResult, bool) {
+	for _, r := range results {
+		if t.Matches(r.Link) {
+			return r, true
+		}
+	}
+	return OrganicResult{}, false
+}
+
+// RankStore keeps rank-tracking history. Implementations must be safe for
+// concurrent use.
+type RankStore interface {
+	Append(ctx context.Context, records []RankRecord) error
+	Records(ctx context.Context) ([]RankRecord, error)
+}
+
+// JSONLRankStore is a RankStore backed by a JSON Lines file.
+type JSONLRankStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewJSONLRankStore returns a store appending to the file at path.
+func NewJSONLRankStore(path string) *JSONLRankStore {
+	return &JSONLRankStore{path: path}
+}
+
+// Append writes records to the end of the file.
+func (s *JSONLRankStore) Append(_ context.Context, records []RankRecord) error {
+	var buf []byte
+	for _, r := range records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Records returns every stored record in file order. A missing file is an
+// empty history.
+func (s *JSONLRankStore) Records(_ context.Context) ([]RankRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []RankRecord
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var r RankRecord
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", s.path, line, err)
+		}
+		out = append(out, r)
+	}
+	return out, sc.Err()
+}
+
+// LatestRankRuns splits history into its two most recent runs, identified
+// by record Time. previous is nil if there is only one run.
+func LatestRankRuns(records []RankRecord) (previous, current []RankRecord) {
+	var times []time.Time
+	byTime := make(map[time.Time][]RankRecord)
+	for _, r := range records {
+		t := r.Time.UTC()
+		if _, ok := byTime[t]; !ok {
+			times = append(times, t)
+		}
+		byTime[t] = append(byTime[t], r)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	switch len(times) {
+	case 0:
+		return nil, nil
+	case 1:
+		return nil, byTime[times[0]]
+	}
+	return byTime[times[len(times)-2]], byTime[times[len(times)-1]]
+}
+
+// RankReport compares two rank-tracking runs.
+type RankReport struct {
+	From time.Time `json:"from,omitzero"`
+	To   time.Time `json:"to"`
+
+	Movers []RankMove `json:"movers,omitempty"` // ranked in both runs at different positions, biggest moves first
+	Gains  []RankMove `json:"gains,omitempty"`  // newly ranked within the tracked depth
+	Losses []RankMove `json:"losses,omitempty"` // no longer ranked within the tracked depth
+
+	Averages []RankAverage `json:"averages"`
+}
+
+// RankMove is a target's position change for one keyword and market.
+// Zero means not ranked.
+type RankMove struct {
+	Keyword string `json:"keyword"`
+	Market  Market `json:"market"`
+	Target  string `json:"target"`
+	From    int    `json:"from"`
+	To      int    `json:"to"`
+	URL     string `json:"url,omitempty"`
+}
+
+// Delta returns how many places the target climbed; negative if it fell.
+// It is zero for gains and losses.
+func (m RankMove) Delta() int {
+	if m.From == 0 || m.To == 0 {
+		return 0
+	}
+	return m.From - m.To
+}
+
+// RankAverage summarises a target in one market for the current run.
+type RankAverage struct {
+	Target   string  `json:"target"`
+	Market   Market  `json:"market"`
+	Keywords int     `json:"keywords"`          // keywords searched successfully
+	Ranked   int     `json:"ranked"`            // keywords where the target was found
+	Average  float64 `json:"average,omitempty"` // mean position over ranked keywords
+	Previous float64 `json:"previous,omitempty"`
+}
+
+// CompareRanks reports movers, gains and losses between two runs, and the
+// average position of each target per market in current. Records with an
+// Error are skipped: a failed search is neither a gain nor a loss.
+func CompareRanks(previous, current []RankRecord) *RankReport {
+	type key struct {
+		keyword, target string
+		market          Market
+	}
+	before := make(map[key]RankRecord, len(previous))
+	for _, r := range previous {
+		if r.Error == "" {
+			before[key{r.Keyword, r.Target, r.Market}] = r
+		}
+	}
+
+	rep := &RankReport{}
+	if len(previous) > 0 {
+		rep.From = previous[0].Time
+	}
+	if len(current) > 0 {
+		rep.To = current[0].Time
+	}
+	for _, r := range current {
+		if r.Error != "" {
+			continue
+		}
+		old, ok := before[key{r.Keyword, r.Target, r.Market}]
+		if !ok {
+			continue
+		}
+		m := RankMove{Keyword: r.Keyword, Market: r.Market, Target: r.Target, From: old.Position, To: r.Position, URL: r.URL}
+		switch {
+		case old.Position == 0 && r.Position > 0:
+			rep.Gains = append(rep.Gains, m)
+		case old.Position > 0 && r.Position == 0:
+			m.URL = old.URL
+			rep.Losses = append(rep.Losses, m)
+		case old.Position != r.Position:
+			rep.Movers = append(rep.Movers, m)
+		}
+	}
+	sort.SliceStable(rep.Movers, func(i, j int) bool {
+		return abs(rep.Movers[i].Delta()) > abs(rep.Movers[j].Delta())
+	})
+
+	rep.Averages = rankAverages(current)
+	prevAverages := make(map[string]float64)
+	for _, a := range rankAverages(previous) {
+		prevAverages[a.Target+"\x00"+a.Market.String()] = a.Average
+	}
+	for i := range rep.Averages {
+		a := &rep.Averages[i]
+		a.Previous = prevAverages[a.Target+"\x00"+a.Market.String()]
+	}
+	return rep
+}
+
+// rankAverages computes RankAverage per target and market, in first-seen
+// order.
+func rankAverages(records []RankRecord) []RankAverage {
+	var out []RankAverage
+	index := make(map[string]int)
+	sums := make(map[string]int)
+	for _, r := range records {
+		if r.Error != "" {
+			continue
+		}
+		k := r.Target + "\x00" + r.Market.String()
+		i, ok := index[k]
+		if !ok {
+			i = len(out)
+			index[k] = i
+			out = append(out, RankAverage{Target: r.Target, Market: r.Market})
+		}
+		out[i].Keywords++
+		if r.Position > 0 {
+			out[i].Ranked++
+			sums[k] += r.Position
+		}
+	}
+	for k, i := range index {
+		if out[i].Ranked > 0 {
+			out[i].Average = float64(sums[k]) / float64(out[i].Ranked)
+		}
+	}
+	return out
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Text renders the report for terminals and emails.
+func (r *RankReport) Text() string {
+	var b strings.Builder
+	if !r.From.IsZero() {
+		fmt.Fprintf(&b, "Ranks %s -> %s\n", r.From.Format(time.DateOnly), r.To.Format(time.DateOnly))
+	} else {
+		fmt.Fprintf(&b, "Ranks %s (first run)\n", r.To.Format(time.DateOnly))
+	}
+	moves := func(title string, ms []RankMove) {
+		if len(ms) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s:\n", title)
+		for _, m := range ms {
+			fmt.Fprintf(&b, "  %-30s %-10s %-20s %s -> %s", m.Keyword, m.Market, m.Target, positionCell(m.From), positionCell(m.To))
+			if d := m.Delta(); d != 0 {
+				fmt.Fprintf(&b, " (%+d)", d)
+			}
+			b.WriteString("\n")
+		}
+	}
+	moves("Movers", r.Movers)
+	moves("Gains", r.Gains)
+	moves("Losses", r.Losses)
+	if len(r.Averages) > 0 {
+		b.WriteString("\nAverage position:\n")
+		for _, a := range r.Averages {
+			fmt.Fprintf(&b, "  %-20s %-10s ranked %d/%d", a.Target, a.Market, a.Ranked, a.Keywords)
+			if a.Ranked > 0 {
+				fmt.Fprintf(&b, ", avg %.1f", a.Average)
+			}
+			if a.Previous > 0 {
+				fmt.Fprintf(&b, " (was %.1f)", a.Previous)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
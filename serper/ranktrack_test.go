@@ -0,0 +1,284 @@
+package serper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseRankTarget(t *testing.T) {
+	tests := []struct {
+		in   string
+		want RankTarget
+	}{
+		{"example.com", RankTarget{Domain: "example.com"}},
+		{"https://www.Example.com/", RankTarget{Domain: "example.com"}},
+		{"*.example.com", RankTarget{Domain: "example.com", Subdomains: true}},
+		{"example.com/blog/", RankTarget{Domain: "example.com", PathPrefix: "/blog"}},
+		{"*.example.co.uk/docs", RankTarget{Domain: "example.co.uk", Subdomains: true, PathPrefix: "/docs"}},
+		{"HTTPS://Example.COM/Blog", RankTarget{Domain: "example.com", PathPrefix: "/Blog"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRankTarget(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseRankTarget(%q): got %+v, %v, want %+v", tt.in, got, err, tt.want)
+		}
+		if again, _ := ParseRankTarget(got.String()); again != got {
+			t.Errorf("String round trip for %q: got %+v", tt.in, again)
+		}
+	}
+	for _, bad := range []string{"", "*.", "/path"} {
+		if _, err := ParseRankTarget(bad); err == nil {
+			t.Errorf("ParseRankTarget(%q): expected error", bad)
+		}
+	}
+}
+
+func TestRankTarget_Matches(t *testing.T) {
+	tests := []struct {
+		target, link string
+		want         bool
+	}{
+		{"example.com", "https://example.com/a", true},
+		{"example.com", "https://www.example.com/a", true},
+		{"example.com", "https://blog.example.com/a", false},
+		{"example.com", "https://notexample.com/", false},
+		{"*.example.com", "https://blog.example.com/a", true},
+		{"*.example.com", "https://example.com/", true},
+		{"*.example.com", "https://badexample.com/", false},
+		{"example.com/docs", "https://example.com/docs", true},
+		{"example.com/docs", "https://example.com/docs/intro", true},
+		{"example.com/docs", "https://example.com/docsy", false},
+		{"example.com/docs", "https://example.com/", false},
+		{"Example.com/Blog", "https://EXAMPLE.com/Blog/post", true},
+		{"example.com/Blog", "https://example.com/blog/post", false},
+		{"example.com", "not a url", false},
+	}
+	for _, tt := range tests {
+		target, _ := ParseRankTarget(tt.target)
+		if got := target.Matches(tt.link); got != tt.want {
+			t.Errorf("%s matches %s: got %v, want %v", tt.target, tt.link, got, tt.want)
+		}
+	}
+}
+
+// pagedDoer serves organic results for a query across pages. pages maps a
+// query to the links of each page; every request is counted.
+type pagedDoer struct {
+	mu    sync.Mutex
+	pages map[string][][]string
+	calls map[string]int
+}
+
+func (d *pagedDoer) Do(req *http.Request) (*http.Response, error) {
+	var sent SearchRequest
+	body, _ := io.ReadAll(req.Body)
+	if err := json.Unmarshal(body, &sent); err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.calls == nil {
+		d.calls = make(map[string]int)
+	}
+	d.calls[sent.Q+"/"+sent.GL]++
+	var organic []OrganicResult
+	if pages := d.pages[sent.Q]; sent.Page <= len(pages) {
+		for i, link := range pages[sent.Page-1] {
+			organic = append(organic, OrganicResult{Title: link, Link: link, Position: i + 1})
+		}
+	}
+	out, _ := json.Marshal(SearchResponse{Organic: organic})
+	return jsonResponse(string(out)), nil
+}
+
+func TestSearchPages(t *testing.T) {
+	doer := &pagedDoer{pages: map[string][][]string{
+		"q": {
+			{"https://a.example/", "https://b.example/"},
+			{"https://b.example/", "https://c.example/", "https://d.example/"},
+			{"https://e.example/"},
+		},
+	}}
+	c := mustNew(t, "key", WithDoer(doer))
+
+	results, err := c.SearchPages(context.Background(), &SearchRequest{Q: "q", Num: 3}, 4, nil)
+	if err != nil {
+		t.Fatalf("SearchPages: %v", err)
+	}
+	var got []string
+	for _, r := range results {
+		got = append(got, fmt.Sprintf("%d:%s", r.Position, strings.TrimSuffix(strings.TrimPrefix(r.Link, "https://"), ".example/")))
+	}
+	if strings.Join(got, " ") != "1:a 2:b 3:c 4:d" {
+		t.Errorf("results: got %v", got)
+	}
+	if doer.calls["q/us"] != 2 {
+		t.Errorf("requests: got %d, want 2", doer.calls["q/us"])
+	}
+
+	budget := NewCreditBudget(1)
+	results, err = c.SearchPages(context.Background(), &SearchRequest{Q: "q", Num: 3}, 9, budget)
+	if err != ErrCreditLimit || len(results) != 2 {
+		t.Errorf("with budget: got %d results, err %v", len(results), err)
+	}
+}
+
+func TestSearchPages_CachedPagesAreFree(t *testing.T) {
+	doer := &pagedDoer{pages: map[string][][]string{
+		"q": {{"https://a.example/"}, {"https://b.example/"}},
+	}}
+	c := mustNew(t, "key", WithDoer(doer), WithCache(NewMemoryCache(time.Minute, 10)))
+	req := &SearchRequest{Q: "q", Num: 1}
+	if _, err := c.SearchPages(context.Background(), req, 2, nil); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+
+	budget := NewCreditBudget(1)
+	results, err := c.SearchPages(context.Background(), req, 2, budget)
+	if err != nil || len(results) != 2 {
+		t.Fatalf("cached run: got %d results, err %v", len(results), err)
+	}
+	if budget.Spent() != 0 || doer.calls["q/us"] != 2 {
+		t.Errorf("spent=%d requests=%d, want 0/2", budget.Spent(), doer.calls["q/us"])
+	}
+}
+
+func TestTrackRanks(t *testing.T) {
+	doer := &pagedDoer{pages: map[string][][]string{
+		"coffee": {
+			{"https://rival.example/", "https://shop.example.com/beans", "https://blog.example.com/", "https://other.example/"},
+		},
+		"tea": {
+			{"https://rival.example/", "https://example.com/"},
+		},
+	}}
+	c := mustNew(t, "key", WithDoer(doer))
+	budget := NewCreditBudget(0)
+	store := NewJSONLRankStore(filepath.Join(t.TempDir(), "ranks.jsonl"))
+	shop, _ := ParseRankTarget("example.com/beans")
+	anySub, _ := ParseRankTarget("*.example.com")
+
+	records, err := c.TrackRanks(context.Background(), RankTrackConfig{
+		Keywords: []string{"coffee", "tea"},
+		Targets:  []RankTarget{shop, anySub},
+		Markets:  []Market{{GL: "us"}, {GL: "de", HL: "de"}},
+		Depth:    20,
+		Budget:   budget,
+		Store:    store,
+	})
+	if err != nil {
+		t.Fatalf("TrackRanks: %v", err)
+	}
+	if len(records) != 8 {
+		t.Fatalf("records: got %d, want 8", len(records))
+	}
+	byKey := make(map[string]RankRecord)
+	for _, r := range records {
+		byKey[r.Keyword+"|"+r.Market.String()+"|"+r.Target] = r
+	}
+	if r := byKey["coffee|us-en|*.example.com"]; r.Position != 2 || r.URL != "https://shop.example.com/beans" {
+		t.Errorf("coffee subdomain target: got %+v", r)
+	}
+	if r := byKey["coffee|us-en|example.com/beans"]; r.Position != 0 {
+		t.Errorf("path target must not match a subdomain: got %+v", r)
+	}
+	if r := byKey["tea|de-de|*.example.com"]; r.Position != 2 {
+		t.Errorf("tea: got %+v", r)
+	}
+	// One num=20 search per keyword and market, at 2 credits each.
+	if doer.calls["coffee/us"] != 1 || doer.calls["tea/de"] != 1 || budget.Spent() != 8 {
+		t.Errorf("requests: got %v, %d credits", doer.calls, budget.Spent())
+	}
+
+	stored, err := store.Records(context.Background())
+	if err != nil || len(stored) != 8 {
+		t.Errorf("stored records: got %d, %v", len(stored), err)
+	}
+}
+
+func TestTrackRanks_SingleSearchAndSink(t *testing.T) {
+	var sent []SearchRequest
+	doer := doerFunc(func(req *http.Request) (*http.Response, error) {
+		var r SearchRequest
+		body, _ := io.ReadAll(req.Body)
+		_ = json.Unmarshal(body, &r)
+		sent = append(sent, r)
+		return jsonResponse(`{"organic":[{"link":"https://other.example/"}]}`), nil
+	})
+	var events []Event
+	sink := SinkFunc(func(_ context.Context, e Event) error {
+		events = append(events, e)
+		return nil
+	})
+	c := mustNew(t, "key", WithDoer(doer))
+	target, _ := ParseRankTarget("example.com")
+	budget := NewCreditBudget(0)
+	records, err := c.TrackRanks(context.Background(), RankTrackConfig{Keywords: []string{"coffee"}, Targets: []RankTarget{target}, Budget: budget, Sink: sink})
+	if err != nil {
+		t.Fatalf("TrackRanks: %v", err)
+	}
+	if len(sent) != 1 || sent[0].Num != 100 || budget.Spent() != 2 {
+		t.Errorf("requests: got %+v, %d credits; want one num=100 search for 2 credits", sent, budget.Spent())
+	}
+	if len(events) != 1 || events[0].Type != EventRankTrackComplete || len(events[0].Data.([]RankRecord)) != len(records) {
+		t.Errorf("events: got %+v", events)
+	}
+
+	failing := SinkFunc(func(context.Context, Event) error { return errors.New("receiver down") })
+	records, err = c.TrackRanks(context.Background(), RankTrackConfig{Keywords: []string{"coffee"}, Targets: []RankTarget{target}, Sink: failing})
+	if err == nil || len(records) != 1 {
+		t.Errorf("failed notification: got %d records, %v", len(records), err)
+	}
+}
+
+func TestCompareRanks(t *testing.T) {
+	t1 := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.AddDate(0, 0, 7)
+	us := Market{GL: "us", HL: "en"}
+	rec := func(at time.Time, kw string, pos int) RankRecord {
+		return RankRecord{Time: at, Keyword: kw, Market: us, Target: "example.com", Position: pos}
+	}
+	history := []RankRecord{
+		rec(t1, "a", 5), rec(t1, "b", 3), rec(t1, "c", 0), rec(t1, "d", 8), rec(t1, "e", 4),
+		rec(t2, "a", 2), rec(t2, "b", 4), rec(t2, "c", 6), rec(t2, "d", 0),
+		{Time: t2, Keyword: "e", Market: us, Target: "example.com", Error: "boom"},
+	}
+	prev, curr := LatestRankRuns(append(history, rec(t1.AddDate(0, 0, -7), "a", 9)))
+	if len(prev) != 5 || len(curr) != 5 {
+		t.Fatalf("runs: got %d and %d records", len(prev), len(curr))
+	}
+
+	rep := CompareRanks(prev, curr)
+	if len(rep.Movers) != 2 || rep.Movers[0].Keyword != "a" || rep.Movers[0].Delta() != 3 || rep.Movers[1].Delta() != -1 {
+		t.Errorf("movers: got %+v", rep.Movers)
+	}
+	if len(rep.Gains) != 1 || rep.Gains[0].Keyword != "c" {
+		t.Errorf("gains: got %+v", rep.Gains)
+	}
+	if len(rep.Losses) != 1 || rep.Losses[0].Keyword != "d" {
+		t.Errorf("losses: got %+v (a failed search is not a loss)", rep.Losses)
+	}
+	if len(rep.Averages) != 1 {
+		t.Fatalf("averages: got %+v", rep.Averages)
+	}
+	avg := rep.Averages[0]
+	if avg.Keywords != 4 || avg.Ranked != 3 || avg.Average != 4 || avg.Previous != 5 {
+		t.Errorf("average: got %+v", avg)
+	}
+
+	text := rep.Text()
+	for _, want := range []string{"Ranks 2026-03-01 -> 2026-03-08", "#5 -> #2 (+3)", "Losses:", "ranked 3/4, avg 4.0 (was 5.0)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text should contain %q:\n%s", want, text)
+		}
+	}
+}
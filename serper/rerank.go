@@ -0,0 +1,207 @@
+package serper
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Ranker scores an organic result for Rerank; higher scores rank first.
+// The built-in rankers score from 0 to 1 so their weights in Weighted are
+// comparable.
+type Ranker interface {
+	Score(r OrganicResult) float64
+}
+
+// RankerFunc adapts a function to the Ranker interface.
+type RankerFunc func(r OrganicResult) float64
+
+// Score calls f(r).
+func (f RankerFunc) Score(r OrganicResult) float64 { return f(r) }
+
+// DomainWeight gives results matching Rule a fixed score.
+type DomainWeight struct {
+	Rule   DomainRule
+	Weight float64
+}
+
+// DomainWeights scores a result by the first rule its link matches, or 0
+// if none does, so list specific rules before general ones. Negative
+// weights demote.
+type DomainWeights []DomainWeight
+
+// Score returns the weight of the first rule matching r.Link.
+func (w DomainWeights) Score(r OrganicResult) float64 {
+	for _, dw := range w {
+		if dw.Rule.Matches(r.Link) {
+			return dw.Weight
+		}
+	}
+	return 0
+}
+
+// ParseDomainWeights parses one "rule weight" pair per entry, such as
+// "*.gov 1" or "/spam[0-9]*\./ -1", with rules as accepted by
+// ParseDomainRule. Blank entries and those starting with "#" are skipped.
+func ParseDomainWeights(lines []string) (DomainWeights, error) {
+	var out DomainWeights
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexAny(line, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("serper: domain weight %q: want \"rule weight\"", line)
+		}
+		weight, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			return nil, fmt.Errorf("serper: domain weight %q: %w", line, err)
+		}
+		rule, err := ParseDomainRule(line[:i])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, DomainWeight{Rule: rule, Weight: weight})
+	}
+	return out, nil
+}
+
+const (
+	defaultRecencyHalfLife = 30 * 24 * time.Hour
+	defaultSnippetTarget   = 160
+)
+
+// Recency scores a result by the age of its Date, halving every HalfLife
+// (default 30 days): 1 for a result dated now, 0.5 for one HalfLife old.
+// Results without a date Google shows, or with one ParseResultDate cannot
+// read, score 0.
+type Recency struct {
+	Now      time.Time     // reference time (default time.Now())
+	HalfLife time.Duration // default 30 days
+}
+
+// Score returns the decayed age of r.Date.
+func (rc Recency) Score(r OrganicResult) float64 {
+	now, halfLife := rc.Now, rc.HalfLife
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if halfLife <= 0 {
+		halfLife = defaultRecencyHalfLife
+	}
+	t, ok := ParseResultDate(r.Date, now)
+	if !ok {
+		return 0
+	}
+	age := now.Sub(t)
+	if age <= 0 {
+		return 1
+	}
+	return math.Exp2(-float64(age) / float64(halfLife))
+}
+
+// TermOverlap returns a ranker scoring the share of the query's words that
+// appear in a result: a word in the title counts fully, one only in the
+// snippet counts half. Case, punctuation and stop words are ignored. A
+// query with no words scores every result 0.
+func TermOverlap(query string) Ranker {
+	terms := make(map[string]bool)
+	for _, w := range simhashTokens(query) {
+		terms[w] = true
+	}
+	return RankerFunc(func(r OrganicResult) float64 {
+		if len(terms) == 0 {
+			return 0
+		}
+		inTitle := make(map[string]bool)
+		for _, w := range simhashTokens(r.Title) {
+			inTitle[w] = true
+		}
+		inSnippet := make(map[string]bool)
+		for _, w := range simhashTokens(r.Snippet) {
+			inSnippet[w] = true
+		}
+		var hits float64
+		for w := range terms {
+			switch {
+			case inTitle[w]:
+				hits++
+			case inSnippet[w]:
+				hits += 0.5
+			}
+		}
+		return hits / float64(len(terms))
+	})
+}
+
+// SnippetLength scores a result by how much text its snippet carries, in
+// characters, up to Target (default 160) where it scores 1. It favours
+// results Google could summarise over bare links.
+type SnippetLength struct {
+	Target int
+}
+
+// Score returns the snippet length as a fraction of Target, at most 1.
+func (s SnippetLength) Score(r OrganicResult) float64 {
+	target := s.Target
+	if target <= 0 {
+		target = defaultSnippetTarget
+	}
+	return math.Min(float64(utf8.RuneCountInString(strings.TrimSpace(r.Snippet)))/float64(target), 1)
+}
+
+// WeightedRanker is a Ranker and its weight in Weighted.
+type WeightedRanker struct {
+	Ranker Ranker
+	Weight float64
+}
+
+// Weighted combines rankers into one scoring the weighted sum of their
+// scores.
+type Weighted []WeightedRanker
+
+// Score returns the weighted sum of the rankers' scores for r.
+func (w Weighted) Score(r OrganicResult) float64 {
+	var sum float64
+	for _, wr := range w {
+		sum += wr.Weight * wr.Ranker.Score(r)
+	}
+	return sum
+}
+
+// RankedResult is an organic result in a Rerank ordering.
+type RankedResult struct {
+	OrganicResult
+	// OriginalPosition is the result's position in Google's order; the
+	// embedded Position is its place in the new order.
+	OriginalPosition int     `json:"originalPosition"`
+	Score            float64 `json:"score"`
+}
+
+// Rerank orders results by r's score, highest first, and renumbers their
+// positions from 1. Ties keep Google's order. Results Serper left without
+// a position are given their place in the input. results is not modified.
+func Rerank(results []OrganicResult, r Ranker) []RankedResult {
+	out := make([]RankedResult, len(results))
+	for i, res := range results {
+		if res.Position <= 0 {
+			res.Position = i + 1
+		}
+		out[i] = RankedResult{OrganicResult: res, OriginalPosition: res.Position, Score: r.Score(res)}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].OriginalPosition < out[j].OriginalPosition
+	})
+	for i := range out {
+		out[i].Position = i + 1
+	}
+	return out
+}
@@ -0,0 +1,132 @@
+package serper
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDomainWeights(t *testing.T) {
+	w, err := ParseDomainWeights([]string{"# trusted", "docs.example.com 2", "*.example.com 1", "/spam[0-9]*\\./ -1", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		link string
+		want float64
+	}{
+		{"https://docs.example.com/a", 2},
+		{"https://www.example.com/", 1},
+		{"https://spam7.net/", -1},
+		{"https://other.org/", 0},
+	}
+	for _, tt := range tests {
+		if got := w.Score(OrganicResult{Link: tt.link}); got != tt.want {
+			t.Errorf("Score(%q) = %v, want %v", tt.link, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"example.com", "example.com high", "exa*mple.com 1"} {
+		if _, err := ParseDomainWeights([]string{bad}); err == nil {
+			t.Errorf("ParseDomainWeights(%q): expected error", bad)
+		}
+	}
+}
+
+func TestRecency(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	rc := Recency{Now: now, HalfLife: 24 * time.Hour}
+	tests := []struct {
+		date string
+		want float64
+	}{
+		{"just now", 1},
+		{"1 day ago", 0.5},
+		{"2 days ago", 0.25},
+		{"", 0},
+		{"sometime", 0},
+	}
+	for _, tt := range tests {
+		if got := rc.Score(OrganicResult{Date: tt.date}); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Score(%q) = %v, want %v", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestTermOverlap(t *testing.T) {
+	r := TermOverlap("The best espresso grinder?")
+	tests := []struct {
+		title, snippet string
+		want           float64
+	}{
+		{"Best Espresso Grinder of 2024", "", 1},
+		{"Espresso guide", "Pick the best grinder.", 2.0 / 3},
+		{"Tea", "Nothing relevant", 0},
+	}
+	for _, tt := range tests {
+		if got := r.Score(OrganicResult{Title: tt.title, Snippet: tt.snippet}); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Score(%q, %q) = %v, want %v", tt.title, tt.snippet, got, tt.want)
+		}
+	}
+	if got := TermOverlap("the").Score(OrganicResult{Title: "the"}); got != 0 {
+		t.Errorf("stop-word query: got %v, want 0", got)
+	}
+}
+
+func TestSnippetLength(t *testing.T) {
+	s := SnippetLength{Target: 10}
+	if got := s.Score(OrganicResult{Snippet: "  héllo  "}); got != 0.5 {
+		t.Errorf("short snippet: got %v, want 0.5", got)
+	}
+	if got := s.Score(OrganicResult{Snippet: "a snippet well past the target"}); got != 1 {
+		t.Errorf("long snippet: got %v, want 1", got)
+	}
+	if got := (SnippetLength{}).Score(OrganicResult{Snippet: "x"}); got != 1.0/160 {
+		t.Errorf("default target: got %v", got)
+	}
+}
+
+func TestRerank(t *testing.T) {
+	results := []OrganicResult{
+		{Title: "Espresso at home", Link: "https://blog.net/a", Position: 1},
+		{Title: "Espresso", Link: "https://trusted.org/b", Position: 2},
+		{Title: "Unrelated", Link: "https://trusted.org/c"},
+		{Title: "Espresso", Link: "https://other.com/d", Position: 4},
+	}
+	ranker := Weighted{
+		{Ranker: DomainWeights{{Rule: mustRules(t, "trusted.org")[0], Weight: 1}}, Weight: 2},
+		{Ranker: TermOverlap("espresso"), Weight: 1},
+	}
+	got := Rerank(results, ranker)
+
+	// trusted.org/b: 2+1; trusted.org/c: 2; blog.net/a and other.com/d: 1,
+	// tied and kept in Google's order.
+	want := []struct {
+		link     string
+		original int
+		score    float64
+	}{
+		{"https://trusted.org/b", 2, 3},
+		{"https://trusted.org/c", 3, 2},
+		{"https://blog.net/a", 1, 1},
+		{"https://other.com/d", 4, 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d results, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Link != w.link || g.OriginalPosition != w.original || g.Score != w.score || g.Position != i+1 {
+			t.Errorf("result %d: got %s orig=%d pos=%d score=%v, want %s orig=%d pos=%d score=%v",
+				i, g.Link, g.OriginalPosition, g.Position, g.Score, w.link, w.original, i+1, w.score)
+		}
+	}
+	if results[2].Position != 0 || results[0].Position != 1 {
+		t.Error("Rerank modified its input")
+	}
+
+	custom := Rerank(results, RankerFunc(func(r OrganicResult) float64 { return float64(len(r.Title)) }))
+	if custom[0].Title != "Espresso at home" {
+		t.Errorf("RankerFunc: got %q first", custom[0].Title)
+	}
+}
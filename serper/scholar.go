@@ -0,0 +1,112 @@
+package serper
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PublicationDetails is the structured form of ScholarResult.PublicationInfo.
+type PublicationDetails struct {
+	Authors   []string `json:"authors,omitempty"`
+	Venue     string   `json:"venue,omitempty"`     // journal, conference or preprint server
+	Year      int      `json:"year,omitempty"`      // 0 if absent
+	Publisher string   `json:"publisher,omitempty"` // publisher name or domain, e.g. "nature.com"
+}
+
+var (
+	pubYearRe     = regexp.MustCompile(`\b(1[5-9]\d{2}|20\d{2})\b`)
+	pubSectionSep = regexp.MustCompile(`\s+[-–—]\s+`)
+)
+
+// ParsePublicationInfo splits a Scholar publication line such as
+// "J Smith, A Doe - Nature, 2021 - nature.com" into authors, venue, year
+// and publisher. Missing sections are left empty; truncation marks ("…")
+// are removed.
+func ParsePublicationInfo(s string) PublicationDetails {
+	var d PublicationDetails
+	s = strings.TrimSpace(strings.ReplaceAll(s, "\u00a0", " "))
+	if s == "" {
+		return d
+	}
+	parts := pubSectionSep.Split(s, -1)
+
+	// The authors always come first. When there are only two sections, the
+	// second is the venue/year if it carries a year and the publisher otherwise.
+	d.Authors = parseAuthors(parts[0])
+	switch len(parts) {
+	case 1:
+		if len(d.Authors) == 1 && pubYearRe.MatchString(parts[0]) {
+			d.Authors = nil
+			d.Venue, d.Year = parseVenueYear(parts[0])
+		}
+	case 2:
+		if pubYearRe.MatchString(parts[1]) {
+			d.Venue, d.Year = parseVenueYear(parts[1])
+		} else {
+			d.Publisher = trimEllipsis(parts[1])
+		}
+	default:
+		d.Venue, d.Year = parseVenueYear(strings.Join(parts[1:len(parts)-1], " - "))
+		d.Publisher = trimEllipsis(parts[len(parts)-1])
+	}
+	return d
+}
+
+// FillPublicationInfo parses PublicationInfo and fills Authors, Year, Venue
+// and Publisher where Serper left them empty. Existing values are kept.
+func (r *ScholarResult) FillPublicationInfo() {
+	d := ParsePublicationInfo(r.PublicationInfo)
+	if len(r.Authors) == 0 {
+		r.Authors = d.Authors
+	}
+	if r.Year == 0 {
+		r.Year = d.Year
+	}
+	if r.Venue == "" {
+		r.Venue = d.Venue
+	}
+	if r.Publisher == "" {
+		r.Publisher = d.Publisher
+	}
+}
+
+// FillPublicationInfo applies ScholarResult.FillPublicationInfo to every result.
+func (r *ScholarResponse) FillPublicationInfo() {
+	for i := range r.Organic {
+		r.Organic[i].FillPublicationInfo()
+	}
+}
+
+func parseAuthors(s string) []string {
+	var authors []string
+	for _, a := range strings.Split(s, ",") {
+		if a = trimEllipsis(a); a != "" {
+			authors = append(authors, a)
+		}
+	}
+	return authors
+}
+
+// parseVenueYear splits "Nature, 2021" into ("Nature", 2021). The last
+// year-like number is taken as the year.
+func parseVenueYear(s string) (string, int) {
+	s = trimEllipsis(s)
+	locs := pubYearRe.FindAllStringIndex(s, -1)
+	if len(locs) == 0 {
+		return s, 0
+	}
+	last := locs[len(locs)-1]
+	year, _ := strconv.Atoi(s[last[0]:last[1]])
+	venue := strings.TrimSpace(s[:last[0]] + s[last[1]:])
+	venue = trimEllipsis(strings.Trim(venue, ", "))
+	return venue, year
+}
+
+func trimEllipsis(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(s, "…")
+	s = strings.TrimSuffix(s, "...")
+	s = strings.TrimPrefix(s, "…")
+	return strings.TrimSpace(s)
+}
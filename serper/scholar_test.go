@@ -0,0 +1,88 @@
+package serper
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParsePublicationInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want PublicationDetails
+	}{
+		{
+			name: "full",
+			in:   "J Smith, A Doe - Nature, 2021 - nature.com",
+			want: PublicationDetails{Authors: []string{"J Smith", "A Doe"}, Venue: "Nature", Year: 2021, Publisher: "nature.com"},
+		},
+		{
+			name: "truncated authors and venue",
+			in:   "J Smith, A Doe, B Lee… - Proceedings of the IEEE …, 2019 - ieeexplore.ieee.org",
+			want: PublicationDetails{Authors: []string{"J Smith", "A Doe", "B Lee"}, Venue: "Proceedings of the IEEE", Year: 2019, Publisher: "ieeexplore.ieee.org"},
+		},
+		{
+			name: "year only",
+			in:   "K Turing - 1950 - Springer",
+			want: PublicationDetails{Authors: []string{"K Turing"}, Year: 1950, Publisher: "Springer"},
+		},
+		{
+			name: "venue with year",
+			in:   "M Chen - arXiv preprint arXiv:2107.03374, 2021",
+			want: PublicationDetails{Authors: []string{"M Chen"}, Venue: "arXiv preprint arXiv:2107.03374", Year: 2021},
+		},
+		{
+			name: "publisher without year",
+			in:   "R Roe - books.google.com",
+			want: PublicationDetails{Authors: []string{"R Roe"}, Publisher: "books.google.com"},
+		},
+		{
+			name: "authors only",
+			in:   "A Author, B Author",
+			want: PublicationDetails{Authors: []string{"A Author", "B Author"}},
+		},
+		{
+			name: "empty",
+			in:   "",
+			want: PublicationDetails{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParsePublicationInfo(tt.in)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePublicationInfo(%q):\n got  %+v\n want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScholarResult_FillPublicationInfo_KeepsExisting(t *testing.T) {
+	r := ScholarResult{
+		PublicationInfo: "J Smith - Nature, 2021 - nature.com",
+		Authors:         []string{"Jane Smith"},
+	}
+	r.FillPublicationInfo()
+	if !reflect.DeepEqual(r.Authors, []string{"Jane Smith"}) {
+		t.Errorf("Authors should be kept, got %v", r.Authors)
+	}
+	if r.Year != 2021 || r.Venue != "Nature" || r.Publisher != "nature.com" {
+		t.Errorf("empty fields should be filled, got year=%d venue=%q publisher=%q", r.Year, r.Venue, r.Publisher)
+	}
+}
+
+func TestScholar_FillsPublicationInfo(t *testing.T) {
+	respJSON := `{"organic": [{"title": "Paper", "publicationInfo": "J Smith, A Doe - Nature, 2021 - nature.com", "position": 1}]}`
+	mock := &mockDoer{statusCode: 200, respBody: respJSON}
+	c := mustNew(t, "key", WithDoer(mock))
+
+	resp, err := c.Scholar(context.Background(), &SearchRequest{Q: "paper"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := resp.Organic[0]
+	if len(got.Authors) != 2 || got.Year != 2021 || got.Venue != "Nature" {
+		t.Errorf("structured fields not filled: %+v", got)
+	}
+}
@@ -0,0 +1,249 @@
+package serper
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Event types sent to a Sink.
+const (
+	EventWatchNewItems     = "watch.new_items"     // Data is []WatchItem
+	EventGridScanComplete  = "gridscan.completed"  // Data is *GridScanResult
+	EventRankTrackComplete = "ranktrack.completed" // Data is []RankRecord
+	EventClusterComplete   = "cluster.completed"   // Data is *KeywordClusterResult
+	EventExpandComplete    = "expand.completed"    // Data is *QueryGraph
+	EventSuggestComplete   = "suggest.completed"   // Data is *SuggestResult
+	EventSOVComplete       = "sov.completed"       // Data is *ShareOfVoiceReport
+	EventFeaturesComplete  = "features.completed"  // Data is *FeatureReport
+)
+
+// Event is a notification from a long-running job.
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data"`
+}
+
+// Sink receives events from watchers and batch jobs. Implementations must
+// be safe for concurrent use.
+type Sink interface {
+	Send(ctx context.Context, e Event) error
+}
+
+// SinkFunc adapts a function to the Sink interface.
+type SinkFunc func(ctx context.Context, e Event) error
+
+// Send calls f(ctx, e).
+func (f SinkFunc) Send(ctx context.Context, e Event) error { return f(ctx, e) }
+
+// Notify sends an event of eventType carrying data to s, if s is set.
+// Batch jobs use it to report their result when they finish.
+func Notify(ctx context.Context, s Sink, eventType string, data any) error {
+	if s == nil {
+		return nil
+	}
+	return s.Send(ctx, Event{Type: eventType, Time: time.Now().UTC(), Data: data})
+}
+
+// Webhook delivery headers.
+const (
+	WebhookSignatureHeader = "X-Serper-Signature"
+	WebhookTimestampHeader = "X-Serper-Timestamp"
+	WebhookEventHeader     = "X-Serper-Event"
+	WebhookDeliveryHeader  = "X-Serper-Delivery"
+)
+
+// Webhook delivery defaults.
+const (
+	defaultWebhookAttempts = 4
+	defaultWebhookBackoff  = time.Second
+	defaultWebhookTimeout  = 10 * time.Second
+)
+
+// WebhookSink POSTs each event as JSON to URL.
+//
+// Each delivery carries an X-Serper-Signature header of the form
+// "sha256=<hex>", the HMAC-SHA256 of "<timestamp>.<body>" keyed with
+// Secret, where timestamp is the X-Serper-Timestamp header in Unix seconds.
+// Secret is required, since a signature keyed with nothing proves nothing.
+// Receivers check it with VerifyWebhookSignature. X-Serper-Delivery is the
+// same for every attempt of one event, so receivers can drop duplicates.
+//
+// Network errors, 429 and 5xx responses are retried with exponential
+// backoff; other responses fail immediately. Deliveries that still fail
+// are appended to DeadLetterPath as JSON lines, if set.
+type WebhookSink struct {
+	URL            string
+	Secret         []byte
+	Doer           Doer          // default: http.Client with a 10s timeout
+	MaxAttempts    int           // default 4
+	Backoff        time.Duration // delay before the first retry, doubled each time; default 1s
+	DeadLetterPath string
+
+	mu sync.Mutex // serialises dead-letter writes
+}
+
+// DeadLetter is one line of a WebhookSink dead-letter file. Payload is the
+// exact body that was sent, so the delivery can be replayed.
+type DeadLetter struct {
+	Time       time.Time       `json:"time"`
+	URL        string          `json:"url"`
+	DeliveryID string          `json:"deliveryId"`
+	Attempts   int             `json:"attempts"`
+	Error      string          `json:"error"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// Send delivers e, retrying as configured. If delivery fails the event is
+// written to the dead-letter file and the delivery error is returned.
+func (s *WebhookSink) Send(ctx context.Context, e Event) error {
+	if s.URL == "" {
+		return fmt.Errorf("serper: webhook: URL must not be empty")
+	}
+	if len(s.Secret) == 0 {
+		return fmt.Errorf("serper: webhook: Secret must not be empty")
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("serper: webhook: marshal event: %w", err)
+	}
+	id, err := newDeliveryID()
+	if err != nil {
+		return fmt.Errorf("serper: webhook: %w", err)
+	}
+
+	attempts := s.MaxAttempts
+	if attempts <= 0 {
+		attempts = defaultWebhookAttempts
+	}
+	backoff := s.Backoff
+	if backoff <= 0 {
+		backoff = defaultWebhookBackoff
+	}
+
+	var attempt int
+	for attempt = 1; ; attempt++ {
+		var retry bool
+		retry, err = s.deliver(ctx, e.Type, id, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == attempts || ctx.Err() != nil {
+			break
+		}
+		timer := time.NewTimer(backoff << (attempt - 1))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	err = fmt.Errorf("serper: webhook: delivery %s failed after %d attempts: %w", id, attempt, err)
+	if dlErr := s.deadLetter(DeadLetter{
+		Time:       time.Now().UTC(),
+		URL:        s.URL,
+		DeliveryID: id,
+		Attempts:   attempt,
+		Error:      err.Error(),
+		Payload:    body,
+	}); dlErr != nil {
+		return fmt.Errorf("%w (dead letter: %v)", err, dlErr)
+	}
+	return err
+}
+
+// deliver makes one POST attempt. retry reports whether a failure is worth
+// retrying.
+func (s *WebhookSink) deliver(ctx context.Context, eventType, id string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, eventType)
+	req.Header.Set(WebhookDeliveryHeader, id)
+	req.Header.Set(WebhookTimestampHeader, ts)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(s.Secret, ts, body))
+
+	doer := s.Doer
+	if doer == nil {
+		doer = &http.Client{Timeout: defaultWebhookTimeout}
+	}
+	resp, err := doer.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("receiver returned %s", resp.Status)
+}
+
+func (s *WebhookSink) deadLetter(dl DeadLetter) error {
+	if s.DeadLetterPath == "" {
+		return nil
+	}
+	line, err := json.Marshal(dl)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.DeadLetterPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// SignWebhook returns the X-Serper-Signature value for a delivery body sent
+// at timestamp (Unix seconds, as a decimal string).
+func SignWebhook(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature reports whether signature is valid for body and
+// timestamp, using a constant-time comparison. Receivers should also reject
+// timestamps too far from their own clock to prevent replays.
+func VerifyWebhookSignature(secret []byte, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhook(secret, timestamp, body)), []byte(signature))
+}
+
+func newDeliveryID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("delivery id: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
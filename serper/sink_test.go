@@ -0,0 +1,245 @@
+package serper
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// webhookReceiver records deliveries and answers with statuses in turn,
+// repeating the last one.
+type webhookReceiver struct {
+	mu         sync.Mutex
+	statuses   []int
+	deliveries []*http.Request
+	bodies     [][]byte
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rcv.mu.Lock()
+	n := len(rcv.deliveries)
+	rcv.deliveries = append(rcv.deliveries, r)
+	rcv.bodies = append(rcv.bodies, body)
+	status := rcv.statuses[min(n, len(rcv.statuses)-1)]
+	rcv.mu.Unlock()
+	w.WriteHeader(status)
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) (*webhookReceiver, string) {
+	t.Helper()
+	rcv := &webhookReceiver{statuses: statuses}
+	srv := httptest.NewServer(rcv)
+	t.Cleanup(srv.Close)
+	return rcv, srv.URL
+}
+
+func TestWebhookSink_SignsDelivery(t *testing.T) {
+	rcv, url := newWebhookReceiver(t, http.StatusNoContent)
+	secret := []byte("s3cret")
+	sink := &WebhookSink{URL: url, Secret: secret}
+
+	if err := sink.Send(context.Background(), Event{Type: "test.event", Data: map[string]int{"n": 1}}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(rcv.deliveries) != 1 {
+		t.Fatalf("deliveries: got %d, want 1", len(rcv.deliveries))
+	}
+	r, body := rcv.deliveries[0], rcv.bodies[0]
+	if r.Header.Get(WebhookEventHeader) != "test.event" || r.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("headers: got %v", r.Header)
+	}
+	ts, sig := r.Header.Get(WebhookTimestampHeader), r.Header.Get(WebhookSignatureHeader)
+	if !VerifyWebhookSignature(secret, ts, body, sig) {
+		t.Errorf("signature %q does not verify", sig)
+	}
+	if VerifyWebhookSignature([]byte("wrong"), ts, body, sig) {
+		t.Error("signature should not verify with a different secret")
+	}
+	var e Event
+	if err := json.Unmarshal(body, &e); err != nil || e.Type != "test.event" || e.Time.IsZero() {
+		t.Errorf("body: got %s (%v)", body, err)
+	}
+}
+
+func TestWebhookSink_RequiresSecret(t *testing.T) {
+	rcv, url := newWebhookReceiver(t, http.StatusOK)
+	sink := &WebhookSink{URL: url}
+	if err := sink.Send(context.Background(), Event{Type: "test.event"}); err == nil {
+		t.Fatal("expected an error without a secret")
+	}
+	if len(rcv.deliveries) != 0 {
+		t.Errorf("deliveries: got %d, want 0", len(rcv.deliveries))
+	}
+}
+
+func TestWebhookSink_RetriesWithSameDeliveryID(t *testing.T) {
+	rcv, url := newWebhookReceiver(t, http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK)
+	sink := &WebhookSink{URL: url, Secret: []byte("s3cret"), Backoff: time.Millisecond}
+
+	if err := sink.Send(context.Background(), Event{Type: "test.event"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(rcv.deliveries) != 3 {
+		t.Fatalf("attempts: got %d, want 3", len(rcv.deliveries))
+	}
+	id := rcv.deliveries[0].Header.Get(WebhookDeliveryHeader)
+	for _, r := range rcv.deliveries {
+		if r.Header.Get(WebhookDeliveryHeader) != id {
+			t.Errorf("delivery ID changed between attempts")
+		}
+	}
+}
+
+func TestWebhookSink_DeadLetter(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantAttempts int
+	}{
+		{"retries exhausted", http.StatusInternalServerError, 3},
+		{"permanent failure", http.StatusBadRequest, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rcv, url := newWebhookReceiver(t, tt.status)
+			path := filepath.Join(t.TempDir(), "dead.jsonl")
+			sink := &WebhookSink{URL: url, Secret: []byte("s3cret"), MaxAttempts: 3, Backoff: time.Millisecond, DeadLetterPath: path}
+
+			if err := sink.Send(context.Background(), Event{Type: "test.event"}); err == nil {
+				t.Fatal("expected delivery error")
+			}
+			if len(rcv.deliveries) != tt.wantAttempts {
+				t.Errorf("attempts: got %d, want %d", len(rcv.deliveries), tt.wantAttempts)
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("dead-letter file: %v", err)
+			}
+			defer f.Close()
+			sc := bufio.NewScanner(f)
+			if !sc.Scan() {
+				t.Fatal("dead-letter file is empty")
+			}
+			var dl DeadLetter
+			if err := json.Unmarshal(sc.Bytes(), &dl); err != nil {
+				t.Fatalf("dead letter: %v", err)
+			}
+			if dl.Attempts != tt.wantAttempts || dl.URL != url || string(dl.Payload) != string(rcv.bodies[0]) {
+				t.Errorf("dead letter: got %+v", dl)
+			}
+		})
+	}
+}
+
+func TestWatcher_NotifiesSink(t *testing.T) {
+	doer, _ := newsSequence(newsPollOne, newsPollOne)
+	c := mustNew(t, "key", WithDoer(doer))
+	var events []Event
+	w, err := c.NewWatcher(WatchConfig{
+		Queries:  []SearchRequest{{Q: "acme"}},
+		Interval: time.Minute,
+		Sink: SinkFunc(func(_ context.Context, e Event) error {
+			events = append(events, e)
+			return nil
+		}),
+	})
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+	_, _ = w.Poll(context.Background())
+	_, _ = w.Poll(context.Background()) // nothing new: no event
+	if len(events) != 1 || events[0].Type != EventWatchNewItems {
+		t.Fatalf("events: got %+v", events)
+	}
+	if items, ok := events[0].Data.([]WatchItem); !ok || len(items) != 2 {
+		t.Errorf("event data: got %#v", events[0].Data)
+	}
+}
+
+func TestGridScan_NotifiesSink(t *testing.T) {
+	var calls atomic.Int32
+	c := mustNew(t, "key", WithDoer(gridDoer(&calls)))
+	cfg := GridScanConfig{
+		Query:  "cafe",
+		Center: LatLng{Lat: 40.0, Lng: -74.0},
+		Size:   1,
+		Target: BusinessMatcher{Name: "Target Cafe"},
+	}
+
+	var got *GridScanResult
+	cfg.Sink = SinkFunc(func(_ context.Context, e Event) error {
+		if e.Type == EventGridScanComplete {
+			got, _ = e.Data.(*GridScanResult)
+		}
+		return nil
+	})
+	res, err := c.GridScan(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("GridScan: %v", err)
+	}
+	if got != res {
+		t.Error("sink should receive the scan result")
+	}
+
+	errDown := errors.New("receiver down")
+	cfg.Sink = SinkFunc(func(context.Context, Event) error { return errDown })
+	res, err = c.GridScan(context.Background(), cfg)
+	if !errors.Is(err, errDown) || res == nil {
+		t.Errorf("failed notification: got res=%v err=%v, want result and error", res, err)
+	}
+}
+
+func TestBatchJobs_NotifySink(t *testing.T) {
+	c := mustNew(t, "key", WithDoer(doerFunc(func(*http.Request) (*http.Response, error) {
+		return jsonResponse(`{"organic":[{"link":"https://a.example/"}],"suggestions":[{"value":"go tutorial"}]}`), nil
+	})))
+	ctx := context.Background()
+	tests := []struct {
+		name, event string
+		run         func(Sink) (any, error)
+	}{
+		{"cluster", EventClusterComplete, func(s Sink) (any, error) {
+			return c.ClusterKeywords(ctx, KeywordClusterConfig{Keywords: []string{"a", "b"}, Sink: s})
+		}},
+		{"expand", EventExpandComplete, func(s Sink) (any, error) {
+			return c.ExpandQueries(ctx, ExpandConfig{Seeds: []string{"go"}, Sink: s})
+		}},
+		{"suggest", EventSuggestComplete, func(s Sink) (any, error) {
+			return c.DiscoverSuggestions(ctx, SuggestConfig{Seed: "go", Questions: []string{}, Comparisons: []string{}, Sink: s})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var events []Event
+			res, err := tt.run(SinkFunc(func(_ context.Context, e Event) error {
+				mu.Lock()
+				defer mu.Unlock()
+				events = append(events, e)
+				return nil
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != 1 || events[0].Type != tt.event || events[0].Data != res {
+				t.Errorf("events: got %+v", events)
+			}
+
+			res, err = tt.run(SinkFunc(func(context.Context, Event) error { return errors.New("receiver down") }))
+			if err == nil || res == nil {
+				t.Errorf("failed notification: got %v, %v; want the result and an error", res, err)
+			}
+		})
+	}
+}
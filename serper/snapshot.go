@@ -0,0 +1,268 @@
+package serper
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSnapshotNotFound is returned when a store has no snapshot for a key.
+var ErrSnapshotNotFound = errors.New("serper: snapshot not found")
+
+// SnapshotKey identifies the SERP a snapshot was taken of. Each page of
+// results has its own history; the zero Page is the first. The number of
+// results is not part of the key, so deeper first pages, such as those
+// fetched by TrackRanks, share page 1's history.
+type SnapshotKey struct {
+	Query    string `json:"query"`
+	Vertical string `json:"vertical"` // endpoint name: "search", "news", "places", ...
+	GL       string `json:"gl"`
+	HL       string `json:"hl"`
+	Location string `json:"location,omitempty"`
+	Page     int    `json:"page,omitempty"`
+}
+
+// Normalize returns the key with the query trimmed, lower-cased and
+// whitespace-collapsed, the vertical, gl and hl defaulted, and page 1
+// stored as 0, so that equivalent searches share a history.
+func (k SnapshotKey) Normalize() SnapshotKey {
+	k.Query = strings.ToLower(strings.Join(strings.Fields(k.Query), " "))
+	k.Location = strings.TrimSpace(k.Location)
+	if k.Page <= 1 {
+		k.Page = 0
+	}
+	if k.Vertical == "" {
+		k.Vertical = "search"
+	}
+	if k.GL == "" {
+		k.GL = "us"
+	}
+	if k.HL == "" {
+		k.HL = "en"
+	}
+	return k
+}
+
+// Snapshot is a search response as returned at a point in time. Response
+// holds the raw API body; decode it with Decode.
+type Snapshot struct {
+	SnapshotKey
+	Time     time.Time       `json:"time"`
+	Credits  int             `json:"credits"`
+	Response json.RawMessage `json:"response"`
+}
+
+// Decode unmarshals the stored response into v, e.g. a *SearchResponse.
+func (s Snapshot) Decode(v any) error {
+	if err := json.Unmarshal(s.Response, v); err != nil {
+		return fmt.Errorf("serper: decode snapshot: %w", err)
+	}
+	return nil
+}
+
+// SnapshotStore keeps the history of SERPs. Implementations must be safe
+// for concurrent use and normalise keys with SnapshotKey.Normalize.
+type SnapshotStore interface {
+	// Save records a snapshot.
+	Save(ctx context.Context, s Snapshot) error
+	// Nearest returns the snapshot for key taken closest to t, or
+	// ErrSnapshotNotFound.
+	Nearest(ctx context.Context, key SnapshotKey, t time.Time) (Snapshot, error)
+	// History returns every snapshot for key, oldest first.
+	History(ctx context.Context, key SnapshotKey) ([]Snapshot, error)
+}
+
+// WithSnapshotStore saves every response fetched from the API to store.
+// Responses served from the cache are not saved again. If saving fails the
+// search returns the error and the response is not cached, so a retry
+// fetches and saves it again and a gap in the history is never silent.
+func WithSnapshotStore(store SnapshotStore) Option {
+	return func(c *Client) { c.snapshots = store }
+}
+
+// saveSnapshot records a raw response body fetched from endpoint.
+func (c *Client) saveSnapshot(ctx context.Context, endpoint string, reqBody any, body []byte) error {
+	req, ok := reqBody.(*SearchRequest)
+	if !ok {
+		return nil
+	}
+	snap := Snapshot{
+		SnapshotKey: SnapshotKey{
+			Query:    req.Q,
+			Vertical: strings.TrimPrefix(endpoint, "/"),
+			GL:       req.GL,
+			HL:       req.HL,
+			Location: req.Location,
+			Page:     req.Page,
+		},
+		Time:     time.Now().UTC(),
+		Response: body,
+	}
+	var credits struct {
+		Credits int `json:"credits"`
+	}
+	if json.Unmarshal(body, &credits) == nil && credits.Credits > 0 {
+		snap.Credits = credits.Credits
+	} else {
+		snap.Credits = RequestCredits(req)
+	}
+	if err := c.snapshots.Save(ctx, snap); err != nil {
+		return fmt.Errorf("serper: save snapshot: %w", err)
+	}
+	return nil
+}
+
+// JSONLSnapshotStore is a SnapshotStore keeping one JSON Lines file per
+// key in a directory. Files are append-only, so history survives crashes
+// up to the last complete line.
+type JSONLSnapshotStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewJSONLSnapshotStore returns a store in dir, creating it if needed.
+func NewJSONLSnapshotStore(dir string) (*JSONLSnapshotStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("serper: snapshot store: %w", err)
+	}
+	return &JSONLSnapshotStore{dir: dir}, nil
+}
+
+// Save appends s to its key's file. A zero Time is set to now.
+func (st *JSONLSnapshotStore) Save(_ context.Context, s Snapshot) error {
+	s.SnapshotKey = s.SnapshotKey.Normalize()
+	if s.Query == "" {
+		return fmt.Errorf("serper: snapshot store: query must not be empty")
+	}
+	if s.Time.IsZero() {
+		s.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("serper: snapshot store: %w", err)
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	f, err := os.OpenFile(st.path(s.SnapshotKey), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("serper: snapshot store: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("serper: snapshot store: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("serper: snapshot store: %w", err)
+	}
+	return nil
+}
+
+// History returns every snapshot for key, oldest first. Records in the
+// key's file whose stored key differs, left by a file name collision, are
+// skipped.
+func (st *JSONLSnapshotStore) History(_ context.Context, key SnapshotKey) ([]Snapshot, error) {
+	key = key.Normalize()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	f, err := os.Open(st.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("serper: snapshot store: %w", err)
+	}
+	defer f.Close()
+
+	var out []Snapshot
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), maxResponseBytes+64*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var s Snapshot
+		if err := json.Unmarshal(sc.Bytes(), &s); err != nil {
+			return nil, fmt.Errorf("serper: snapshot store: %s line %d: %w", f.Name(), line, err)
+		}
+		if s.SnapshotKey.Normalize() != key {
+			continue
+		}
+		out = append(out, s)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("serper: snapshot store: %w", err)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out, nil
+}
+
+// Nearest returns the snapshot for key taken closest to t. On a tie the
+// earlier snapshot wins.
+func (st *JSONLSnapshotStore) Nearest(ctx context.Context, key SnapshotKey, t time.Time) (Snapshot, error) {
+	history, err := st.History(ctx, key)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return nearestSnapshot(history, t)
+}
+
+// nearestSnapshot picks the snapshot closest to t from a history sorted
+// oldest first.
+func nearestSnapshot(history []Snapshot, t time.Time) (Snapshot, error) {
+	if len(history) == 0 {
+		return Snapshot{}, ErrSnapshotNotFound
+	}
+	i := sort.Search(len(history), func(i int) bool { return !history[i].Time.Before(t) })
+	switch {
+	case i == 0:
+		return history[0], nil
+	case i == len(history):
+		return history[i-1], nil
+	}
+	before, after := history[i-1], history[i]
+	if after.Time.Sub(t) < t.Sub(before.Time) {
+		return after, nil
+	}
+	return before, nil
+}
+
+// path returns the file for a normalised key: a readable slug of the query
+// followed by a truncated hash of the full key. Distinct keys are unlikely,
+// though not certain, to share a file; each record stores its full key,
+// which History checks.
+func (st *JSONLSnapshotStore) path(key SnapshotKey) string {
+	data, _ := json.Marshal(key)
+	sum := sha256.Sum256(data)
+	return filepath.Join(st.dir, fmt.Sprintf("%s-%s-%s.jsonl", slugify(key.Vertical), slugify(key.Query), hex.EncodeToString(sum[:6])))
+}
+
+// slugify keeps ASCII letters and digits of s, joined by dashes, and caps
+// the length so file names stay short.
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range s {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+		if b.Len() >= 40 {
+			break
+		}
+	}
+	return strings.TrimRight(b.String(), "-")
+}
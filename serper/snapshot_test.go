@@ -0,0 +1,214 @@
+package serper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestJSONLSnapshotStore_HistoryAndNearest(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewJSONLSnapshotStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewJSONLSnapshotStore: %v", err)
+	}
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 12, 0, 0, 0, time.UTC) }
+	key := SnapshotKey{Query: "Best  Coffee", Vertical: "search", GL: "us", HL: "en"}
+	// Saved out of order; History sorts by time.
+	for _, d := range []int{10, 1, 5} {
+		s := Snapshot{SnapshotKey: key, Time: day(d), Credits: 1, Response: []byte(`{"organic":[]}`)}
+		if err := store.Save(ctx, s); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	other := Snapshot{SnapshotKey: SnapshotKey{Query: "best coffee", GL: "de"}, Time: day(2)}
+	if err := store.Save(ctx, other); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	history, err := store.History(ctx, SnapshotKey{Query: "best coffee"})
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(history) != 3 || !history[0].Time.Equal(day(1)) || !history[2].Time.Equal(day(10)) {
+		t.Fatalf("history: got %+v", history)
+	}
+	if history[0].Query != "best coffee" {
+		t.Errorf("query should be normalised, got %q", history[0].Query)
+	}
+
+	tests := []struct {
+		at   time.Time
+		want time.Time
+	}{
+		{day(1).Add(-48 * time.Hour), day(1)},
+		{day(4), day(5)},
+		{day(3), day(1)}, // tie between day 1 and day 5: earlier wins
+		{day(8), day(10)},
+		{day(30), day(10)},
+	}
+	for _, tt := range tests {
+		got, err := store.Nearest(ctx, key, tt.at)
+		if err != nil {
+			t.Fatalf("Nearest(%s): %v", tt.at, err)
+		}
+		if !got.Time.Equal(tt.want) {
+			t.Errorf("Nearest(%s): got %s, want %s", tt.at, got.Time, tt.want)
+		}
+	}
+
+	if _, err := store.Nearest(ctx, SnapshotKey{Query: "unknown"}, day(1)); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("unknown key: got %v, want ErrSnapshotNotFound", err)
+	}
+}
+
+func TestJSONLSnapshotStore_SkipsCollidingKeys(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewJSONLSnapshotStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewJSONLSnapshotStore: %v", err)
+	}
+	key := SnapshotKey{Query: "coffee"}.Normalize()
+	if err := store.Save(ctx, Snapshot{SnapshotKey: key, Time: time.Now()}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	// Simulate another key whose file name collides with this one.
+	other, _ := json.Marshal(Snapshot{SnapshotKey: SnapshotKey{Query: "tea"}.Normalize(), Time: time.Now()})
+	f, err := os.OpenFile(store.path(key), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(append(other, '\n')); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	history, err := store.History(ctx, key)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(history) != 1 || history[0].Query != "coffee" {
+		t.Errorf("history: got %+v, want only the coffee snapshot", history)
+	}
+}
+
+// memorySnapshotStore records saved snapshots for client tests.
+type memorySnapshotStore struct {
+	saved []Snapshot
+	err   error
+}
+
+func (m *memorySnapshotStore) Save(_ context.Context, s Snapshot) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.saved = append(m.saved, s)
+	return nil
+}
+
+func (m *memorySnapshotStore) Nearest(context.Context, SnapshotKey, time.Time) (Snapshot, error) {
+	return nearestSnapshot(m.saved, time.Now())
+}
+
+func (m *memorySnapshotStore) History(context.Context, SnapshotKey) ([]Snapshot, error) {
+	return m.saved, nil
+}
+
+func TestWithSnapshotStore(t *testing.T) {
+	store := &memorySnapshotStore{}
+	mock := &mockDoer{statusCode: 200, respBody: `{"news":[{"title":"A"}],"credits":2}`}
+	c := mustNew(t, "key", WithDoer(mock), WithSnapshotStore(store), WithCache(NewMemoryCache(time.Minute, 10)))
+
+	resp, err := c.News(context.Background(), &SearchRequest{Q: "acme", Location: "Berlin"})
+	if err != nil {
+		t.Fatalf("News: %v", err)
+	}
+	if resp.Credits != 2 {
+		t.Errorf("Credits: got %d, want 2", resp.Credits)
+	}
+	if _, err := c.News(context.Background(), &SearchRequest{Q: "acme", Location: "Berlin"}); err != nil {
+		t.Fatalf("cached News: %v", err)
+	}
+	if len(store.saved) != 1 {
+		t.Fatalf("snapshots: got %d, want 1 (cache hits are not saved)", len(store.saved))
+	}
+	s := store.saved[0]
+	want := SnapshotKey{Query: "acme", Vertical: "news", GL: "us", HL: "en", Location: "Berlin", Page: 1}
+	if s.SnapshotKey != want || s.Credits != 2 || s.Time.IsZero() {
+		t.Errorf("snapshot: got %+v", s)
+	}
+	var decoded NewsResponse
+	if err := s.Decode(&decoded); err != nil || len(decoded.News) != 1 {
+		t.Errorf("Decode: got %+v, %v", decoded, err)
+	}
+}
+
+func TestWithSnapshotStore_CreditsFallbackAndErrors(t *testing.T) {
+	store := &memorySnapshotStore{}
+	mock := &mockDoer{statusCode: 200, respBody: `{"organic":[]}`}
+	c := mustNew(t, "key", WithDoer(mock), WithSnapshotStore(store))
+
+	if _, err := c.Search(context.Background(), &SearchRequest{Q: "x", Num: 20}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if store.saved[0].Credits != 2 {
+		t.Errorf("credits estimate: got %d, want 2", store.saved[0].Credits)
+	}
+
+	store.err = errors.New("disk full")
+	if _, err := c.Search(context.Background(), &SearchRequest{Q: "x"}); !errors.Is(err, store.err) {
+		t.Errorf("save failure: got %v, want disk full", err)
+	}
+}
+
+func TestWithSnapshotStore_FailedSaveIsNotCached(t *testing.T) {
+	store := &memorySnapshotStore{err: errors.New("disk full")}
+	mock := &mockDoer{statusCode: 200, respBody: `{"organic":[]}`}
+	c := mustNew(t, "key", WithDoer(mock), WithSnapshotStore(store), WithCache(NewMemoryCache(time.Minute, 10)))
+
+	if _, err := c.Search(context.Background(), &SearchRequest{Q: "x"}); err == nil {
+		t.Fatal("expected the save failure")
+	}
+	store.err = nil
+	if _, err := c.Search(context.Background(), &SearchRequest{Q: "x"}); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if len(store.saved) != 1 {
+		t.Errorf("snapshots after retry: got %d, want 1", len(store.saved))
+	}
+}
+
+func TestWithSnapshotStore_PagesKeptApart(t *testing.T) {
+	doer := &pagedDoer{pages: map[string][][]string{
+		"q": {{"https://a.example/", "https://b.example/"}, {"https://c.example/", "https://d.example/"}},
+	}}
+	store, err := NewJSONLSnapshotStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := mustNew(t, "key", WithDoer(doer), WithSnapshotStore(store))
+	ctx := context.Background()
+	if _, err := c.SearchPages(ctx, &SearchRequest{Q: "q", Num: 2}, 4, nil); err != nil {
+		t.Fatalf("SearchPages: %v", err)
+	}
+
+	for page, want := range map[int]string{1: "https://a.example/", 2: "https://c.example/"} {
+		history, err := store.History(ctx, SnapshotKey{Query: "q", Page: page})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 1 {
+			t.Fatalf("page %d: got %d snapshots, want 1", page, len(history))
+		}
+		var resp SearchResponse
+		if err := history[0].Decode(&resp); err != nil || resp.Organic[0].Link != want {
+			t.Errorf("page %d: got %+v, %v", page, resp.Organic, err)
+		}
+	}
+	if history, _ := store.History(ctx, SnapshotKey{Query: "q"}); len(history) != 1 {
+		t.Errorf("zero page should name page 1: got %d snapshots", len(history))
+	}
+}
@@ -0,0 +1,259 @@
+package serper
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// RegistrableDomain returns the registrable domain of a URL or host under
+// the public suffix rules: one label more than the public suffix, so
+// "https://news.bbc.co.uk/x" gives "bbc.co.uk" and "user.github.io" stays
+// "user.github.io". IP addresses are returned as they are. It returns ""
+// when there is no host or the host is itself a public suffix.
+func RegistrableDomain(link string) string {
+	host := websiteHost(link)
+	if host == "" {
+		return ""
+	}
+	if net.ParseIP(strings.Trim(host, "[]")) != nil {
+		return host
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(strings.TrimSuffix(host, "."))
+	if err != nil {
+		return ""
+	}
+	return domain
+}
+
+// CTRCurve returns the expected click-through rate of a 1-based position.
+type CTRCurve func(position int) float64
+
+// defaultCTR holds typical desktop organic click-through rates for
+// positions 1-10.
+var defaultCTR = []float64{0.28, 0.15, 0.11, 0.08, 0.07, 0.05, 0.04, 0.03, 0.03, 0.02}
+
+// DefaultCTRCurve is a typical organic click-through curve for positions
+// 1-10, falling from 28% to 2%. Later positions get 1%, so results beyond
+// the first page still count, just barely.
+func DefaultCTRCurve(position int) float64 {
+	switch {
+	case position < 1:
+		return 0
+	case position <= len(defaultCTR):
+		return defaultCTR[position-1]
+	}
+	return 0.01
+}
+
+// CTRTable returns a curve giving rates[i] to position i+1 and zero to
+// positions beyond the table.
+func CTRTable(rates ...float64) CTRCurve {
+	return func(position int) float64 {
+		if position < 1 || position > len(rates) {
+			return 0
+		}
+		return rates[position-1]
+	}
+}
+
+// DomainShare is one domain's row in a share-of-voice report.
+type DomainShare struct {
+	Domain string `json:"domain"`
+	// Share is the domain's fraction of all CTR-weighted visibility added,
+	// from 0 to 1; shares of all domains sum to 1.
+	Share float64 `json:"share"`
+	// Visibility is the sum of the CTR of every position the domain held.
+	Visibility float64 `json:"visibility"`
+	// Appearances counts results per vertical, e.g. "search" and "news".
+	Appearances  map[string]int `json:"appearances"`
+	BestPosition int            `json:"bestPosition"`
+	// AveragePosition is the mean position over all appearances.
+	AveragePosition float64 `json:"averagePosition"`
+
+	positions int // sum of positions, for AveragePosition
+	count     int // appearances in all verticals
+}
+
+// ShareOfVoice aggregates which registrable domains rank across many
+// result sets. Each result adds the CTR of its position to its domain's
+// visibility, and a domain's share is its fraction of the total. Add
+// results with AddSearch, AddNews or AddLinks, then call Report. It is not
+// safe for concurrent use.
+type ShareOfVoice struct {
+	curve   CTRCurve
+	domains map[string]*DomainShare
+	total   float64
+	sets    map[string]int
+}
+
+// NewShareOfVoice returns an empty aggregation weighting positions by
+// curve, or by DefaultCTRCurve if curve is nil.
+func NewShareOfVoice(curve CTRCurve) *ShareOfVoice {
+	if curve == nil {
+		curve = DefaultCTRCurve
+	}
+	return &ShareOfVoice{curve: curve, domains: make(map[string]*DomainShare), sets: make(map[string]int)}
+}
+
+// AddSearch adds the organic results of a web search as vertical "search".
+func (s *ShareOfVoice) AddSearch(resp *SearchResponse) {
+	ranked := rankedOrganic(resp.Organic)
+	links := make([]RankedLink, len(ranked))
+	for i, r := range ranked {
+		links[i] = RankedLink{Link: r.Link, Position: r.Position}
+	}
+	s.AddLinks("search", links)
+}
+
+// AddNews adds the results of a news search as vertical "news".
+func (s *ShareOfVoice) AddNews(resp *NewsResponse) {
+	links := make([]RankedLink, len(resp.News))
+	for i, r := range resp.News {
+		links[i] = RankedLink{Link: r.Link, Position: r.Position}
+		if links[i].Position <= 0 {
+			links[i].Position = i + 1
+		}
+	}
+	s.AddLinks("news", links)
+}
+
+// RankedLink is a result link and its 1-based position.
+type RankedLink struct {
+	Link     string
+	Position int
+}
+
+// AddLinks adds one result set of any vertical. Links without a
+// registrable domain are skipped.
+func (s *ShareOfVoice) AddLinks(vertical string, links []RankedLink) {
+	s.sets[vertical]++
+	for _, l := range links {
+		domain := RegistrableDomain(l.Link)
+		if domain == "" || l.Position < 1 {
+			continue
+		}
+		d, ok := s.domains[domain]
+		if !ok {
+			d = &DomainShare{Domain: domain, Appearances: make(map[string]int)}
+			s.domains[domain] = d
+		}
+		w := s.curve(l.Position)
+		d.Visibility += w
+		s.total += w
+		d.Appearances[vertical]++
+		d.positions += l.Position
+		d.count++
+		if d.BestPosition == 0 || l.Position < d.BestPosition {
+			d.BestPosition = l.Position
+		}
+	}
+}
+
+// ShareOfVoiceReport is the result of ShareOfVoice.Report.
+type ShareOfVoiceReport struct {
+	// ResultSets counts the result sets added per vertical.
+	ResultSets map[string]int `json:"resultSets"`
+	// Domains are ordered by share descending, then by appearances and
+	// name.
+	Domains []DomainShare `json:"domains"`
+}
+
+// Report returns the share of voice of every domain seen so far.
+func (s *ShareOfVoice) Report() *ShareOfVoiceReport {
+	rep := &ShareOfVoiceReport{ResultSets: make(map[string]int, len(s.sets))}
+	for k, v := range s.sets {
+		rep.ResultSets[k] = v
+	}
+	for _, d := range s.domains {
+		row := *d
+		row.Appearances = make(map[string]int, len(d.Appearances))
+		for k, v := range d.Appearances {
+			row.Appearances[k] = v
+		}
+		if s.total > 0 {
+			row.Share = d.Visibility / s.total
+		}
+		row.AveragePosition = float64(d.positions) / float64(d.count)
+		rep.Domains = append(rep.Domains, row)
+	}
+	sort.Slice(rep.Domains, func(i, j int) bool {
+		a, b := rep.Domains[i], rep.Domains[j]
+		if a.Visibility != b.Visibility {
+			return a.Visibility > b.Visibility
+		}
+		if a.count != b.count {
+			return a.count > b.count
+		}
+		return a.Domain < b.Domain
+	})
+	return rep
+}
+
+// verticals returns the verticals added, sorted.
+func (r *ShareOfVoiceReport) verticals() []string {
+	out := make([]string, 0, len(r.ResultSets))
+	for v := range r.ResultSets {
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Text renders the first limit domains as a plain-text table; zero or
+// less renders all of them.
+func (r *ShareOfVoiceReport) Text(limit int) string {
+	rows := r.Domains
+	if limit > 0 && len(rows) > limit {
+		rows = rows[:limit]
+	}
+	verticals := r.verticals()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-30s %7s %5s %6s", "Domain", "Share", "Best", "Avg")
+	for _, v := range verticals {
+		fmt.Fprintf(&b, " %8s", v)
+	}
+	b.WriteString("\n")
+	for _, d := range rows {
+		fmt.Fprintf(&b, "%-30s %6.1f%% %5d %6.1f", d.Domain, 100*d.Share, d.BestPosition, d.AveragePosition)
+		for _, v := range verticals {
+			fmt.Fprintf(&b, " %8d", d.Appearances[v])
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// WriteCSV writes one row per domain: domain, share, visibility,
+// best_position, average_position, then an appearance count per vertical.
+func (r *ShareOfVoiceReport) WriteCSV(w io.Writer) error {
+	verticals := r.verticals()
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"domain", "share", "visibility", "best_position", "average_position"}, verticals...)); err != nil {
+		return err
+	}
+	for _, d := range r.Domains {
+		row := []string{
+			d.Domain,
+			strconv.FormatFloat(d.Share, 'f', 4, 64),
+			strconv.FormatFloat(d.Visibility, 'f', 4, 64),
+			strconv.Itoa(d.BestPosition),
+			strconv.FormatFloat(d.AveragePosition, 'f', 2, 64),
+		}
+		for _, v := range verticals {
+			row = append(row, strconv.Itoa(d.Appearances[v]))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,99 @@
+package serper
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestRegistrableDomain(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"https://www.example.com/page", "example.com"},
+		{"https://news.bbc.co.uk/story", "bbc.co.uk"},
+		{"https://Shop.Example.COM.AU", "example.com.au"},
+		{"https://alice.github.io/blog", "alice.github.io"},
+		{"example.org", "example.org"},
+		{"https://co.uk/", ""},
+		{"http://192.0.2.1:8080/x", "192.0.2.1"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := RegistrableDomain(tt.in); got != tt.want {
+			t.Errorf("RegistrableDomain(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCTRCurves(t *testing.T) {
+	if DefaultCTRCurve(1) <= DefaultCTRCurve(2) || DefaultCTRCurve(0) != 0 || DefaultCTRCurve(50) != 0.01 {
+		t.Errorf("default curve: %v %v %v %v", DefaultCTRCurve(0), DefaultCTRCurve(1), DefaultCTRCurve(2), DefaultCTRCurve(50))
+	}
+	c := CTRTable(0.5, 0.25)
+	if c(1) != 0.5 || c(2) != 0.25 || c(3) != 0 || c(0) != 0 {
+		t.Errorf("table curve: %v %v %v", c(1), c(2), c(3))
+	}
+}
+
+func TestShareOfVoice(t *testing.T) {
+	sov := NewShareOfVoice(CTRTable(0.5, 0.3, 0.2))
+	sov.AddSearch(&SearchResponse{Organic: []OrganicResult{
+		{Link: "https://www.a.com/1"},
+		{Link: "https://blog.b.co.uk/"},
+		{Link: "https://a.com/2"},
+	}})
+	sov.AddSearch(&SearchResponse{Organic: []OrganicResult{
+		{Link: "https://b.co.uk/", Position: 1},
+		{Link: "not a url", Position: 2},
+	}})
+	sov.AddNews(&NewsResponse{News: []NewsResult{
+		{Link: "https://news.a.com/x"},
+		{Link: "https://c.org/y"},
+	}})
+
+	rep := sov.Report()
+	if rep.ResultSets["search"] != 2 || rep.ResultSets["news"] != 1 {
+		t.Errorf("result sets: got %v", rep.ResultSets)
+	}
+	// a.com: 0.5 + 0.2 + 0.5 = 1.2; b.co.uk: 0.3 + 0.5 = 0.8; c.org: 0.3.
+	want := []struct {
+		domain     string
+		visibility float64
+		search     int
+		news       int
+		best       int
+		avg        float64
+	}{
+		{"a.com", 1.2, 2, 1, 1, 5.0 / 3},
+		{"b.co.uk", 0.8, 2, 0, 1, 1.5},
+		{"c.org", 0.3, 0, 1, 2, 2},
+	}
+	if len(rep.Domains) != len(want) {
+		t.Fatalf("domains: got %+v", rep.Domains)
+	}
+	for i, w := range want {
+		d := rep.Domains[i]
+		if d.Domain != w.domain || math.Abs(d.Visibility-w.visibility) > 1e-9 ||
+			d.Appearances["search"] != w.search || d.Appearances["news"] != w.news ||
+			d.BestPosition != w.best || math.Abs(d.AveragePosition-w.avg) > 1e-9 {
+			t.Errorf("row %d: got %+v, want %+v", i, d, w)
+		}
+		if math.Abs(d.Share-w.visibility/2.3) > 1e-9 {
+			t.Errorf("%s share: got %v", d.Domain, d.Share)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := rep.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[0] != "domain,share,visibility,best_position,average_position,news,search" || lines[1] != "a.com,0.5217,1.2000,1,1.67,1,2" {
+		t.Errorf("CSV:\n%s", buf.String())
+	}
+	if text := rep.Text(1); !strings.Contains(text, "a.com") || strings.Contains(text, "b.co.uk") || !strings.Contains(text, "52.2%") {
+		t.Errorf("Text:\n%s", text)
+	}
+}
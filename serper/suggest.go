@@ -0,0 +1,189 @@
+package serper
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const defaultSuggestConcurrency = 4
+
+// DefaultSuggestQuestions are the words DiscoverSuggestions puts before the
+// seed, e.g. "how espresso".
+var DefaultSuggestQuestions = []string{"how", "why", "what", "when", "where", "who", "which", "can", "is", "are", "does", "will", "should"}
+
+// DefaultSuggestComparisons are the words DiscoverSuggestions puts after
+// the seed, e.g. "espresso vs".
+var DefaultSuggestComparisons = []string{"vs", "versus", "or", "and", "for", "with", "without", "near", "like"}
+
+// SuggestConfig configures DiscoverSuggestions.
+type SuggestConfig struct {
+	Seed   string
+	Market Market
+	// Questions go before the seed and Comparisons after it. Nil uses
+	// DefaultSuggestQuestions and DefaultSuggestComparisons; an empty,
+	// non-nil slice disables them.
+	Questions   []string
+	Comparisons []string
+	Concurrency int           // parallel requests (default 4)
+	Budget      *CreditBudget // nil means unlimited; responses served from the client cache are free
+	Sink        Sink          // optional; receives EventSuggestComplete
+}
+
+// SuggestExpansion is one autocomplete query made from a seed.
+type SuggestExpansion struct {
+	Prefix string // "" for the seed alone, else the letter, digit or word added
+	Query  string
+}
+
+// SuggestExpansions returns the autocomplete queries for seed: the seed
+// itself, the seed followed by each letter a-z and digit 0-9, each question
+// word followed by the seed, and the seed followed by each comparison word.
+func SuggestExpansions(seed string, questions, comparisons []string) []SuggestExpansion {
+	seed = strings.Join(strings.Fields(seed), " ")
+	out := []SuggestExpansion{{Query: seed}}
+	for _, r := range "abcdefghijklmnopqrstuvwxyz0123456789" {
+		out = append(out, SuggestExpansion{Prefix: string(r), Query: seed + " " + string(r)})
+	}
+	for _, q := range questions {
+		out = append(out, SuggestExpansion{Prefix: q, Query: q + " " + seed})
+	}
+	for _, c := range comparisons {
+		out = append(out, SuggestExpansion{Prefix: c, Query: seed + " " + c})
+	}
+	return out
+}
+
+// KeywordSuggestion is a deduplicated autocomplete suggestion.
+type KeywordSuggestion struct {
+	Value string `json:"value"`
+	// Prefix and Query identify the first expansion that returned the
+	// suggestion, and Rank is its 1-based place in that list.
+	Prefix string `json:"prefix"`
+	Query  string `json:"query"`
+	Rank   int    `json:"rank"`
+	// Count is how many expansions returned the suggestion.
+	Count int `json:"count"`
+}
+
+// SuggestResult is the outcome of DiscoverSuggestions.
+type SuggestResult struct {
+	Suggestions []KeywordSuggestion `json:"suggestions"`
+	// Failed lists the expansion queries whose request failed.
+	Failed []KeywordFailure `json:"failed,omitempty"`
+}
+
+// DiscoverSuggestions runs every SuggestExpansions query for cfg.Seed
+// through Autocomplete and merges the suggestions. Suggestions are
+// deduplicated after case, whitespace and trailing punctuation are folded,
+// and ordered by the expansion that first returned them, then by rank. The
+// seed itself is left out.
+//
+// A failed expansion, including one refused by the budget, is reported in
+// Failed. Only an empty seed or a cancelled context returns an error. If
+// cfg.Sink is set the result is sent to it; a failed notification is
+// returned together with the result.
+func (c *Client) DiscoverSuggestions(ctx context.Context, cfg SuggestConfig) (*SuggestResult, error) {
+	if strings.TrimSpace(cfg.Seed) == "" {
+		return nil, fmt.Errorf("serper: suggestions: seed must not be empty")
+	}
+	if cfg.Questions == nil {
+		cfg.Questions = DefaultSuggestQuestions
+	}
+	if cfg.Comparisons == nil {
+		cfg.Comparisons = DefaultSuggestComparisons
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaultSuggestConcurrency
+	}
+	expansions := SuggestExpansions(cfg.Seed, cfg.Questions, cfg.Comparisons)
+
+	resps := make([]*AutocompleteResponse, len(expansions))
+	errs := make([]error, len(expansions))
+	sem := make(chan struct{}, cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i, e := range expansions {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			req := &SearchRequest{Q: e.Query, GL: cfg.Market.GL, HL: cfg.Market.HL, Location: cfg.Market.Location}
+			if err := c.reserveUncached(ctx, cfg.Budget, "/autocomplete", req); err != nil {
+				errs[i] = err
+				return
+			}
+			resps[i], errs[i] = c.Autocomplete(ctx, req)
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	res := &SuggestResult{}
+	index := map[string]int{normalizeQuery(cfg.Seed): -1}
+	for i, e := range expansions {
+		if errs[i] != nil {
+			res.Failed = append(res.Failed, KeywordFailure{Keyword: e.Query, Error: errs[i].Error()})
+			continue
+		}
+		seen := make(map[string]bool) // count each suggestion once per expansion
+		for rank, s := range resps[i].Suggestions {
+			key := normalizeQuery(s.Value)
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			j, ok := index[key]
+			switch {
+			case !ok:
+				index[key] = len(res.Suggestions)
+				res.Suggestions = append(res.Suggestions, KeywordSuggestion{
+					Value:  strings.TrimSpace(s.Value),
+					Prefix: e.Prefix,
+					Query:  e.Query,
+					Rank:   rank + 1,
+					Count:  1,
+				})
+			case j >= 0:
+				res.Suggestions[j].Count++
+			}
+		}
+	}
+	if err := Notify(ctx, cfg.Sink, EventSuggestComplete, res); err != nil {
+		return res, fmt.Errorf("serper: suggestions: notify: %w", err)
+	}
+	return res, nil
+}
+
+// WriteCSV writes one row per suggestion: suggestion, prefix, query, rank,
+// count, error. Failed expansions come last, with only query and error
+// set.
+func (r *SuggestResult) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"suggestion", "prefix", "query", "rank", "count", "error"}); err != nil {
+		return err
+	}
+	for _, s := range r.Suggestions {
+		if err := cw.Write([]string{s.Value, s.Prefix, s.Query, strconv.Itoa(s.Rank), strconv.Itoa(s.Count), ""}); err != nil {
+			return err
+		}
+	}
+	for _, f := range r.Failed {
+		if err := cw.Write([]string{"", "", f.Keyword, "", "", f.Error}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,121 @@
+package serper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// suggestResponses builds fakeDoer responses carrying the given
+// autocomplete suggestions, keyed by query.
+func suggestResponses(suggestions map[string][]string) map[string]any {
+	out := make(map[string]any, len(suggestions))
+	for q, values := range suggestions {
+		var resp AutocompleteResponse
+		for _, v := range values {
+			resp.Suggestions = append(resp.Suggestions, Suggestion{Value: v})
+		}
+		out[q] = resp
+	}
+	return out
+}
+
+func TestSuggestExpansions(t *testing.T) {
+	got := SuggestExpansions("  espresso  machine ", []string{"how"}, []string{"vs"})
+	if len(got) != 1+26+10+2 {
+		t.Fatalf("expansions: got %d", len(got))
+	}
+	for i, want := range map[int]SuggestExpansion{
+		0:  {Prefix: "", Query: "espresso machine"},
+		1:  {Prefix: "a", Query: "espresso machine a"},
+		36: {Prefix: "9", Query: "espresso machine 9"},
+		37: {Prefix: "how", Query: "how espresso machine"},
+		38: {Prefix: "vs", Query: "espresso machine vs"},
+	} {
+		if got[i] != want {
+			t.Errorf("expansion %d: got %+v, want %+v", i, got[i], want)
+		}
+	}
+}
+
+func TestDiscoverSuggestions(t *testing.T) {
+	suggestions := map[string][]string{
+		"espresso":     {"Espresso", "espresso machine", "espresso martini"},
+		"espresso b":   {"espresso beans", "espresso machine", "espresso beans"},
+		"how espresso": {"how espresso is made?", "Espresso Beans"},
+	}
+	responses := suggestResponses(suggestions)
+	responses["espresso vs"] = errors.New("unreachable")
+	var calls atomic.Int32
+	c := mustNew(t, "key", WithDoer(fakeDoer(responses, &calls)))
+	res, err := c.DiscoverSuggestions(context.Background(), SuggestConfig{
+		Seed:        "espresso",
+		Questions:   []string{"how"},
+		Comparisons: []string{"vs"},
+	})
+	if err != nil {
+		t.Fatalf("DiscoverSuggestions: %v", err)
+	}
+	if calls.Load() != 39 {
+		t.Errorf("calls: got %d, want 39", calls.Load())
+	}
+	want := []KeywordSuggestion{
+		{Value: "espresso machine", Prefix: "", Query: "espresso", Rank: 2, Count: 2},
+		{Value: "espresso martini", Prefix: "", Query: "espresso", Rank: 3, Count: 1},
+		{Value: "espresso beans", Prefix: "b", Query: "espresso b", Rank: 1, Count: 2},
+		{Value: "how espresso is made?", Prefix: "how", Query: "how espresso", Rank: 1, Count: 1},
+	}
+	if len(res.Suggestions) != len(want) {
+		t.Fatalf("suggestions: got %+v", res.Suggestions)
+	}
+	for i := range want {
+		if res.Suggestions[i] != want[i] {
+			t.Errorf("suggestion %d: got %+v, want %+v", i, res.Suggestions[i], want[i])
+		}
+	}
+	if len(res.Failed) != 1 || res.Failed[0].Keyword != "espresso vs" {
+		t.Errorf("failed: got %+v", res.Failed)
+	}
+
+	var buf bytes.Buffer
+	if err := res.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[0] != "suggestion,prefix,query,rank,count,error" ||
+		lines[3] != "espresso beans,b,espresso b,1,2," ||
+		!strings.HasPrefix(lines[5], ",,espresso vs,,,") {
+		t.Errorf("CSV:\n%s", buf.String())
+	}
+}
+
+func TestDiscoverSuggestions_BudgetAndCache(t *testing.T) {
+	var calls atomic.Int32
+	c := mustNew(t, "key", WithDoer(fakeDoer(nil, &calls)), WithCache(NewMemoryCache(time.Hour, 0)))
+	cfg := SuggestConfig{Seed: "espresso", Questions: []string{}, Comparisons: []string{}, Budget: NewCreditBudget(30)}
+	res, err := c.DiscoverSuggestions(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("DiscoverSuggestions: %v", err)
+	}
+	if calls.Load() != 30 || len(res.Failed) != 7 || !strings.Contains(res.Failed[0].Error, ErrCreditLimit.Error()) {
+		t.Errorf("first run: %d calls, failed %+v", calls.Load(), res.Failed)
+	}
+
+	// Cached expansions are free, so seven more credits finish the job.
+	cfg.Budget = NewCreditBudget(7)
+	res, err = c.DiscoverSuggestions(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("DiscoverSuggestions: %v", err)
+	}
+	if calls.Load() != 37 || len(res.Failed) != 0 {
+		t.Errorf("second run: %d calls, failed %+v", calls.Load(), res.Failed)
+	}
+
+	if _, err := c.DiscoverSuggestions(context.Background(), SuggestConfig{Seed: " "}); err == nil {
+		t.Error("expected an error for an empty seed")
+	}
+}
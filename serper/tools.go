@@ -0,0 +1,227 @@
+package serper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	chassiserrors "github.com/ai8future/chassis-go/v11/errors"
+)
+
+// JSONSchema is the subset of JSON Schema used for tool parameters.
+type JSONSchema struct {
+	Type                 string                 `json:"type"`
+	Description          string                 `json:"description,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Minimum              *int                   `json:"minimum,omitempty"`
+	Maximum              *int                   `json:"maximum,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+}
+
+// ToolDefinition describes a search as a tool a language model can call,
+// in the generic name, description and parameters form.
+type ToolDefinition struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Parameters  *JSONSchema `json:"parameters"`
+}
+
+// OpenAITool is a tool in the OpenAI function-calling format.
+type OpenAITool struct {
+	Type     string         `json:"type"` // always "function"
+	Function ToolDefinition `json:"function"`
+}
+
+// AnthropicTool is a tool in the Anthropic tool-use format.
+type AnthropicTool struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	InputSchema *JSONSchema `json:"input_schema"`
+}
+
+// tool is one search exposed to language models. omit lists the request
+// fields, by JSON name, that the endpoint does not use.
+type tool struct {
+	name        string
+	description string
+	omit        []string
+	call        func(ctx context.Context, c *Client, req *SearchRequest) (any, error)
+}
+
+// toolCall adapts a Client search method to tool.call.
+func toolCall[T any](method func(*Client, context.Context, *SearchRequest) (*T, error)) func(context.Context, *Client, *SearchRequest) (any, error) {
+	return func(ctx context.Context, c *Client, req *SearchRequest) (any, error) {
+		return method(c, ctx, req)
+	}
+}
+
+// tools lists the searches in the order ToolDefinitions returns them.
+var tools = []tool{
+	{"serper_search", "Search the web with Google. Returns the answer box, knowledge graph, top results with snippets, and related questions, as numbered sources.", []string{"ll"}, toolCall((*Client).Search)},
+	{"serper_news", "Search Google News for recent articles. Returns headlines, sources, dates and snippets as numbered sources.", []string{"ll"}, toolCall((*Client).News)},
+	{"serper_images", "Search Google Images. Returns image titles, sources and page links.", []string{"ll"}, toolCall((*Client).Images)},
+	{"serper_videos", "Search Google Videos. Returns titles, channels, durations and links.", []string{"ll"}, toolCall((*Client).Videos)},
+	{"serper_places", "Search Google Maps for businesses and places. Returns names, addresses, ratings, phone numbers and websites.", nil, toolCall((*Client).Places)},
+	{"serper_scholar", "Search Google Scholar for academic papers. Returns titles, publication details, citation counts and snippets.", []string{"ll"}, toolCall((*Client).Scholar)},
+	{"serper_shopping", "Search Google Shopping for products. Returns titles, prices, sellers and ratings.", []string{"ll"}, toolCall((*Client).Shopping)},
+	{"serper_autocomplete", "Get Google's autocomplete suggestions for a partial query, one per line.", []string{"num", "page", "ll"}, toolCall((*Client).Autocomplete)},
+}
+
+// ToolDefinitions returns a tool for each search vertical and
+// autocomplete. Parameter schemas are generated from SearchRequest, so
+// they follow its fields and limits.
+func ToolDefinitions() []ToolDefinition {
+	out := make([]ToolDefinition, len(tools))
+	for i, t := range tools {
+		out[i] = ToolDefinition{Name: t.name, Description: t.description, Parameters: requestSchema(t.omit)}
+	}
+	return out
+}
+
+// OpenAITools returns ToolDefinitions in the OpenAI format.
+func OpenAITools() []OpenAITool {
+	defs := ToolDefinitions()
+	out := make([]OpenAITool, len(defs))
+	for i, d := range defs {
+		out[i] = OpenAITool{Type: "function", Function: d}
+	}
+	return out
+}
+
+// AnthropicTools returns ToolDefinitions in the Anthropic format.
+func AnthropicTools() []AnthropicTool {
+	defs := ToolDefinitions()
+	out := make([]AnthropicTool, len(defs))
+	for i, d := range defs {
+		out[i] = AnthropicTool{Name: d.Name, Description: d.Description, InputSchema: d.Parameters}
+	}
+	return out
+}
+
+// requestSchema builds the object schema of SearchRequest from its json,
+// desc, minimum and maximum tags, leaving out the fields in omit. Fields
+// without omitempty are required.
+func requestSchema(omit []string) *JSONSchema {
+	closed := false
+	s := &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema), AdditionalProperties: &closed}
+	rt := reflect.TypeOf(SearchRequest{})
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "" || name == "-" || slices.Contains(omit, name) {
+			continue
+		}
+		prop := &JSONSchema{Type: schemaType(f.Type.Kind()), Description: f.Tag.Get("desc")}
+		prop.Minimum = tagInt(f.Tag.Get("minimum"))
+		prop.Maximum = tagInt(f.Tag.Get("maximum"))
+		s.Properties[name] = prop
+		if !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// schemaType returns the JSON Schema type of a Go kind.
+func schemaType(k reflect.Kind) string {
+	switch k {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return "string"
+}
+
+// tagInt parses an integer struct tag value, or returns nil if it is empty.
+func tagInt(v string) *int {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+// Dispatcher runs tool calls made by a language model against a Client.
+// It is safe for concurrent use.
+type Dispatcher struct {
+	client *Client
+	format ContextConfig
+}
+
+// NewDispatcher returns a dispatcher calling c. Results are formatted with
+// BuildContext under cfg, in ContextText unless cfg.Format says otherwise.
+func NewDispatcher(c *Client, cfg ContextConfig) *Dispatcher {
+	if cfg.Format == "" {
+		cfg.Format = ContextText
+	}
+	return &Dispatcher{client: c, format: cfg}
+}
+
+// Call runs the tool named name with its JSON arguments and returns the
+// result formatted for the model. Arguments are checked against the tool's
+// schema, defaulted and validated with SearchRequest.Validate before any
+// request is made. An unknown tool is a NotFoundError and bad arguments are
+// a ValidationError; API failures keep the Client's typed errors.
+func (d *Dispatcher) Call(ctx context.Context, name string, args json.RawMessage) (*LLMContext, error) {
+	i := slices.IndexFunc(tools, func(t tool) bool { return t.name == name })
+	if i < 0 {
+		return nil, chassiserrors.NotFoundError(fmt.Sprintf("serper: unknown tool %q", name))
+	}
+	t := tools[i]
+	req, err := decodeToolArgs(args, requestSchema(t.omit))
+	if err != nil {
+		return nil, err
+	}
+	req, err = prepareRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.call(ctx, d.client, req)
+	if err != nil {
+		return nil, err
+	}
+	if ac, ok := resp.(*AutocompleteResponse); ok {
+		values := make([]string, 0, len(ac.Suggestions))
+		for _, s := range ac.Suggestions {
+			values = append(values, s.Value)
+		}
+		return &LLMContext{Text: strings.Join(values, "\n"), Citations: map[int]string{}}, nil
+	}
+	return BuildContext(resp, d.format)
+}
+
+// decodeToolArgs decodes tool arguments into a request, rejecting
+// properties the schema does not list. Empty arguments decode as {}.
+func decodeToolArgs(args json.RawMessage, schema *JSONSchema) (*SearchRequest, error) {
+	if len(bytes.TrimSpace(args)) == 0 {
+		args = json.RawMessage("{}")
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(args, &fields); err != nil {
+		return nil, chassiserrors.ValidationError(fmt.Sprintf("serper: tool arguments must be a JSON object: %v", err))
+	}
+	for k := range fields {
+		if _, ok := schema.Properties[k]; !ok {
+			return nil, chassiserrors.ValidationError(fmt.Sprintf("serper: unknown tool argument %q", k))
+		}
+	}
+	var req SearchRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, chassiserrors.ValidationError(fmt.Sprintf("serper: invalid tool arguments: %v", err))
+	}
+	return &req, nil
+}
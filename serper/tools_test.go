@@ -0,0 +1,123 @@
+package serper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	chassiserrors "github.com/ai8future/chassis-go/v11/errors"
+)
+
+func TestToolDefinitions(t *testing.T) {
+	defs := ToolDefinitions()
+	var names []string
+	for _, d := range defs {
+		names = append(names, d.Name)
+	}
+	want := []string{"serper_search", "serper_news", "serper_images", "serper_videos", "serper_places", "serper_scholar", "serper_shopping", "serper_autocomplete"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("names = %v, want %v", names, want)
+	}
+
+	search := defs[0].Parameters
+	if search.Type != "object" || !reflect.DeepEqual(search.Required, []string{"q"}) || *search.AdditionalProperties {
+		t.Errorf("search schema: %+v", search)
+	}
+	num := search.Properties["num"]
+	if num == nil || num.Type != "integer" || *num.Minimum != 1 || *num.Maximum != 100 || num.Description == "" {
+		t.Errorf("num property: %+v", num)
+	}
+	if _, ok := search.Properties["ll"]; ok {
+		t.Error("search schema should not accept ll")
+	}
+	if _, ok := defs[4].Parameters.Properties["ll"]; !ok {
+		t.Error("places schema should accept ll")
+	}
+	var acProps []string
+	for k := range defs[7].Parameters.Properties {
+		acProps = append(acProps, k)
+	}
+	if len(acProps) != 4 {
+		t.Errorf("autocomplete properties = %v, want q, gl, hl, location", acProps)
+	}
+}
+
+func TestToolFormats(t *testing.T) {
+	openai, err := json.Marshal(OpenAITools()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(openai), `{"type":"function","function":{"name":"serper_search","description":`) ||
+		!strings.Contains(string(openai), `"parameters":{"type":"object"`) {
+		t.Errorf("OpenAI tool: %s", openai)
+	}
+	anthropic, err := json.Marshal(AnthropicTools()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(anthropic), `{"name":"serper_search","description":`) ||
+		!strings.Contains(string(anthropic), `"input_schema":{"type":"object"`) {
+		t.Errorf("Anthropic tool: %s", anthropic)
+	}
+}
+
+func TestDispatcher_Call(t *testing.T) {
+	doer := &mockDoer{statusCode: 200, respBody: `{"searchParameters":{"q":"go"},"organic":[{"title":"Go","link":"https://go.dev","snippet":"Build fast."}]}`}
+	d := NewDispatcher(mustNew(t, "key", WithDoer(doer)), ContextConfig{})
+
+	got, err := d.Call(context.Background(), "serper_search", json.RawMessage(`{"q":"go","num":5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Text != "Search results for \"go\"\n\n[1] Go\nBuild fast.\n" || got.Citations[1] != "https://go.dev" {
+		t.Errorf("got %+v", got)
+	}
+	if doer.req.URL.Path != "/search" || !strings.Contains(string(doer.body), `"num":5`) {
+		t.Errorf("request: %s %s", doer.req.URL.Path, doer.body)
+	}
+}
+
+func TestDispatcher_Autocomplete(t *testing.T) {
+	doer := &mockDoer{statusCode: 200, respBody: `{"suggestions":[{"value":"go tutorial"},{"value":"go vs rust"}]}`}
+	d := NewDispatcher(mustNew(t, "key", WithDoer(doer)), ContextConfig{})
+	got, err := d.Call(context.Background(), "serper_autocomplete", json.RawMessage(`{"q":"go"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Text != "go tutorial\ngo vs rust" || doer.req.URL.Path != "/autocomplete" {
+		t.Errorf("got %q from %s", got.Text, doer.req.URL.Path)
+	}
+}
+
+func TestDispatcher_Errors(t *testing.T) {
+	doer := &mockDoer{statusCode: 200, respBody: `{}`}
+	d := NewDispatcher(mustNew(t, "key", WithDoer(doer)), ContextConfig{})
+	tests := []struct {
+		name, tool, args string
+		code             int
+	}{
+		{"unknown tool", "serper_maps", `{"q":"x"}`, 404},
+		{"not an object", "serper_search", `["x"]`, 400},
+		{"missing query", "serper_search", ``, 400},
+		{"unknown argument", "serper_search", `{"q":"x","safe":true}`, 400},
+		{"argument omitted for tool", "serper_news", `{"q":"x","ll":"@1,2,3z"}`, 400},
+		{"wrong type", "serper_search", `{"q":"x","num":"ten"}`, 400},
+		{"out of range", "serper_search", `{"q":"x","num":500}`, 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doer.req = nil
+			_, err := d.Call(context.Background(), tt.tool, json.RawMessage(tt.args))
+			var se *chassiserrors.ServiceError
+			if !errors.As(err, &se) || se.HTTPCode != tt.code {
+				t.Fatalf("got %v, want a %d service error", err, tt.code)
+			}
+			if doer.req != nil {
+				t.Error("request sent for invalid call")
+			}
+		})
+	}
+}
@@ -8,22 +8,30 @@ import (
 )
 
 // SearchRequest represents a search request to Serper.dev.
+// The desc, minimum and maximum tags describe the fields in the JSON
+// Schemas of ToolDefinitions.
 type SearchRequest struct {
-	Q        string `json:"q"`
-	Num      int    `json:"num,omitempty"`
-	GL       string `json:"gl,omitempty"`
-	HL       string `json:"hl,omitempty"`
-	Location string `json:"location,omitempty"`
-	Page     int    `json:"page,omitempty"`
+	Q        string `json:"q" desc:"Search query"`
+	Num      int    `json:"num,omitempty" desc:"Number of results (default 10)" minimum:"1" maximum:"100"`
+	GL       string `json:"gl,omitempty" desc:"Two-letter country code to search from, e.g. us (default us)"`
+	HL       string `json:"hl,omitempty" desc:"Two-letter language code of the results, e.g. en (default en)"`
+	Location string `json:"location,omitempty" desc:"Place to search from, e.g. Austin, Texas, United States"`
+	Page     int    `json:"page,omitempty" desc:"Page of results (default 1)" minimum:"1"`
+	LL       string `json:"ll,omitempty" desc:"Map position as @latitude,longitude,zoom, e.g. @40.7128,-74.006,14z"`
 }
 
 // SearchResponse represents the response from Serper.dev search endpoint.
 type SearchResponse struct {
 	SearchParameters SearchParameters `json:"searchParameters"`
+	AnswerBox        *AnswerBox       `json:"answerBox,omitempty"`
 	KnowledgeGraph   *KnowledgeGraph  `json:"knowledgeGraph,omitempty"`
 	Organic          []OrganicResult  `json:"organic"`
 	PeopleAlsoAsk    []PeopleAlsoAsk  `json:"peopleAlsoAsk,omitempty"`
+	TopStories       []TopStory       `json:"topStories,omitempty"`
+	Images           []ImageResult    `json:"images,omitempty"` // image pack
+	Videos           []VideoResult    `json:"videos,omitempty"` // video carousel
 	RelatedSearches  []RelatedSearch  `json:"relatedSearches,omitempty"`
+	Credits          int              `json:"credits,omitempty"` // credits charged, as reported by Serper
 }
 
 // SearchParameters contains the echoed search parameters.
@@ -32,10 +40,21 @@ type SearchParameters struct {
 	GL     string `json:"gl"`
 	HL     string `json:"hl"`
 	Num    int    `json:"num"`
+	Page   int    `json:"page,omitempty"`
 	Type   string `json:"type"`
 	Engine string `json:"engine"`
 }
 
+// AnswerBox is the featured snippet or direct answer shown above the
+// organic results.
+type AnswerBox struct {
+	Title   string `json:"title,omitempty"`
+	Answer  string `json:"answer,omitempty"`
+	Snippet string `json:"snippet,omitempty"`
+	Link    string `json:"link,omitempty"`
+	Date    string `json:"date,omitempty"`
+}
+
 // KnowledgeGraph contains knowledge graph data.
 type KnowledgeGraph struct {
 	Title       string            `json:"title"`
@@ -70,6 +89,15 @@ type PeopleAlsoAsk struct {
 	Link     string `json:"link"`
 }
 
+// TopStory represents an entry in the "Top stories" news carousel.
+type TopStory struct {
+	Title    string `json:"title"`
+	Link     string `json:"link"`
+	Source   string `json:"source"`
+	Date     string `json:"date,omitempty"`
+	ImageURL string `json:"imageUrl,omitempty"`
+}
+
 // RelatedSearch represents a related search suggestion.
 type RelatedSearch struct {
 	Query string `json:"query"`
@@ -79,6 +107,7 @@ type RelatedSearch struct {
 type ImagesResponse struct {
 	SearchParameters SearchParameters `json:"searchParameters"`
 	Images           []ImageResult    `json:"images"`
+	Credits          int              `json:"credits,omitempty"` // credits charged, as reported by Serper
 }
 
 // ImageResult represents a single image search result.
@@ -95,6 +124,7 @@ type ImageResult struct {
 type NewsResponse struct {
 	SearchParameters SearchParameters `json:"searchParameters"`
 	News             []NewsResult     `json:"news"`
+	Credits          int              `json:"credits,omitempty"` // credits charged, as reported by Serper
 }
 
 // NewsResult represents a single news search result.
@@ -112,6 +142,7 @@ type NewsResult struct {
 type PlacesResponse struct {
 	SearchParameters SearchParameters `json:"searchParameters"`
 	Places           []PlaceResult    `json:"places"`
+	Credits          int              `json:"credits,omitempty"` // credits charged, as reported by Serper
 }
 
 // PlaceResult represents a single place search result.
@@ -133,6 +164,7 @@ type PlaceResult struct {
 type ScholarResponse struct {
 	SearchParameters SearchParameters `json:"searchParameters"`
 	Organic          []ScholarResult  `json:"organic"`
+	Credits          int              `json:"credits,omitempty"` // credits charged, as reported by Serper
 }
 
 // ScholarResult represents a single scholar search result.
@@ -144,6 +176,8 @@ type ScholarResult struct {
 	CitedBy         int      `json:"citedBy"`
 	Authors         []string `json:"authors,omitempty"`
 	Year            int      `json:"year,omitempty"`
+	Venue           string   `json:"venue,omitempty"`     // parsed from PublicationInfo
+	Publisher       string   `json:"publisher,omitempty"` // parsed from PublicationInfo
 	Position        int      `json:"position"`
 }
 
@@ -151,6 +185,7 @@ type ScholarResult struct {
 type ShoppingResponse struct {
 	SearchParameters SearchParameters `json:"searchParameters"`
 	Shopping         []ShoppingResult `json:"shopping"`
+	Credits          int              `json:"credits,omitempty"` // credits charged, as reported by Serper
 }
 
 // ShoppingResult represents a single shopping search result.
@@ -170,6 +205,7 @@ type ShoppingResult struct {
 type VideosResponse struct {
 	SearchParameters SearchParameters `json:"searchParameters"`
 	Videos           []VideoResult    `json:"videos"`
+	Credits          int              `json:"credits,omitempty"` // credits charged, as reported by Serper
 }
 
 // VideoResult represents a single video search result.
@@ -185,6 +221,18 @@ type VideoResult struct {
 	Position int    `json:"position"`
 }
 
+// AutocompleteResponse represents the response from Serper.dev autocomplete endpoint.
+type AutocompleteResponse struct {
+	SearchParameters SearchParameters `json:"searchParameters"`
+	Suggestions      []Suggestion     `json:"suggestions"`
+	Credits          int              `json:"credits,omitempty"` // credits charged, as reported by Serper
+}
+
+// Suggestion represents a single autocomplete suggestion.
+type Suggestion struct {
+	Value string `json:"value"`
+}
+
 // SetDefaults applies default values to a SearchRequest.
 func (r *SearchRequest) SetDefaults() {
 	if r.Num == 0 {
@@ -0,0 +1,251 @@
+package serper
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Volatility defaults.
+const (
+	defaultVolatilityDepth       = 10
+	defaultVolatilityPersistence = 0.9
+)
+
+// VolatilityConfig configures ComputeVolatility.
+type VolatilityConfig struct {
+	TopN        int            // organic results compared per snapshot (default 10)
+	Persistence float64        // RBO persistence p in (0, 1); default 0.9 weights the top ranks most
+	TimeZone    *time.Location // zone that defines a day (default UTC)
+}
+
+// KeywordChange is the change in one keyword's top results between two
+// consecutive snapshots.
+type KeywordChange struct {
+	Key        SnapshotKey `json:"key"`
+	From       time.Time   `json:"from"`
+	To         time.Time   `json:"to"`
+	Overlap    float64     `json:"overlap"`    // rank-biased overlap, 1 = identical
+	Volatility float64     `json:"volatility"` // 1 - Overlap
+}
+
+// VolatilityIndex is the mean volatility of a market's keywords on one day.
+type VolatilityIndex struct {
+	Day        string  `json:"day"` // YYYY-MM-DD in the configured zone
+	Market     Market  `json:"market"`
+	Keywords   int     `json:"keywords"`   // keywords with a change observed that day
+	Volatility float64 `json:"volatility"` // mean over keywords, 0 (stable) to 1 (all results replaced)
+	Max        float64 `json:"max"`        // most volatile keyword that day
+}
+
+// VolatilityReport holds the per-day index and the changes it was built
+// from, so a spike can be traced to individual keywords.
+type VolatilityReport struct {
+	Days    []VolatilityIndex `json:"days"`
+	Changes []KeywordChange   `json:"changes"`
+}
+
+// Text renders the daily index as a plain-text table, one line per day and
+// market.
+func (r *VolatilityReport) Text() string {
+	var b strings.Builder
+	if len(r.Days) == 0 {
+		b.WriteString("No changes (need at least two snapshots per keyword)\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%-10s  %-20s  %10s  %8s  %6s\n", "Day", "Market", "Volatility", "Keywords", "Max")
+	for _, d := range r.Days {
+		fmt.Fprintf(&b, "%-10s  %-20s  %10.3f  %8d  %6.3f\n", d.Day, d.Market, d.Volatility, d.Keywords, d.Max)
+	}
+	return b.String()
+}
+
+// VolatilityFromStore loads the history of each key from store and
+// computes its volatility. Keys for verticals other than "search" are
+// ignored.
+func VolatilityFromStore(ctx context.Context, store SnapshotStore, keys []SnapshotKey, cfg VolatilityConfig) (*VolatilityReport, error) {
+	var all []Snapshot
+	for _, k := range keys {
+		history, err := store.History(ctx, k)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, history...)
+	}
+	return ComputeVolatility(all, cfg)
+}
+
+// ComputeVolatility compares each keyword's consecutive web search
+// snapshots by the rank-biased overlap of their top results. Each change
+// counts towards the day of the later snapshot; a keyword changing several
+// times in a day contributes its mean. Days are then averaged per market
+// (gl, hl and location). Only first pages are compared: snapshots of
+// later pages, by key or by the page Serper echoed in the response, are
+// skipped, as are snapshots of other verticals. A snapshot whose response
+// cannot be decoded returns an error.
+func ComputeVolatility(snapshots []Snapshot, cfg VolatilityConfig) (*VolatilityReport, error) {
+	if cfg.TopN <= 0 {
+		cfg.TopN = defaultVolatilityDepth
+	}
+	if cfg.Persistence <= 0 || cfg.Persistence >= 1 {
+		cfg.Persistence = defaultVolatilityPersistence
+	}
+	if cfg.TimeZone == nil {
+		cfg.TimeZone = time.UTC
+	}
+
+	type series struct {
+		key   SnapshotKey
+		snaps []Snapshot
+	}
+	byKey := make(map[SnapshotKey]*series)
+	var order []*series
+	for _, s := range snapshots {
+		key := s.SnapshotKey.Normalize()
+		if key.Vertical != "search" || key.Page != 0 {
+			continue
+		}
+		sr, ok := byKey[key]
+		if !ok {
+			sr = &series{key: key}
+			byKey[key] = sr
+			order = append(order, sr)
+		}
+		sr.snaps = append(sr.snaps, s)
+	}
+
+	rep := &VolatilityReport{}
+	for _, sr := range order {
+		sort.SliceStable(sr.snaps, func(i, j int) bool { return sr.snaps[i].Time.Before(sr.snaps[j].Time) })
+		var prev []string
+		var prevTime time.Time
+		for _, s := range sr.snaps {
+			var resp SearchResponse
+			if err := s.Decode(&resp); err != nil {
+				return nil, err
+			}
+			// Histories saved before snapshots were keyed by page mix
+			// later pages in with the first.
+			if resp.SearchParameters.Page > 1 {
+				continue
+			}
+			links := topLinks(resp.Organic, cfg.TopN)
+			if prev != nil {
+				overlap := RankBiasedOverlap(prev, links, cfg.Persistence, cfg.TopN)
+				rep.Changes = append(rep.Changes, KeywordChange{
+					Key:        sr.key,
+					From:       prevTime,
+					To:         s.Time,
+					Overlap:    overlap,
+					Volatility: 1 - overlap,
+				})
+			}
+			prev, prevTime = links, s.Time
+		}
+	}
+	rep.Days = rollUpVolatility(rep.Changes, cfg.TimeZone)
+	return rep, nil
+}
+
+// rollUpVolatility averages changes per keyword and day, then per market
+// and day. The result is ordered by day, then market.
+func rollUpVolatility(changes []KeywordChange, loc *time.Location) []VolatilityIndex {
+	type dayKey struct {
+		day    string
+		market Market
+	}
+	type keywordDay struct {
+		dayKey
+		query string
+	}
+	sums := make(map[keywordDay]float64)
+	counts := make(map[keywordDay]int)
+	for _, c := range changes {
+		k := keywordDay{
+			dayKey: dayKey{day: c.To.In(loc).Format(time.DateOnly), market: Market{GL: c.Key.GL, HL: c.Key.HL, Location: c.Key.Location}},
+			query:  c.Key.Query,
+		}
+		sums[k] += c.Volatility
+		counts[k]++
+	}
+
+	days := make(map[dayKey]*VolatilityIndex)
+	for k, sum := range sums {
+		v := sum / float64(counts[k])
+		idx, ok := days[k.dayKey]
+		if !ok {
+			idx = &VolatilityIndex{Day: k.day, Market: k.market}
+			days[k.dayKey] = idx
+		}
+		idx.Keywords++
+		idx.Volatility += v
+		idx.Max = math.Max(idx.Max, v)
+	}
+	out := make([]VolatilityIndex, 0, len(days))
+	for _, idx := range days {
+		idx.Volatility /= float64(idx.Keywords)
+		out = append(out, *idx)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Day != out[j].Day {
+			return out[i].Day < out[j].Day
+		}
+		return out[i].Market.String() < out[j].Market.String()
+	})
+	return out
+}
+
+// topLinks returns the normalised links of the first n organic results.
+func topLinks(results []OrganicResult, n int) []string {
+	ranked := rankedOrganic(results)
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	links := make([]string, len(ranked))
+	for i, r := range ranked {
+		links[i] = normalizeLink(r.Link)
+	}
+	return links
+}
+
+// RankBiasedOverlap returns the extrapolated rank-biased overlap (Webber,
+// Moffat and Zobel, 2010) of two rankings evaluated to depth, between 0
+// (disjoint) and 1 (identical). Persistence p in (0, 1) sets how steeply
+// weight falls with rank: with p = 0.9 the top 10 ranks carry about 86% of
+// the weight. Depth is capped at the longer ranking; places missing from
+// the shorter one count as distinct items. Two empty rankings are identical.
+func RankBiasedOverlap(a, b []string, p float64, depth int) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	if longest := max(len(a), len(b)); depth <= 0 || depth > longest {
+		depth = longest
+	}
+	seenA := make(map[string]bool, depth)
+	seenB := make(map[string]bool, depth)
+	overlap := 0
+	sum := 0.0
+	weight := 1.0 // p^d
+	for d := 1; d <= depth; d++ {
+		if d <= len(a) {
+			x := a[d-1]
+			if seenB[x] && !seenA[x] {
+				overlap++
+			}
+			seenA[x] = true
+		}
+		if d <= len(b) {
+			y := b[d-1]
+			if seenA[y] && !seenB[y] {
+				overlap++
+			}
+			seenB[y] = true
+		}
+		weight *= p
+		sum += float64(overlap) / float64(d) * weight
+	}
+	return float64(overlap)/float64(depth)*weight + (1-p)/p*sum
+}
@@ -0,0 +1,171 @@
+package serper
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRankBiasedOverlap(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  []string
+		depth int
+		want  float64
+	}{
+		{"identical", []string{"a", "b", "c"}, []string{"a", "b", "c"}, 3, 1},
+		{"disjoint", []string{"a", "b", "c"}, []string{"x", "y", "z"}, 3, 0},
+		{"both empty", nil, nil, 10, 1},
+		{"one empty", []string{"a"}, nil, 10, 0},
+		// Overlap 0 then 2: 1*0.81 + (0.1/0.9)*(0 + 1*0.81).
+		{"top two swapped", []string{"a", "b"}, []string{"b", "a"}, 2, 0.9},
+		{"depth capped at longest", []string{"a"}, []string{"a"}, 10, 1},
+		// The missing second place counts as a difference.
+		{"uneven lengths", []string{"a"}, []string{"a", "b"}, 10, 0.5*0.81 + 0.1/0.9*(0.9+0.5*0.81)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RankBiasedOverlap(tt.a, tt.b, 0.9, tt.depth)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankBiasedOverlap_TopWeighted(t *testing.T) {
+	base := []string{"a", "b", "c", "d", "e"}
+	topChanged := RankBiasedOverlap(base, []string{"x", "b", "c", "d", "e"}, 0.9, 5)
+	bottomChanged := RankBiasedOverlap(base, []string{"a", "b", "c", "d", "x"}, 0.9, 5)
+	if topChanged >= bottomChanged {
+		t.Errorf("a change at rank 1 (%v) should weigh more than at rank 5 (%v)", topChanged, bottomChanged)
+	}
+}
+
+func organicSnapshot(t *testing.T, key SnapshotKey, at time.Time, links ...string) Snapshot {
+	t.Helper()
+	var resp SearchResponse
+	for _, l := range links {
+		resp.Organic = append(resp.Organic, OrganicResult{Link: "https://" + l})
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Snapshot{SnapshotKey: key, Time: at, Response: body}
+}
+
+func TestComputeVolatility(t *testing.T) {
+	at := func(d, h int) time.Time { return time.Date(2026, 3, d, h, 0, 0, 0, time.UTC) }
+	coffee := SnapshotKey{Query: "coffee", GL: "us", HL: "en"}
+	tea := SnapshotKey{Query: "tea", GL: "us", HL: "en"}
+	teaDE := SnapshotKey{Query: "tea", GL: "de", HL: "de"}
+
+	snaps := []Snapshot{
+		// Out of order on purpose.
+		organicSnapshot(t, coffee, at(2, 9), "x.com", "y.com"),
+		organicSnapshot(t, coffee, at(1, 9), "a.com", "b.com"),
+		organicSnapshot(t, coffee, at(2, 18), "x.com", "y.com"),
+		organicSnapshot(t, tea, at(1, 9), "t.com", "u.com"),
+		organicSnapshot(t, tea, at(2, 9), "T.com/", "u.com"),
+		organicSnapshot(t, teaDE, at(1, 9), "t.de"),
+		organicSnapshot(t, teaDE, at(2, 9), "s.de"),
+		{SnapshotKey: SnapshotKey{Query: "coffee", Vertical: "news"}, Time: at(2, 9), Response: []byte(`not json`)},
+	}
+	rep, err := ComputeVolatility(snaps, VolatilityConfig{TopN: 2})
+	if err != nil {
+		t.Fatalf("ComputeVolatility: %v", err)
+	}
+	if len(rep.Changes) != 4 {
+		t.Fatalf("changes: got %d, want 4: %+v", len(rep.Changes), rep.Changes)
+	}
+	if c := rep.Changes[0]; c.Key.Query != "coffee" || !c.From.Equal(at(1, 9)) || c.Volatility != 1 {
+		t.Errorf("first coffee change: got %+v", c)
+	}
+	if c := rep.Changes[1]; c.Volatility != 0 {
+		t.Errorf("unchanged coffee results should have zero volatility, got %+v", c)
+	}
+
+	if len(rep.Days) != 2 {
+		t.Fatalf("days: got %+v", rep.Days)
+	}
+	de, us := rep.Days[0], rep.Days[1]
+	if de.Day != "2026-03-02" || de.Market.GL != "de" || de.Keywords != 1 || de.Volatility != 1 {
+		t.Errorf("de: got %+v", de)
+	}
+	// coffee averages its two changes that day (1 and 0); tea is unchanged
+	// once links are normalised.
+	if us.Market.GL != "us" || us.Keywords != 2 || us.Volatility != 0.25 || us.Max != 0.5 {
+		t.Errorf("us: got %+v", us)
+	}
+}
+
+func TestComputeVolatility_SkipsLaterPages(t *testing.T) {
+	at := func(d, h int) time.Time { return time.Date(2026, 3, d, h, 0, 0, 0, time.UTC) }
+	key := SnapshotKey{Query: "coffee"}
+	page2 := SnapshotKey{Query: "coffee", Page: 2}
+	// Saved before snapshots were keyed by page: page 2 shares page 1's key
+	// and is told apart only by the page Serper echoed.
+	legacy := organicSnapshot(t, key, at(1, 10), "k.com", "l.com")
+	legacy.Response = []byte(`{"searchParameters":{"q":"coffee","page":2},"organic":[{"link":"https://k.com"},{"link":"https://l.com"}]}`)
+
+	snaps := []Snapshot{
+		organicSnapshot(t, key, at(1, 9), "a.com", "b.com"),
+		legacy,
+		organicSnapshot(t, page2, at(2, 8), "m.com", "n.com"),
+		organicSnapshot(t, key, at(2, 9), "a.com", "b.com"),
+		organicSnapshot(t, page2, at(2, 10), "o.com", "p.com"),
+	}
+	rep, err := ComputeVolatility(snaps, VolatilityConfig{TopN: 2})
+	if err != nil {
+		t.Fatalf("ComputeVolatility: %v", err)
+	}
+	if len(rep.Changes) != 1 {
+		t.Fatalf("changes: got %d, want 1: %+v", len(rep.Changes), rep.Changes)
+	}
+	if c := rep.Changes[0]; !c.From.Equal(at(1, 9)) || !c.To.Equal(at(2, 9)) || c.Volatility != 0 {
+		t.Errorf("change: got %+v, want page 1 against page 1", c)
+	}
+}
+
+func TestComputeVolatility_TimeZone(t *testing.T) {
+	key := SnapshotKey{Query: "coffee"}
+	snaps := []Snapshot{
+		organicSnapshot(t, key, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), "a.com"),
+		organicSnapshot(t, key, time.Date(2026, 3, 2, 2, 0, 0, 0, time.UTC), "b.com"),
+	}
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	rep, err := ComputeVolatility(snaps, VolatilityConfig{TimeZone: ny})
+	if err != nil {
+		t.Fatalf("ComputeVolatility: %v", err)
+	}
+	if len(rep.Days) != 1 || rep.Days[0].Day != "2026-03-01" {
+		t.Errorf("days: got %+v", rep.Days)
+	}
+}
+
+func TestVolatilityFromStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewJSONLSnapshotStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := SnapshotKey{Query: "coffee"}
+	for i, link := range []string{"a.com", "b.com"} {
+		if err := store.Save(ctx, organicSnapshot(t, key, time.Date(2026, 3, 1+i, 0, 0, 0, 0, time.UTC), link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rep, err := VolatilityFromStore(ctx, store, []SnapshotKey{key}, VolatilityConfig{})
+	if err != nil {
+		t.Fatalf("VolatilityFromStore: %v", err)
+	}
+	if len(rep.Days) != 1 || rep.Days[0].Volatility <= 0.5 {
+		t.Errorf("days: got %+v", rep.Days)
+	}
+}
@@ -0,0 +1,311 @@
+package serper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// defaultWatchRetention is how long a seen link is remembered when
+// WatchConfig.Retention is zero.
+const defaultWatchRetention = 30 * 24 * time.Hour
+
+// WatchConfig configures a news Watcher.
+//
+// The watcher needs fresh results on every poll, so use a client without a
+// cache, or one whose cache TTL is shorter than Interval.
+type WatchConfig struct {
+	// Queries are re-run on every poll. Each query keeps its own set of
+	// seen links, so an article matching two queries is reported for both.
+	Queries []SearchRequest
+
+	// Interval is the time between polls; Jitter randomises it by up to
+	// +/-Jitter*Interval (0.1 = +/-10%) so many watchers do not poll in step.
+	Interval time.Duration
+	Jitter   float64
+
+	// StatePath is a JSON file remembering seen links across runs. It is
+	// read by NewWatcher and rewritten after every poll. Empty keeps state
+	// in memory only.
+	StatePath string
+
+	// Retention is how long a link is remembered after it last appeared in
+	// the results; default 30 days.
+	Retention time.Duration
+
+	// Baseline records the results of a query's first poll as seen without
+	// reporting them, so only articles published after the watch started
+	// are emitted.
+	Baseline bool
+
+	// Budget caps the credits spent. Run stops with ErrCreditLimit once a
+	// poll cannot be afforded. Nil is unlimited.
+	Budget *CreditBudget
+
+	// OnNew is called for every new item, in result order.
+	OnNew func(WatchItem)
+
+	// Sink, if set, receives one EventWatchNewItems event per poll that
+	// found new items.
+	Sink Sink
+
+	// OnError is called by Run with errors from polls that did not stop the
+	// watch, such as a failed query. Nil drops them.
+	OnError func(error)
+}
+
+// WatchItem is a news result not seen before by the watcher.
+type WatchItem struct {
+	Query string `json:"query"`
+	NewsResult
+	FoundAt time.Time `json:"foundAt"`
+}
+
+// Watcher re-runs news queries and reports articles it has not seen before.
+type Watcher struct {
+	client *Client
+	cfg    WatchConfig
+	now    func() time.Time
+
+	mu    sync.Mutex
+	state watchState
+	items chan WatchItem
+}
+
+// watchState is the on-disk state: per query, the normalised links seen
+// and when each last appeared.
+type watchState struct {
+	Queries map[string]map[string]time.Time `json:"queries"`
+}
+
+// NewWatcher returns a watcher for cfg, loading seen links from
+// cfg.StatePath if the file exists.
+func (c *Client) NewWatcher(cfg WatchConfig) (*Watcher, error) {
+	if len(cfg.Queries) == 0 {
+		return nil, fmt.Errorf("serper: watch: no queries")
+	}
+	for i := range cfg.Queries {
+		req := cfg.Queries[i]
+		req.SetDefaults()
+		if err := req.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Interval <= 0 {
+		return nil, fmt.Errorf("serper: watch: interval must be positive")
+	}
+	if cfg.Jitter < 0 || cfg.Jitter >= 1 {
+		return nil, fmt.Errorf("serper: watch: jitter must be in [0, 1)")
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = defaultWatchRetention
+	}
+	w := &Watcher{
+		client: c,
+		cfg:    cfg,
+		now:    time.Now,
+		state:  watchState{Queries: make(map[string]map[string]time.Time)},
+	}
+	if cfg.StatePath != "" {
+		data, err := os.ReadFile(cfg.StatePath)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return nil, fmt.Errorf("serper: watch: read state: %w", err)
+		default:
+			if err := json.Unmarshal(data, &w.state); err != nil {
+				return nil, fmt.Errorf("serper: watch: parse state %s: %w", cfg.StatePath, err)
+			}
+			if w.state.Queries == nil {
+				w.state.Queries = make(map[string]map[string]time.Time)
+			}
+		}
+	}
+	return w, nil
+}
+
+// Items returns a channel that receives every new item found by Run. It is
+// closed when Run returns. Once Items has been called the channel must be
+// drained, or Run blocks until its context is cancelled.
+func (w *Watcher) Items() <-chan WatchItem {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.items == nil {
+		w.items = make(chan WatchItem, 64)
+	}
+	return w.items
+}
+
+// Run polls immediately and then every jittered interval until ctx is
+// cancelled or the credit budget runs out. It returns ctx.Err() or
+// ErrCreditLimit.
+func (w *Watcher) Run(ctx context.Context) error {
+	w.mu.Lock()
+	items := w.items
+	w.mu.Unlock()
+	if items != nil {
+		defer close(items)
+	}
+
+	for {
+		found, err := w.Poll(ctx)
+		if items != nil {
+			for _, item := range found {
+				select {
+				case items <- item:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		switch {
+		case errors.Is(err, ErrCreditLimit):
+			return ErrCreditLimit
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil && w.cfg.OnError != nil:
+			w.cfg.OnError(err)
+		}
+
+		timer := time.NewTimer(w.nextInterval())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Poll runs every query once, calls OnNew for each new item, notifies the
+// sink and saves the state. It returns the new items together with the joined errors of any
+// failed queries. If the budget cannot cover a query, Poll stops there and
+// the error wraps ErrCreditLimit.
+func (w *Watcher) Poll(ctx context.Context) ([]WatchItem, error) {
+	var found []WatchItem
+	var errs []error
+	for i := range w.cfg.Queries {
+		req := w.cfg.Queries[i]
+		if err := w.cfg.Budget.Reserve(RequestCredits(&req)); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		resp, err := w.client.News(ctx, &req)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("serper: watch %q: %w", req.Q, err))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		found = append(found, w.record(&req, resp.News)...)
+	}
+	if err := w.save(); err != nil {
+		errs = append(errs, err)
+	}
+	for _, item := range found {
+		if w.cfg.OnNew != nil {
+			w.cfg.OnNew(item)
+		}
+	}
+	if w.cfg.Sink != nil && len(found) > 0 {
+		if err := w.cfg.Sink.Send(ctx, Event{Type: EventWatchNewItems, Time: found[0].FoundAt, Data: found}); err != nil {
+			errs = append(errs, fmt.Errorf("serper: watch: notify: %w", err))
+		}
+	}
+	return found, errors.Join(errs...)
+}
+
+// record marks results as seen for req and returns the ones that were new.
+func (w *Watcher) record(req *SearchRequest, results []NewsResult) []WatchItem {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := w.now().UTC()
+	key := watchKey(req)
+	seen, known := w.state.Queries[key]
+	if !known {
+		seen = make(map[string]time.Time)
+		w.state.Queries[key] = seen
+	}
+	var found []WatchItem
+	for _, r := range results {
+		if r.Link == "" {
+			continue
+		}
+		link := normalizeLink(r.Link)
+		_, ok := seen[link]
+		seen[link] = now
+		if ok || !known && w.cfg.Baseline {
+			continue
+		}
+		found = append(found, WatchItem{Query: req.Q, NewsResult: r, FoundAt: now})
+	}
+	return found
+}
+
+// watchKey identifies a query in the state file. Market and location are
+// part of the key, so the same query watched in two countries is tracked
+// separately.
+func watchKey(req *SearchRequest) string {
+	cp := *req
+	cp.SetDefaults()
+	key := cp.Q + " | " + cp.GL + "-" + cp.HL
+	if cp.Location != "" {
+		key += " | " + cp.Location
+	}
+	return key
+}
+
+// save forgets links not seen within Retention and writes the state file
+// atomically via a temporary file in the same directory.
+func (w *Watcher) save() error {
+	w.mu.Lock()
+	cutoff := w.now().Add(-w.cfg.Retention)
+	for _, seen := range w.state.Queries {
+		for link, at := range seen {
+			if at.Before(cutoff) {
+				delete(seen, link)
+			}
+		}
+	}
+	data, err := json.MarshalIndent(w.state, "", "  ")
+	w.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("serper: watch: marshal state: %w", err)
+	}
+	if w.cfg.StatePath == "" {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(w.cfg.StatePath), filepath.Base(w.cfg.StatePath)+".*")
+	if err != nil {
+		return fmt.Errorf("serper: watch: write state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("serper: watch: write state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("serper: watch: write state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), w.cfg.StatePath); err != nil {
+		return fmt.Errorf("serper: watch: write state: %w", err)
+	}
+	return nil
+}
+
+// nextInterval returns Interval randomised by up to +/-Jitter.
+func (w *Watcher) nextInterval() time.Duration {
+	if w.cfg.Jitter == 0 {
+		return w.cfg.Interval
+	}
+	offset := (rand.Float64()*2 - 1) * w.cfg.Jitter
+	return time.Duration(float64(w.cfg.Interval) * (1 + offset))
+}
@@ -0,0 +1,160 @@
+package serper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newsSequence returns a doer serving the given news bodies in turn,
+// repeating the last one.
+func newsSequence(bodies ...string) (Doer, *int) {
+	calls := 0
+	return doerFunc(func(*http.Request) (*http.Response, error) {
+		body := bodies[min(calls, len(bodies)-1)]
+		calls++
+		return jsonResponse(body), nil
+	}), &calls
+}
+
+const (
+	newsPollOne = `{"news":[{"title":"A","link":"https://a.example/1"},{"title":"B","link":"https://b.example/2"}]}`
+	newsPollTwo = `{"news":[{"title":"C","link":"https://c.example/3"},{"title":"A again","link":"https://a.example/1#top"},{"title":"B","link":"https://b.example/2"}]}`
+)
+
+func TestWatcher_PollEmitsOnlyNewItems(t *testing.T) {
+	doer, _ := newsSequence(newsPollOne, newsPollTwo)
+	c := mustNew(t, "key", WithDoer(doer))
+	statePath := filepath.Join(t.TempDir(), "watch.json")
+
+	var emitted []string
+	w, err := c.NewWatcher(WatchConfig{
+		Queries:   []SearchRequest{{Q: "acme"}},
+		Interval:  time.Minute,
+		StatePath: statePath,
+		OnNew:     func(item WatchItem) { emitted = append(emitted, item.Title) },
+	})
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+	if _, err := w.Poll(context.Background()); err != nil {
+		t.Fatalf("first poll: %v", err)
+	}
+	found, err := w.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("second poll: %v", err)
+	}
+	if len(found) != 1 || found[0].Title != "C" || found[0].Query != "acme" {
+		t.Errorf("second poll: got %+v", found)
+	}
+	if got := len(emitted); got != 3 {
+		t.Errorf("OnNew calls: got %d (%v), want 3", got, emitted)
+	}
+
+	// A new watcher on the same state file remembers every link.
+	w2, err := c.NewWatcher(WatchConfig{Queries: []SearchRequest{{Q: "acme"}}, Interval: time.Minute, StatePath: statePath})
+	if err != nil {
+		t.Fatalf("NewWatcher from state: %v", err)
+	}
+	if found, _ := w2.Poll(context.Background()); len(found) != 0 {
+		t.Errorf("restored watcher should find nothing new, got %+v", found)
+	}
+}
+
+func TestWatcher_Baseline(t *testing.T) {
+	doer, _ := newsSequence(newsPollOne, newsPollTwo)
+	c := mustNew(t, "key", WithDoer(doer))
+	w, err := c.NewWatcher(WatchConfig{Queries: []SearchRequest{{Q: "acme"}}, Interval: time.Minute, Baseline: true})
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+	if found, _ := w.Poll(context.Background()); len(found) != 0 {
+		t.Errorf("baseline poll should emit nothing, got %+v", found)
+	}
+	if found, _ := w.Poll(context.Background()); len(found) != 1 {
+		t.Errorf("second poll: got %+v, want only C", found)
+	}
+}
+
+func TestWatcher_RetentionForgetsLinksNoLongerReturned(t *testing.T) {
+	const onlyC = `{"news":[{"title":"C","link":"https://c.example/3"}]}`
+	doer, _ := newsSequence(newsPollOne, newsPollOne, onlyC, newsPollOne, onlyC, newsPollOne)
+	c := mustNew(t, "key", WithDoer(doer))
+	w, err := c.NewWatcher(WatchConfig{Queries: []SearchRequest{{Q: "acme"}}, Interval: time.Minute, Retention: time.Hour})
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	polls := []struct {
+		at   time.Duration
+		want int
+	}{
+		{0, 2},                 // A, B
+		{50 * time.Minute, 0},  // A, B again: last seen refreshed
+		{100 * time.Minute, 1}, // C
+		{110 * time.Minute, 0}, // A, B still remembered from the second poll
+		{200 * time.Minute, 0}, // C; A and B are now forgotten
+		{210 * time.Minute, 2}, // A, B reported again
+	}
+	for i, p := range polls {
+		w.now = func() time.Time { return start.Add(p.at) }
+		found, err := w.Poll(context.Background())
+		if err != nil {
+			t.Fatalf("poll %d: %v", i, err)
+		}
+		if len(found) != p.want {
+			t.Errorf("poll %d: got %d new items, want %d", i, len(found), p.want)
+		}
+	}
+}
+
+func TestWatcher_RunStopsAtCreditLimit(t *testing.T) {
+	doer, calls := newsSequence(newsPollOne, newsPollTwo)
+	c := mustNew(t, "key", WithDoer(doer))
+	w, err := c.NewWatcher(WatchConfig{
+		Queries:  []SearchRequest{{Q: "acme"}},
+		Interval: time.Millisecond,
+		Jitter:   0.5,
+		Budget:   NewCreditBudget(2),
+	})
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+	items := w.Items()
+	done := make(chan error, 1)
+	go func() { done <- w.Run(context.Background()) }()
+
+	var titles []string
+	for item := range items {
+		titles = append(titles, item.Title)
+	}
+	if err := <-done; !errors.Is(err, ErrCreditLimit) {
+		t.Errorf("Run: got %v, want ErrCreditLimit", err)
+	}
+	if *calls != 2 || len(titles) != 3 {
+		t.Errorf("calls=%d titles=%v", *calls, titles)
+	}
+}
+
+func TestNewWatcher_Validation(t *testing.T) {
+	c := mustNew(t, "key")
+	tests := []struct {
+		name string
+		cfg  WatchConfig
+	}{
+		{"no queries", WatchConfig{Interval: time.Minute}},
+		{"empty query", WatchConfig{Queries: []SearchRequest{{}}, Interval: time.Minute}},
+		{"no interval", WatchConfig{Queries: []SearchRequest{{Q: "x"}}}},
+		{"jitter too large", WatchConfig{Queries: []SearchRequest{{Q: "x"}}, Interval: time.Minute, Jitter: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := c.NewWatcher(tt.cfg); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}